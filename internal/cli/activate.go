@@ -2,20 +2,47 @@ package cli
 
 import (
 	"fmt"
-	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/blackwell-systems/dotclaude/internal/profile"
+	"github.com/blackwell-systems/dotclaude/internal/terminal"
 	"github.com/spf13/cobra"
 )
 
 func newActivateCmd() *cobra.Command {
 	var dryRun bool
 	var verbose bool
+	var force bool
+	var project string
+	var claudeMD string
+	var scan bool
 
 	cmd := &cobra.Command{
-		Use:     "activate <profile-name>",
-		Short:   "Activate a profile",
-		Long:    "Activate a dotclaude profile by merging base + profile configuration.",
+		Use:   "activate <profile-name>[+<profile-name>...]",
+		Short: "Activate a profile",
+		Long: `Activate a dotclaude profile by merging base + profile configuration.
+
+Several profiles can be activated together as a stack, e.g. work+golang:
+CLAUDE.md sections are concatenated in order, settings are deep-merged with
+later profiles taking precedence, and agents and hooks are combined (a later
+profile's file replaces an earlier one of the same name).
+
+By default the profile is deployed to ~/.claude and applies everywhere. With
+--project it is deployed to the .claude directory of the current project
+(the git work tree containing the current directory, or --project=<dir>)
+instead: CLAUDE.md, settings.json and agents are written there, hooks stay
+global, and the generated files are added to .git/info/exclude. Files git
+already tracks there (e.g. a committed .claude/settings.json) are not
+overwritten without --force.
+
+With --claude-md=managed, dotclaude only owns a block of CLAUDE.md between
+BEGIN/END markers; notes you add above or below it survive every switch.
+--claude-md=replace goes back to writing the whole file. The mode is kept
+for later activations.
+
+With --scan, activation is refused if base or the profile contains
+possible secrets (see 'dotclaude scan').`,
 		Aliases: []string{"use"},
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
@@ -39,16 +66,26 @@ func newActivateCmd() *cobra.Command {
 				verbose = true
 			}
 
-			mgr := profile.NewManager(RepoDir, ClaudeDir)
+			mgr, err := scopedManager(project)
+			if err != nil {
+				return err
+			}
 
-			// Validate profile name
-			if err := profile.ValidateProfileName(profileName); err != nil {
+			// Validate profile or stack name
+			if err := profile.ValidateStackName(profileName); err != nil {
 				return fmt.Errorf("Invalid profile name: %w", err)
 			}
 
-			// Check if profile exists
-			if !mgr.ProfileExists(profileName) {
-				return fmt.Errorf("profile '%s' not found", profileName)
+			// Check if the profiles exist
+			if err := mgr.StackExists(profileName); err != nil {
+				return err
+			}
+
+			var mode profile.CLAUDEmdMode
+			if claudeMD != "" {
+				if mode, err = profile.ParseCLAUDEmdMode(claudeMD); err != nil {
+					return err
+				}
 			}
 
 			// Get current active profile
@@ -56,13 +93,41 @@ func newActivateCmd() *cobra.Command {
 
 			// Handle dry-run mode
 			if dryRun {
-				return showPreview(mgr, profileName, currentProfile, verbose)
+				return showPreview(mgr, profileName, currentProfile, mode, verbose)
+			}
+
+			// Deployed files edited by hand are only overwritten on request
+			if !force {
+				drift, err := mgr.Drift()
+				if err != nil {
+					return err
+				}
+				var modified []profile.FileDrift
+				for _, f := range drift {
+					if f.Status == profile.DriftModified {
+						modified = append(modified, f)
+					}
+				}
+				if len(modified) > 0 {
+					if !terminal.StdinIsTerminal() {
+						return &profile.DriftError{Files: modified}
+					}
+					overwrite, err := confirmDriftOverwrite(mgr, profileName, modified)
+					if err != nil {
+						return err
+					}
+					if !overwrite {
+						fmt.Println("Cancelled. Run 'dotclaude status --diff' to review the changes.")
+						return nil
+					}
+					force = true
+				}
 			}
 
 			// Handle verbose mode
 			if verbose {
 				fmt.Printf("[DEBUG] RepoDir: %s\n", RepoDir)
-				fmt.Printf("[DEBUG] ClaudeDir: %s\n", ClaudeDir)
+				fmt.Printf("[DEBUG] ClaudeDir: %s\n", mgr.ClaudeDir)
 				fmt.Printf("[DEBUG] ProfilesDir: %s\n", mgr.ProfilesDir)
 				fmt.Printf("[DEBUG] Current profile: %s\n", currentProfile)
 				fmt.Printf("[DEBUG] Target profile: %s\n", profileName)
@@ -82,20 +147,33 @@ func newActivateCmd() *cobra.Command {
 
 			// Show backup message if switching profiles
 			if currentProfile != "" && currentProfile != profileName {
-				fmt.Printf("  [1/3] Backing up existing configuration...\n")
+				fmt.Printf("  [1/4] Backing up existing configuration...\n")
 			} else if currentProfile == profileName {
-				fmt.Printf("  [1/3] Already on '%s', updating in place\n", profileName)
+				fmt.Printf("  [1/4] Already on '%s', updating in place\n", profileName)
 			} else {
-				fmt.Printf("  [1/3] No existing configuration to backup\n")
+				fmt.Printf("  [1/4] No existing configuration to backup\n")
+			}
+
+			agents, err := mgr.ListAgents(profileName)
+			if err != nil {
+				return err
+			}
+			var hookFiles []*profile.HookFile
+			if !mgr.IsProject() {
+				hookFiles, err = mgr.ListHooks(profileName)
+				if err != nil {
+					return err
+				}
 			}
 
 			// Activate the profile
-			if err := mgr.Activate(profileName); err != nil {
+			if err := mgr.ActivateWithOptions(profileName, profile.ActivateOptions{Force: force, CLAUDEmd: mode, Scan: scan}); err != nil {
 				return err
 			}
 
-			fmt.Printf("  [2/3] Merged base + profile configuration\n")
-			fmt.Printf("  [3/3] Applied profile settings\n")
+			fmt.Printf("  [2/4] Merged base + profile configuration\n")
+			fmt.Printf("  [3/4] Applied profile settings\n")
+			fmt.Printf("  [4/4] Deployed %d agent(s) and %d hook(s)\n", len(agents), len(hookFiles))
 
 			// Success message
 			fmt.Println()
@@ -103,11 +181,15 @@ func newActivateCmd() *cobra.Command {
 			fmt.Printf("│  ✓ Profile Activated: %-41s│\n", profileName)
 			fmt.Println("╰─────────────────────────────────────────────────────────────╯")
 			fmt.Println()
-			fmt.Printf("  Configuration deployed to: %s\n", ClaudeDir)
+			fmt.Printf("  Configuration deployed to: %s\n", mgr.ClaudeDir)
 			fmt.Println()
 			fmt.Println("  Verify with:")
-			fmt.Println("    • dotclaude show")
-			fmt.Printf("    • cat %s/CLAUDE.md\n", ClaudeDir)
+			if mgr.IsProject() {
+				fmt.Println("    • dotclaude show --project")
+			} else {
+				fmt.Println("    • dotclaude show")
+			}
+			fmt.Printf("    • cat %s\n", filepath.Join(mgr.ClaudeDir, "CLAUDE.md"))
 			fmt.Println()
 
 			return nil
@@ -119,12 +201,16 @@ func newActivateCmd() *cobra.Command {
 	cmd.Flags().Bool("preview", false, "Alias for --dry-run")
 	cmd.Flags().BoolVar(&verbose, "verbose", false, "Show debug output")
 	cmd.Flags().Bool("debug", false, "Alias for --verbose")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Overwrite deployed files that were edited by hand, or tracked by git in project scope")
+	cmd.Flags().StringVar(&claudeMD, "claude-md", "", "How to write CLAUDE.md: managed (only a marked block) or replace (the whole file); default keeps the current mode")
+	cmd.Flags().BoolVar(&scan, "scan", false, "Refuse to activate if the profile contains possible secrets")
+	addProjectFlag(cmd, &project, "Deploy into the project's .claude directory instead of ~/.claude")
 
 	return cmd
 }
 
 // showPreview displays what would happen without making changes
-func showPreview(mgr *profile.Manager, profileName, currentProfile string, verbose bool) error {
+func showPreview(mgr *profile.Manager, profileName, currentProfile string, mode profile.CLAUDEmdMode, verbose bool) error {
 	fmt.Println()
 	fmt.Println("╭─────────────────────────────────────────────────────────────╮")
 	fmt.Printf("│  DRY RUN - Preview Mode                                     │\n")
@@ -132,6 +218,33 @@ func showPreview(mgr *profile.Manager, profileName, currentProfile string, verbo
 	fmt.Println()
 
 	fmt.Printf("Would activate profile: %s\n", profileName)
+	fmt.Printf("Target: %s\n", mgr.ClaudeDir)
+	fmt.Println()
+
+	if profile.IsStack(profileName) {
+		fmt.Printf("Stack (later profiles take precedence): %s\n", strings.Join(profile.SplitStack(profileName), " + "))
+	}
+
+	// Show resolved inheritance chain
+	chain, err := mgr.ResolveChain(profileName)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Inheritance chain: %s\n", strings.Join(append([]string{"base"}, chain...), " → "))
+
+	// Show the host overlays that match this machine
+	overlays, err := mgr.HostOverlays(profileName)
+	if err != nil {
+		return err
+	}
+	if len(overlays) == 0 {
+		fmt.Printf("Host overlays: none for %s\n", profile.CurrentHost())
+	} else {
+		fmt.Printf("Host overlays for %s:\n", profile.CurrentHost())
+		for _, overlay := range overlays {
+			fmt.Printf("  • %s\n", overlay)
+		}
+	}
 	fmt.Println()
 
 	// Show current state
@@ -148,30 +261,145 @@ func showPreview(mgr *profile.Manager, profileName, currentProfile string, verbo
 	}
 	fmt.Println()
 
+	// Show hand edits activation would refuse to overwrite
+	drift, err := mgr.Drift()
+	if err != nil {
+		return err
+	}
+	var modified []string
+	for _, f := range drift {
+		if f.Status == profile.DriftModified {
+			modified = append(modified, f.Path)
+		}
+	}
+	if len(modified) > 0 {
+		fmt.Println(Yellow("Locally modified deployed files (activation requires --force):"))
+		for _, path := range modified {
+			fmt.Printf("  • %s\n", path)
+		}
+		fmt.Println()
+	}
+
 	// Show what would be merged
+	claudeSources, err := mgr.CLAUDEmdSources(profileName)
+	if err != nil {
+		return err
+	}
+	templated := false
 	fmt.Println("Files that would be merged:")
-	fmt.Printf("  • base/CLAUDE.md\n")
-	fmt.Printf("  • profiles/%s/CLAUDE.md\n", profileName)
+	for _, source := range claudeSources {
+		if strings.HasSuffix(source, profile.TemplateSuffix) {
+			templated = true
+			fmt.Printf("  • %s (template)\n", source)
+		} else {
+			fmt.Printf("  • %s\n", source)
+		}
+	}
+	if mode == "" {
+		state, err := mgr.LoadState()
+		if err != nil {
+			return err
+		}
+		mode = state.CLAUDEmdMode()
+	}
+	if mode == profile.CLAUDEmdManaged {
+		fmt.Println("  → written into the managed block of CLAUDE.md; content outside it is kept")
+	}
 	fmt.Println()
 
+	// Render CLAUDE.md so template errors surface before activation
+	claudeMD, err := mgr.MergedCLAUDEmd(profileName)
+	if err != nil {
+		return fmt.Errorf("failed to merge CLAUDE.md: %w", err)
+	}
+	included := strings.Contains(string(claudeMD), "<!-- begin include: ")
+	if templated || included || verbose {
+		fmt.Println("Rendered CLAUDE.md:")
+		fmt.Println("─────────────────────────────────────────────────────────────")
+		fmt.Print(string(claudeMD))
+		if !strings.HasSuffix(string(claudeMD), "\n") {
+			fmt.Println()
+		}
+		fmt.Println("─────────────────────────────────────────────────────────────")
+		fmt.Println()
+	}
+
 	// Show settings
 	fmt.Println("Settings:")
-	profileSettingsPath := fmt.Sprintf("%s/profiles/%s/settings.json", mgr.RepoDir, profileName)
-	baseSettingsPath := fmt.Sprintf("%s/base/settings.json", mgr.RepoDir)
-
-	if fileExists(profileSettingsPath) {
-		fmt.Printf("  • Would use profile settings: profiles/%s/settings.json\n", profileName)
-	} else if fileExists(baseSettingsPath) {
-		fmt.Println("  • Would use base settings: base/settings.json")
-	} else {
+	sources, err := mgr.SettingsSources(profileName)
+	if err != nil {
+		return err
+	}
+	if len(sources) == 0 {
 		fmt.Println("  • No settings found")
+	} else {
+		settings, err := mgr.MergedSettings(profileName)
+		if err != nil {
+			return err
+		}
+		if len(sources) == 1 {
+			fmt.Printf("  • Would use settings: %s\n", sources[0])
+		} else {
+			fmt.Println("  • Would deep-merge (later files override earlier ones):")
+			for _, source := range sources {
+				fmt.Printf("      %s\n", source)
+			}
+		}
+		refs, err := mgr.SecretRefs(profileName)
+		if err != nil {
+			return err
+		}
+		if len(refs) > 0 {
+			fmt.Println("  • Secret references (resolved on activation; values are never shown):")
+			for _, ref := range refs {
+				fmt.Printf("      %s ← %s\n", ref.Path, ref)
+			}
+		}
+		if verbose {
+			fmt.Println()
+			fmt.Println("[DEBUG] Merged settings.json (unresolved):")
+			fmt.Print(string(settings))
+		}
+	}
+	fmt.Println()
+
+	// Show agents
+	agents, err := mgr.ListAgents(profileName)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Agents:")
+	if len(agents) == 0 {
+		fmt.Println("  • No agents found")
+	}
+	for _, agent := range agents {
+		fmt.Printf("  • %s (from %s)\n", agent.Name, agent.Layer)
+	}
+	fmt.Println()
+
+	// Show hooks
+	hookFiles, err := mgr.ListHooks(profileName)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Hooks:")
+	if mgr.IsProject() {
+		fmt.Println("  • Not deployed in project scope (hooks stay global)")
+		hookFiles = nil
+	} else if len(hookFiles) == 0 {
+		fmt.Println("  • No hooks found")
+	}
+	for _, hook := range hookFiles {
+		fmt.Printf("  • %s (from %s)\n", hook.RelPath(), hook.Layer)
 	}
 	fmt.Println()
 
 	// Show verbose details if requested
 	if verbose {
 		fmt.Println("[DEBUG] Preview Details:")
-		fmt.Printf("[DEBUG]   Profile path: %s/profiles/%s\n", mgr.RepoDir, profileName)
+		for _, name := range profile.SplitStack(profileName) {
+			fmt.Printf("[DEBUG]   Profile path: %s/profiles/%s\n", mgr.RepoDir, name)
+		}
 		fmt.Printf("[DEBUG]   Claude dir: %s\n", mgr.ClaudeDir)
 		fmt.Printf("[DEBUG]   Would create backup: %v\n", currentProfile != "" && currentProfile != profileName)
 		fmt.Println()
@@ -182,14 +410,12 @@ func showPreview(mgr *profile.Manager, profileName, currentProfile string, verbo
 	fmt.Println("╰─────────────────────────────────────────────────────────────╯")
 	fmt.Println()
 	fmt.Println("To apply these changes, run without --dry-run:")
-	fmt.Printf("  dotclaude activate %s\n", profileName)
+	if mgr.IsProject() {
+		fmt.Printf("  dotclaude activate %s --project=%s\n", profileName, mgr.ProjectDir)
+	} else {
+		fmt.Printf("  dotclaude activate %s\n", profileName)
+	}
 	fmt.Println()
 
 	return nil
 }
-
-// fileExists checks if a file exists
-func fileExists(path string) bool {
-	_, err := os.Stat(path)
-	return err == nil
-}
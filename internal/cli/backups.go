@@ -0,0 +1,200 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/blackwell-systems/dotclaude/internal/profile"
+	"github.com/spf13/cobra"
+)
+
+// newBackupsCmd returns the backups parent command
+func newBackupsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backups",
+		Short: "Manage backup storage and retention",
+		Long: `Manage the snapshots taken before every profile switch, forced activation,
+deactivation and restore.
+
+Snapshots are kept in <claude-dir>/.dotclaude-backups. File contents are
+stored once by their hash, so a snapshot of files that haven't changed
+costs almost nothing. Old snapshots are removed according to the retention
+policy after every new one:
+
+  --keep N        Keep the N most recent snapshots (default 20)
+  --max-age AGE   Remove snapshots older than AGE (30d, 2w, 36h)
+  --max-size SIZE Keep the snapshots within SIZE of storage (500K, 100M, 1G)
+
+A limit of 0 is no limit, and the most recent snapshot is always kept.
+Use 'dotclaude restore' to restore one.`,
+	}
+
+	cmd.AddCommand(
+		newBackupsPruneCmd(),
+		newBackupsRetentionCmd(),
+	)
+
+	return cmd
+}
+
+// newBackupsPruneCmd returns the backups prune command
+func newBackupsPruneCmd() *cobra.Command {
+	var dryRun bool
+	var limits retentionFlags
+	var project string
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove snapshots the retention policy doesn't keep",
+		Long: `Remove the snapshots the retention policy doesn't keep, and the stored
+file contents no remaining snapshot uses, and report the space reclaimed.
+
+Limits given as flags replace the stored policy's for this run only; use
+'dotclaude backups retention' to change the policy.
+
+Examples:
+  dotclaude backups prune --dry-run          # What the policy would remove
+  dotclaude backups prune                    # Apply the policy now
+  dotclaude backups prune --max-age 30d      # Remove snapshots older than 30 days
+  dotclaude backups prune --keep 1 --dry-run # What keeping only the latest saves`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mgr, err := scopedManager(project)
+			if err != nil {
+				return err
+			}
+
+			retention, err := mgr.BackupRetention()
+			if err != nil {
+				return err
+			}
+			if err := limits.apply(cmd, &retention); err != nil {
+				return err
+			}
+
+			result, err := mgr.PruneBackups(retention, dryRun)
+			if err != nil {
+				return err
+			}
+
+			verb := "Removed"
+			if dryRun {
+				verb = "Would remove"
+			}
+			for _, backup := range result.Removed {
+				fmt.Printf("  %s  %s\n", backup.ID, describeBackup(backup))
+			}
+			if len(result.Removed) > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%s %d of %d snapshot(s) (%s)", verb, len(result.Removed), len(result.Removed)+result.Kept, retention)
+			if result.Objects > 0 {
+				fmt.Printf(" and %d unused stored file(s)", result.Objects)
+			}
+			fmt.Println()
+			if dryRun {
+				fmt.Printf("Would reclaim %s\n", profile.FormatByteSize(result.Reclaimed))
+			} else {
+				fmt.Printf("Reclaimed %s\n", profile.FormatByteSize(result.Reclaimed))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Report what would be removed without removing it")
+	limits.register(cmd)
+	addProjectFlag(cmd, &project, "Prune backups of the project's .claude directory")
+
+	return cmd
+}
+
+// newBackupsRetentionCmd returns the backups retention command
+func newBackupsRetentionCmd() *cobra.Command {
+	var limits retentionFlags
+	var project string
+
+	cmd := &cobra.Command{
+		Use:   "retention",
+		Short: "Show or change the backup retention policy",
+		Long: `Show the backup retention policy, or change the limits given as flags.
+The policy applies from the next snapshot or prune; run
+'dotclaude backups prune --dry-run' to see what it would remove now.
+
+Examples:
+  dotclaude backups retention                            # Show the policy
+  dotclaude backups retention --keep 0 --max-age 30d     # A month of history
+  dotclaude backups retention --max-size 50M             # Cap the storage used`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mgr, err := scopedManager(project)
+			if err != nil {
+				return err
+			}
+
+			retention, err := mgr.BackupRetention()
+			if err != nil {
+				return err
+			}
+			if !limits.changed(cmd) {
+				fmt.Printf("Backup retention: %s\n", retention)
+				return nil
+			}
+
+			if err := limits.apply(cmd, &retention); err != nil {
+				return err
+			}
+			if err := mgr.SetBackupRetention(retention); err != nil {
+				return err
+			}
+			fmt.Printf("Backup retention set to: %s\n", retention)
+			return nil
+		},
+	}
+
+	limits.register(cmd)
+	addProjectFlag(cmd, &project, "Use the project's .claude directory")
+
+	return cmd
+}
+
+// retentionFlags are the --keep, --max-age and --max-size flags.
+type retentionFlags struct {
+	keep    int
+	maxAge  string
+	maxSize string
+}
+
+func (f *retentionFlags) register(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&f.keep, "keep", 0, "Keep this many of the most recent snapshots (0: no limit)")
+	cmd.Flags().StringVar(&f.maxAge, "max-age", "", "Remove snapshots older than this, e.g. 30d, 2w, 36h (0: no limit)")
+	cmd.Flags().StringVar(&f.maxSize, "max-size", "", "Keep snapshots within this much storage, e.g. 100M, 1G (0: no limit)")
+}
+
+// changed reports whether any limit was given.
+func (f *retentionFlags) changed(cmd *cobra.Command) bool {
+	return cmd.Flags().Changed("keep") || cmd.Flags().Changed("max-age") || cmd.Flags().Changed("max-size")
+}
+
+// apply replaces the limits of a policy with those given as flags.
+func (f *retentionFlags) apply(cmd *cobra.Command, retention *profile.BackupRetention) error {
+	if cmd.Flags().Changed("keep") {
+		if f.keep < 0 {
+			return fmt.Errorf("--keep cannot be negative")
+		}
+		retention.Keep = f.keep
+	}
+	if cmd.Flags().Changed("max-age") {
+		age, err := profile.ParseBackupAge(f.maxAge)
+		if err != nil {
+			return fmt.Errorf("--max-age: %w", err)
+		}
+		retention.MaxAge = age
+	}
+	if cmd.Flags().Changed("max-size") {
+		size, err := profile.ParseByteSize(f.maxSize)
+		if err != nil {
+			return fmt.Errorf("--max-size: %w", err)
+		}
+		retention.MaxSize = size
+	}
+	return nil
+}
@@ -2,11 +2,16 @@ package cli
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/blackwell-systems/dotclaude/internal/profile"
 	"github.com/spf13/cobra"
 )
 
@@ -212,6 +217,25 @@ func TestCreateCmd(t *testing.T) {
 			t.Error("creating profile with invalid name should error")
 		}
 	})
+
+	t.Run("create with metadata", func(t *testing.T) {
+		cmd := newCreateCmd()
+		err := executeCommand(cmd, "client-x", "--extends", "new-profile",
+			"--description", "Client X work", "--owner", "me", "--tags", "client,go")
+
+		if err != nil {
+			t.Fatalf("create command error: %v", err)
+		}
+
+		manifest, err := profile.LoadManifest(filepath.Join(ProfilesDir, "client-x"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if manifest.Extends != "new-profile" || manifest.Description != "Client X work" ||
+			manifest.Owner != "me" || strings.Join(manifest.Tags, ",") != "client,go" {
+			t.Errorf("manifest = %+v", manifest)
+		}
+	})
 }
 
 func TestDeleteCmd(t *testing.T) {
@@ -291,13 +315,12 @@ func TestActivateCmd(t *testing.T) {
 		}
 
 		// Verify state file
-		stateFile := filepath.Join(ClaudeDir, ".current-profile")
-		content, err := os.ReadFile(stateFile)
+		state, err := newManager().LoadState()
 		if err != nil {
 			t.Fatal(err)
 		}
-		if strings.TrimSpace(string(content)) != "to-activate" {
-			t.Errorf("state file = %q, want %q", string(content), "to-activate")
+		if state == nil || state.Profile != "to-activate" {
+			t.Errorf("state = %+v, want profile %q", state, "to-activate")
 		}
 	})
 
@@ -318,24 +341,164 @@ func TestActivateCmd(t *testing.T) {
 			t.Error("activate without name should error")
 		}
 	})
+
+	t.Run("activate stack", func(t *testing.T) {
+		profileDir := filepath.Join(ProfilesDir, "stacked")
+		if err := os.MkdirAll(profileDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(profileDir, "CLAUDE.md"), []byte("# Stacked\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := executeCommand(newActivateCmd(), "to-activate+stacked"); err != nil {
+			t.Fatalf("activate command error: %v", err)
+		}
+
+		state, err := newManager().LoadState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state == nil || state.Profile != "to-activate+stacked" || len(state.Stack) != 2 {
+			t.Errorf("state = %+v, want stack to-activate+stacked", state)
+		}
+
+		if err := executeCommand(newShowCmd()); err != nil {
+			t.Errorf("show command error: %v", err)
+		}
+		if err := executeCommand(newListCmd()); err != nil {
+			t.Errorf("list command error: %v", err)
+		}
+		if err := executeCommand(newActivateCmd(), "to-activate+non-existent"); err == nil {
+			t.Error("activating a stack with a missing profile should error")
+		}
+	})
+
+	t.Run("activate with managed CLAUDE.md", func(t *testing.T) {
+		if err := executeCommand(newActivateCmd(), "to-activate", "--claude-md=block"); err == nil {
+			t.Error("an unknown --claude-md mode should error")
+		}
+		if err := executeCommand(newActivateCmd(), "to-activate", "--claude-md=managed", "--force"); err != nil {
+			t.Fatalf("activate command error: %v", err)
+		}
+
+		content, err := os.ReadFile(filepath.Join(ClaudeDir, "CLAUDE.md"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(content), "<!-- BEGIN dotclaude managed block: profile=to-activate") {
+			t.Errorf("CLAUDE.md should contain the managed block:\n%s", content)
+		}
+		if err := executeCommand(newStatusCmd()); err != nil {
+			t.Errorf("status command error: %v", err)
+		}
+		if err := executeCommand(newActivateCmd(), "to-activate", "--claude-md=replace"); err != nil {
+			t.Fatalf("activate command error: %v", err)
+		}
+	})
+
+	t.Run("activate profile requiring newer version", func(t *testing.T) {
+		profileDir := filepath.Join(ProfilesDir, "from-the-future")
+		if err := os.MkdirAll(profileDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(profileDir, "CLAUDE.md"), []byte("# Test\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(profileDir, "profile.yaml"), []byte("min_version: 99.0.0\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := newActivateCmd()
+		err := executeCommand(cmd, "from-the-future")
+
+		if err == nil || !strings.Contains(err.Error(), "requires dotclaude >= 99.0.0") {
+			t.Errorf("activate error = %v, want version requirement error", err)
+		}
+	})
 }
 
 func TestRestoreCmd(t *testing.T) {
-	_, cleanup := setupTestEnv(t)
+	tmpDir, cleanup := setupTestEnv(t)
 	defer cleanup()
 
-	// Note: restore is interactive, so we just verify the command is set up correctly
-	cmd := newRestoreCmd()
-
-	// Verify basic command structure
-	if cmd.Use != "restore" {
-		t.Errorf("restore Use = %q, want %q", cmd.Use, "restore")
+	for _, name := range []string{"work", "personal", "oss"} {
+		profileDir := filepath.Join(tmpDir, "profiles", name)
+		if err := os.MkdirAll(profileDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(profileDir, "CLAUDE.md"), []byte("# "+name), 0644); err != nil {
+			t.Fatal(err)
+		}
 	}
-
-	// Verify it takes no arguments (interactive)
-	if cmd.Args == nil {
-		t.Error("restore should have Args validation")
+	for _, name := range []string{"work", "personal", "oss"} {
+		if err := newManager().Activate(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Backups of work, then personal
+	backups, err := newManager().ListBackups()
+	if err != nil || len(backups) != 2 {
+		t.Fatalf("ListBackups() = %d backups, %v, want 2", len(backups), err)
 	}
+
+	t.Run("flag conflicts", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"--id", backups[0].ID, "--latest"},
+			{"--json", "--latest"},
+		} {
+			if err := executeCommand(newRestoreCmd(), args...); err == nil {
+				t.Errorf("restore %v should fail", args)
+			}
+		}
+	})
+
+	t.Run("unknown selection", func(t *testing.T) {
+		if err := executeCommand(newRestoreCmd(), "--profile", "nope", "--yes"); err == nil || !strings.Contains(err.Error(), "no backup of profile nope") {
+			t.Errorf("restore error = %v, want no matching backup", err)
+		}
+		if err := executeCommand(newRestoreCmd(), "--latest", "--before", "soon"); err == nil || !strings.Contains(err.Error(), "invalid --before") {
+			t.Errorf("restore error = %v, want invalid --before", err)
+		}
+	})
+
+	t.Run("by profile", func(t *testing.T) {
+		if err := executeCommand(newRestoreCmd(), "--profile", "work", "--diff", "--yes"); err != nil {
+			t.Fatalf("restore error: %v", err)
+		}
+		if got := newManager().GetActiveProfileName(); got != "work" {
+			t.Errorf("active profile = %q, want work", got)
+		}
+	})
+
+	t.Run("latest", func(t *testing.T) {
+		// The restore above backed up oss first
+		// Not in a terminal: the selector alone restores, without a prompt
+		stdin := os.Stdin
+		input, err := os.Create(filepath.Join(tmpDir, "stdin"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer input.Close()
+		os.Stdin = input
+		defer func() { os.Stdin = stdin }()
+
+		if err := executeCommand(newRestoreCmd(), "--latest"); err != nil {
+			t.Fatalf("restore error: %v", err)
+		}
+		if got := newManager().GetActiveProfileName(); got != "oss" {
+			t.Errorf("active profile = %q, want oss", got)
+		}
+	})
+
+	t.Run("by id", func(t *testing.T) {
+		if err := executeCommand(newRestoreCmd(), "--id", backups[0].ID, "--yes"); err != nil {
+			t.Fatalf("restore error: %v", err)
+		}
+		if got := newManager().GetActiveProfileName(); got != "personal" {
+			t.Errorf("active profile = %q, want personal", got)
+		}
+	})
 }
 
 func TestDiffCmd(t *testing.T) {
@@ -465,6 +628,375 @@ func TestEditCmd(t *testing.T) {
 	})
 }
 
+func TestStatusCmd(t *testing.T) {
+	_, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	t.Run("no active profile", func(t *testing.T) {
+		if err := executeCommand(newStatusCmd()); err != nil {
+			t.Fatalf("status command error: %v", err)
+		}
+	})
+
+	t.Run("drift blocks activate", func(t *testing.T) {
+		profileDir := filepath.Join(ProfilesDir, "drifty")
+		if err := os.MkdirAll(profileDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(profileDir, "CLAUDE.md"), []byte("# Test\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := executeCommand(newActivateCmd(), "drifty"); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(ClaudeDir, "CLAUDE.md"), []byte("edited\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := executeCommand(newStatusCmd(), "--diff"); err != nil {
+			t.Fatalf("status command error: %v", err)
+		}
+
+		// Tests run without a terminal on stdin, so there is no prompt
+		if err := executeCommand(newActivateCmd(), "drifty"); err == nil {
+			t.Error("activate should refuse to overwrite an edited CLAUDE.md")
+		}
+		if err := executeCommand(newActivateCmd(), "drifty", "--force"); err != nil {
+			t.Errorf("activate --force error: %v", err)
+		}
+	})
+}
+
+func TestHistoryCmd(t *testing.T) {
+	tmpDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	for _, name := range []string{"first", "second"} {
+		profileDir := filepath.Join(tmpDir, "profiles", name)
+		if err := os.MkdirAll(profileDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(profileDir, "CLAUDE.md"), []byte("# "+name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := executeCommand(newActivateCmd(), name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("json filtered by profile", func(t *testing.T) {
+		if err := executeCommand(newHistoryCmd(), "--profile", "first", "--json"); err != nil {
+			t.Fatalf("history error: %v", err)
+		}
+		entries, err := newManager().History(profile.HistoryFilter{Profile: "first"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 2 {
+			t.Errorf("got %d entries involving 'first', want 2", len(entries))
+		}
+	})
+
+	t.Run("table with dates", func(t *testing.T) {
+		if err := executeCommand(newHistoryCmd(), "--since", "7d", "--until", time.Now().Format("2006-01-02")); err != nil {
+			t.Errorf("history error: %v", err)
+		}
+	})
+
+	t.Run("invalid date", func(t *testing.T) {
+		if err := executeCommand(newHistoryCmd(), "--since", "last tuesday"); err == nil {
+			t.Error("history should reject an unparsable --since")
+		}
+	})
+}
+
+func TestScanCmd(t *testing.T) {
+	tmpDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	profileDir := filepath.Join(tmpDir, "profiles", "work")
+	if err := os.MkdirAll(profileDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(profileDir, "CLAUDE.md"), []byte("# work"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("clean", func(t *testing.T) {
+		if err := executeCommand(newScanCmd()); err != nil {
+			t.Errorf("scan error: %v", err)
+		}
+	})
+
+	// Assembled at run time so the source doesn't trip other scanners
+	key := "AKIA" + "IOSFODNN7EXAMPLE"
+	if err := os.WriteFile(filepath.Join(profileDir, "settings.json"), []byte(`{"env": {"AWS_ACCESS_KEY_ID": "`+key+`"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("finding fails", func(t *testing.T) {
+		err := executeCommand(newScanCmd(), "--repo")
+		if err == nil || !strings.Contains(err.Error(), "1 possible secret(s) found") {
+			t.Errorf("scan error = %v, want one finding", err)
+		}
+	})
+
+	t.Run("sarif", func(t *testing.T) {
+		result, err := newManager().Scan(profile.ScanOptions{Repo: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := json.Marshal(sarifReport(result))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{`"version":"2.1.0"`, `"ruleId":"aws-access-key-id"`, `"uri":"profiles/work/settings.json"`, `"startLine":1`} {
+			if !strings.Contains(string(data), want) {
+				t.Errorf("SARIF output missing %s:\n%s", want, data)
+			}
+		}
+		if strings.Contains(string(data), key) {
+			t.Errorf("SARIF output should not contain the secret:\n%s", data)
+		}
+	})
+
+	t.Run("activate gate", func(t *testing.T) {
+		err := executeCommand(newActivateCmd(), "--scan", "work")
+		var scanErr *profile.ScanError
+		if !errors.As(err, &scanErr) {
+			t.Errorf("activate --scan error = %v, want a scan error", err)
+		}
+	})
+}
+
+func TestBackupsCmd(t *testing.T) {
+	tmpDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	for _, name := range []string{"work", "personal"} {
+		profileDir := filepath.Join(tmpDir, "profiles", name)
+		if err := os.MkdirAll(profileDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(profileDir, "CLAUDE.md"), []byte("# "+name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Each switch snapshots the previous profile
+	for _, name := range []string{"work", "personal", "work", "personal"} {
+		if err := newManager().Activate(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("retention", func(t *testing.T) {
+		if err := executeCommand(newBackupsCmd(), "retention", "--keep", "0", "--max-age", "30d"); err != nil {
+			t.Fatalf("backups retention error: %v", err)
+		}
+		retention, err := newManager().BackupRetention()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := (profile.BackupRetention{MaxAge: 30 * 24 * time.Hour}); retention != want {
+			t.Errorf("retention = %v, want %v", retention, want)
+		}
+
+		if err := executeCommand(newBackupsCmd(), "retention", "--max-size", "lots"); err == nil {
+			t.Error("backups retention should reject an invalid size")
+		}
+	})
+
+	t.Run("prune dry run", func(t *testing.T) {
+		if err := executeCommand(newBackupsCmd(), "prune", "--keep", "1", "--dry-run"); err != nil {
+			t.Fatalf("backups prune error: %v", err)
+		}
+		if backups, _ := newManager().ListBackups(); len(backups) != 3 {
+			t.Errorf("dry run left %d backups, want all 3", len(backups))
+		}
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		if err := executeCommand(newBackupsCmd(), "prune", "--keep", "1"); err != nil {
+			t.Fatalf("backups prune error: %v", err)
+		}
+		if backups, _ := newManager().ListBackups(); len(backups) != 1 {
+			t.Errorf("prune left %d backups, want 1", len(backups))
+		}
+	})
+}
+
+func TestDeactivateCmd(t *testing.T) {
+	tmpDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	profileDir := filepath.Join(tmpDir, "profiles", "work")
+	if err := os.MkdirAll(profileDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(profileDir, "CLAUDE.md"), []byte("# work"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := executeCommand(newActivateCmd(), "work"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := executeCommand(newDeactivateCmd()); err != nil {
+		t.Fatalf("deactivate error: %v", err)
+	}
+	if name := newManager().GetActiveProfileName(); name != "" {
+		t.Errorf("active profile = %q, want none", name)
+	}
+	if _, err := os.Stat(filepath.Join(ClaudeDir, "CLAUDE.md")); !os.IsNotExist(err) {
+		t.Error("deactivate should remove the deployed CLAUDE.md")
+	}
+
+	// Nothing active is not an error
+	if err := executeCommand(newDeactivateCmd()); err != nil {
+		t.Errorf("deactivate with nothing active error: %v", err)
+	}
+}
+
+func TestTrustCmd(t *testing.T) {
+	tmpDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+	t.Setenv(profile.AutoActivateEnv, "")
+
+	project := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	dotclaude := filepath.Join(project, ".dotclaude")
+	if err := os.WriteFile(dotclaude, []byte("profile: work\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := executeCommand(newTrustCmd(), project); err != nil {
+		t.Fatalf("trust error: %v", err)
+	}
+	if status, err := newManager().CheckTrust(dotclaude); err != nil || status != profile.Trusted {
+		t.Errorf("CheckTrust() = %v, %v, want Trusted", status, err)
+	}
+
+	if err := executeCommand(newTrustCmd(), "--policy", "auto"); err != nil {
+		t.Fatalf("trust --policy error: %v", err)
+	}
+	if policy, _ := newManager().AutoActivatePolicy(); policy != profile.AutoActivateAuto {
+		t.Errorf("policy = %v, want auto", policy)
+	}
+	if err := executeCommand(newTrustCmd(), "--policy", "always"); err == nil {
+		t.Error("trust --policy should reject unknown policies")
+	}
+
+	if err := executeCommand(newTrustCmd(), "--list"); err != nil {
+		t.Errorf("trust --list error: %v", err)
+	}
+
+	if err := executeCommand(newTrustCmd(), "--revoke", project); err != nil {
+		t.Fatalf("trust --revoke error: %v", err)
+	}
+	if status, _ := newManager().CheckTrust(dotclaude); status != profile.Untrusted {
+		t.Errorf("CheckTrust() after revoke = %v, want Untrusted", status)
+	}
+
+	if err := executeCommand(newTrustCmd(), filepath.Join(tmpDir, "missing")); err == nil {
+		t.Error("trusting a missing .dotclaude should fail")
+	}
+}
+
+func TestProjectHookLines(t *testing.T) {
+	df, err := profile.ParseDotclaude("/project/.dotclaude", []byte(
+		"profile: work\nhooks:\n  session-start: ./check-env.sh\n  post-tool-bash: [make lint, make vet]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"post-tool-bash: make lint", "post-tool-bash: make vet", "session-start: ./check-env.sh"}
+	if got := projectHookLines(df); !reflect.DeepEqual(got, want) {
+		t.Errorf("projectHookLines() = %q, want %q", got, want)
+	}
+}
+
+func TestWhichCmd(t *testing.T) {
+	tmpDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	project := filepath.Join(tmpDir, "project")
+	sub := filepath.Join(project, "services", "api")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := executeCommand(newWhichCmd(), sub); err == nil {
+		t.Error("which without a .dotclaude should fail")
+	}
+
+	if err := os.WriteFile(filepath.Join(project, ".dotclaude"), []byte("profile: work\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := executeCommand(newWhichCmd(), sub); err != nil {
+		t.Errorf("which error: %v", err)
+	}
+}
+
+func TestActivateProjectCmd(t *testing.T) {
+	tmpDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	profileDir := filepath.Join(tmpDir, "profiles", "client")
+	if err := os.MkdirAll(profileDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(profileDir, "CLAUDE.md"), []byte("# client"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	project := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := executeCommand(newActivateCmd(), "client", "--project="+project); err != nil {
+		t.Fatalf("activate --project error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".claude", "CLAUDE.md")); err != nil {
+		t.Errorf("CLAUDE.md should be deployed to the project: %v", err)
+	}
+	if name := newManager().GetActiveProfileName(); name != "" {
+		t.Errorf("global active profile = %q, project activation should not change it", name)
+	}
+
+	for _, cmd := range []*cobra.Command{newShowCmd(), newStatusCmd()} {
+		if err := executeCommand(cmd, "--project="+project); err != nil {
+			t.Errorf("%s --project error: %v", cmd.Name(), err)
+		}
+	}
+	if err := executeCommand(newDeactivateCmd(), "--project="+project); err != nil {
+		t.Errorf("deactivate --project error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".claude", "CLAUDE.md")); !os.IsNotExist(err) {
+		t.Error("deactivate --project should remove the project's CLAUDE.md")
+	}
+}
+
+func TestParseHistoryTime(t *testing.T) {
+	day := time.Date(2025, 1, 6, 0, 0, 0, 0, time.Local)
+
+	got, err := parseHistoryTime("2025-01-06", false)
+	if err != nil || !got.Equal(day) {
+		t.Errorf("since date = %v, %v, want %v", got, err, day)
+	}
+	got, err = parseHistoryTime("2025-01-06", true)
+	if err != nil || !got.Equal(day.AddDate(0, 0, 1)) {
+		t.Errorf("until date = %v, %v, want the next midnight", got, err)
+	}
+	got, err = parseHistoryTime("2025-01-06 15:04", false)
+	if err != nil || !got.Equal(day.Add(15*time.Hour+4*time.Minute)) {
+		t.Errorf("date and time = %v, %v", got, err)
+	}
+	got, err = parseHistoryTime("7d", false)
+	if err != nil || time.Since(got) < 6*24*time.Hour {
+		t.Errorf("7d = %v, %v, want a week ago", got, err)
+	}
+}
+
 func TestRootCmdVersion(t *testing.T) {
 	// Verify version constant
 	if Version == "" {
@@ -491,6 +1023,14 @@ func TestCommandRegistration(t *testing.T) {
 		"check-branches",
 		"sync",
 		"diff",
+		"unlock",
+		"status",
+		"history",
+		"deactivate",
+		"trust",
+		"which",
+		"scan",
+		"backups",
 	}
 
 	registeredCommands := make(map[string]bool)
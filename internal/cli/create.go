@@ -2,25 +2,46 @@ package cli
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/blackwell-systems/dotclaude/internal/profile"
 	"github.com/spf13/cobra"
 )
 
 func newCreateCmd() *cobra.Command {
-	return &cobra.Command{
+	var description, owner, extends string
+	var tags []string
+
+	cmd := &cobra.Command{
 		Use:     "create <profile-name>",
 		Aliases: []string{"new"},
 		Short:   "Create a new profile",
-		Long:    "Create a new dotclaude profile from the template.",
-		Args:    cobra.ExactArgs(1),
+		Long: `Create a new dotclaude profile from the template.
+
+Metadata flags are written to the profile's profile.yaml manifest.
+
+Examples:
+  dotclaude create my-project
+  dotclaude create client-x --extends work --description "Client X" --tags client,go`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			profileName := args[0]
 
-			mgr := profile.NewManager(RepoDir, ClaudeDir)
+			mgr := newManager()
+
+			// Only write a manifest when metadata was given
+			var manifest *profile.Manifest
+			if description != "" || owner != "" || extends != "" || len(tags) > 0 {
+				manifest = &profile.Manifest{
+					Description: description,
+					Owner:       owner,
+					Tags:        tags,
+					Extends:     extends,
+				}
+			}
 
 			// Create the profile
-			if err := mgr.Create(profileName); err != nil {
+			if err := mgr.CreateWithManifest(profileName, manifest); err != nil {
 				return err
 			}
 
@@ -31,6 +52,12 @@ func newCreateCmd() *cobra.Command {
 			fmt.Println("╰─────────────────────────────────────────────────────────────╯")
 			fmt.Println()
 			fmt.Printf("Profile created at: %s/profiles/%s\n", RepoDir, profileName)
+			if extends != "" {
+				fmt.Printf("Extends:            %s\n", extends)
+			}
+			if len(tags) > 0 {
+				fmt.Printf("Tags:               %s\n", strings.Join(tags, ", "))
+			}
 			fmt.Println()
 			fmt.Println("Next steps:")
 			fmt.Printf("  1. Edit profile:    dotclaude edit %s\n", profileName)
@@ -40,4 +67,11 @@ func newCreateCmd() *cobra.Command {
 			return nil
 		},
 	}
+
+	cmd.Flags().StringVar(&description, "description", "", "One-line description of the profile")
+	cmd.Flags().StringVar(&owner, "owner", "", "Person or team responsible for the profile")
+	cmd.Flags().StringSliceVar(&tags, "tags", nil, "Comma-separated tags")
+	cmd.Flags().StringVar(&extends, "extends", "", "Parent profile to inherit from")
+
+	return cmd
 }
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/blackwell-systems/dotclaude/internal/profile"
+	"github.com/spf13/cobra"
+)
+
+func newDeactivateCmd() *cobra.Command {
+	var keepFiles bool
+	var force bool
+	var project string
+
+	cmd := &cobra.Command{
+		Use:   "deactivate",
+		Short: "Stop managing ~/.claude",
+		Long: `Deactivate the active profile and return ~/.claude to an unmanaged state.
+
+The files the profile deployed (CLAUDE.md, settings.json, agents, hooks) are
+removed. Files that were there before dotclaude first overwrote them are put
+back instead. The configuration is snapshotted first (see 'dotclaude
+restore'), so all of it can be brought back.
+
+With --keep-files the deployed files stay where they are and only dotclaude's
+record of them is dropped; they become ordinary files dotclaude won't touch.
+
+Examples:
+  dotclaude deactivate               # Remove deployed files, restore originals
+  dotclaude deactivate --keep-files  # Leave the files, stop tracking them
+  dotclaude deactivate --project     # Deactivate the current project's profile`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mgr, err := scopedManager(project)
+			if err != nil {
+				return err
+			}
+
+			activeName := mgr.GetActiveProfileName()
+			if activeName == "" {
+				fmt.Println("No profile is currently active.")
+				return nil
+			}
+
+			opts := profile.DeactivateOptions{KeepFiles: keepFiles, Force: force}
+			if err := mgr.Deactivate(opts); err != nil {
+				return err
+			}
+
+			fmt.Println()
+			fmt.Println("╭─────────────────────────────────────────────────────────────╮")
+			fmt.Printf("│  ✓ Profile Deactivated: %-39s│\n", activeName)
+			fmt.Println("╰─────────────────────────────────────────────────────────────╯")
+			fmt.Println()
+			if keepFiles {
+				fmt.Printf("  Deployed files left in %s, no longer managed.\n", mgr.ClaudeDir)
+			} else {
+				fmt.Printf("  Deployed files removed from %s (original files restored).\n", mgr.ClaudeDir)
+			}
+			fmt.Println()
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&keepFiles, "keep-files", false, "Leave deployed files in place and only stop tracking them")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Remove deployed files even if they were edited by hand")
+	addProjectFlag(cmd, &project, "Deactivate the project's profile instead of the global one")
+
+	return cmd
+}
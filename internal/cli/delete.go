@@ -6,7 +6,6 @@ import (
 	"os"
 	"strings"
 
-	"github.com/blackwell-systems/dotclaude/internal/profile"
 	"github.com/spf13/cobra"
 )
 
@@ -22,7 +21,7 @@ func newDeleteCmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			profileName := args[0]
 
-			mgr := profile.NewManager(RepoDir, ClaudeDir)
+			mgr := newManager()
 
 			// Check if profile exists
 			if !mgr.ProfileExists(profileName) {
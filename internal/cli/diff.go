@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 
 	"github.com/blackwell-systems/dotclaude/internal/profile"
 	"github.com/spf13/cobra"
@@ -19,7 +20,7 @@ If only one profile is provided, compares it to the currently active profile.
 If no profiles are provided, shows an error.`,
 		Args: cobra.RangeArgs(1, 2),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			mgr := profile.NewManager(RepoDir, ClaudeDir)
+			mgr := newManager()
 
 			var profile1Path, profile2Path string
 			var profile1Name, profile2Name string
@@ -35,15 +36,15 @@ If no profiles are provided, shows an error.`,
 				}
 
 				profile1Name = activeProfile.Name
-				profile1Path = fmt.Sprintf("%s/profiles/%s/CLAUDE.md", RepoDir, activeProfile.Name)
+				profile1Path = profile.CLAUDEmdPath(filepath.Join(ProfilesDir, activeProfile.Name))
 				profile2Name = args[0]
-				profile2Path = fmt.Sprintf("%s/profiles/%s/CLAUDE.md", RepoDir, args[0])
+				profile2Path = profile.CLAUDEmdPath(filepath.Join(ProfilesDir, args[0]))
 			} else {
 				// Compare two specified profiles
 				profile1Name = args[0]
-				profile1Path = fmt.Sprintf("%s/profiles/%s/CLAUDE.md", RepoDir, args[0])
+				profile1Path = profile.CLAUDEmdPath(filepath.Join(ProfilesDir, args[0]))
 				profile2Name = args[1]
-				profile2Path = fmt.Sprintf("%s/profiles/%s/CLAUDE.md", RepoDir, args[1])
+				profile2Path = profile.CLAUDEmdPath(filepath.Join(ProfilesDir, args[1]))
 			}
 
 			// Check if profiles exist
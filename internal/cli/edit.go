@@ -64,7 +64,7 @@ Editor selection (in order of priority):
   3. Platform default (Windows: notepad, Unix: vim/nano)`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			mgr := profile.NewManager(RepoDir, ClaudeDir)
+			mgr := newManager()
 
 			var profileName string
 			if len(args) == 0 {
@@ -73,6 +73,9 @@ Editor selection (in order of priority):
 				if profileName == "" {
 					return fmt.Errorf("no active profile. Specify a profile name or activate one first")
 				}
+				if profile.IsStack(profileName) {
+					return fmt.Errorf("the active profile is the stack '%s'. Specify which profile to edit", profileName)
+				}
 			} else {
 				profileName = args[0]
 			}
@@ -90,7 +93,7 @@ Editor selection (in order of priority):
 			if editSettings {
 				filePath = filepath.Join(ProfilesDir, profileName, "settings.json")
 			} else {
-				filePath = filepath.Join(ProfilesDir, profileName, "CLAUDE.md")
+				filePath = profile.CLAUDEmdPath(filepath.Join(ProfilesDir, profileName))
 			}
 
 			// Check if file exists
@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/blackwell-systems/dotclaude/internal/profile"
+	"github.com/spf13/cobra"
+)
+
+func newHistoryCmd() *cobra.Command {
+	var profileName string
+	var since string
+	var until string
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show the activation history",
+		Long: `Show the log of profile activations, deactivations, restores and deletions,
+oldest first.
+
+Each entry records when it happened, the active profile before and after,
+the dotclaude repo commit, the command that ran and its working directory.
+
+--since and --until accept a date (2006-01-02), a date and time
+(2006-01-02 15:04 or RFC 3339), or an age such as 36h or 7d. A date given
+to --until includes that whole day.
+
+Examples:
+  dotclaude history                          # Everything
+  dotclaude history --profile work           # Switches to or from 'work'
+  dotclaude history --since 7d               # The last week
+  dotclaude history --since 2025-01-06 --until 2025-01-06
+  dotclaude history --json                   # One JSON array, for scripts`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filter := profile.HistoryFilter{Profile: profileName}
+			var err error
+			if since != "" {
+				if filter.Since, err = parseHistoryTime(since, false); err != nil {
+					return fmt.Errorf("invalid --since: %w", err)
+				}
+			}
+			if until != "" {
+				if filter.Until, err = parseHistoryTime(until, true); err != nil {
+					return fmt.Errorf("invalid --until: %w", err)
+				}
+			}
+
+			entries, err := newManager().History(filter)
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				if entries == nil {
+					entries = []profile.HistoryEntry{}
+				}
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(entries)
+			}
+
+			if len(entries) == 0 {
+				fmt.Println("No matching history.")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "TIME\tACTION\tPROFILE\tCOMMIT\tDIRECTORY")
+			for _, e := range entries {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+					e.Time.Local().Format("2006-01-02 15:04:05"),
+					e.Action,
+					describeHistoryChange(e),
+					shortCommit(e.Commit),
+					e.Dir)
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVarP(&profileName, "profile", "p", "", "Only entries involving this profile")
+	cmd.Flags().StringVar(&since, "since", "", "Only entries at or after this time")
+	cmd.Flags().StringVar(&until, "until", "", "Only entries before this time")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output JSON instead of a table")
+
+	return cmd
+}
+
+// describeHistoryChange summarizes what an entry did to the active profile.
+func describeHistoryChange(e profile.HistoryEntry) string {
+	from := e.From
+	if from == "" {
+		from = "(none)"
+	}
+
+	switch e.Action {
+	case profile.HistoryActivate:
+		if e.From == e.To {
+			return e.To + " (update)"
+		}
+		return from + " → " + e.To
+	case profile.HistoryDeactivate:
+		return from + " → (none)"
+	case profile.HistoryDelete:
+		return "deleted " + e.Target
+	case profile.HistoryRestore:
+		if e.From != e.To {
+			to := e.To
+			if to == "" {
+				to = "(none)"
+			}
+			return from + " → " + to + " (backup " + e.Target + ")"
+		}
+		return from + " ← " + e.Target
+	}
+	return from + " → " + e.To
+}
+
+// parseHistoryTime parses a --since/--until value. Dates are local midnight;
+// as an upper bound (endOfDay) a date means the following midnight.
+func parseHistoryTime(value string, endOfDay bool) (time.Time, error) {
+	// Ages: 36h, 90m, 7d
+	if strings.HasSuffix(value, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(value, "d")); err == nil && days >= 0 {
+			return time.Now().AddDate(0, 0, -days), nil
+		}
+	}
+	if age, err := time.ParseDuration(value); err == nil && age >= 0 {
+		return time.Now().Add(-age), nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date, time or age (e.g. 2025-01-06, \"2025-01-06 15:04\", 7d)", value)
+}
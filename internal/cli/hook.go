@@ -19,6 +19,8 @@ They enable cross-platform automation and customization.
 
 Built-in hooks provide core functionality (session info, profile mismatch detection).
 Custom hooks can be added to the hooks directory (<claude-dir>/hooks/<hook-type>/).
+Hooks in base/hooks/<hook-type>/ and profiles/<name>/hooks/<hook-type>/ are
+deployed there on activation.
 
 Hook types:
   session-start    Runs when a Claude Code session starts
@@ -82,6 +84,7 @@ Examples:
 			}
 
 			runner := hooks.NewRunner(ClaudeDir, RepoDir)
+			runner.Version = Version
 			return runner.Run(hookType)
 		},
 	}
@@ -98,7 +101,10 @@ func newHookListCmd() *cobra.Command {
 		Short: "List available hooks",
 		Long: `List all available hooks, optionally filtered by type.
 
-Shows both built-in hooks and custom hooks with their priority and status.
+Shows both built-in hooks and custom hooks with their priority, status and
+the layer they came from: built-in, base, a profile name, user for hooks
+added to the hooks directory by hand, or project for commands in the
+current project's .dotclaude (disabled until it is trusted).
 
 Examples:
   dotclaude hook list                  List all hooks
@@ -106,6 +112,7 @@ Examples:
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			runner := hooks.NewRunner(ClaudeDir, RepoDir)
+			runner.Version = Version
 
 			var typesToList []hooks.HookType
 			if len(args) > 0 {
@@ -129,11 +136,7 @@ Examples:
 						status = "disabled"
 					}
 
-					if h.Type == "built-in" {
-						fmt.Printf("  [%02d] %s (built-in, %s)\n", h.Priority, h.Name, status)
-					} else {
-						fmt.Printf("  [%02d] %s (%s)\n", h.Priority, h.Name, status)
-					}
+					fmt.Printf("  [%02d] %s (%s, %s)\n", h.Priority, h.Name, h.Layer, status)
 				}
 			}
 
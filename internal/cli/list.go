@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/blackwell-systems/dotclaude/internal/profile"
 	"github.com/spf13/cobra"
@@ -14,7 +15,7 @@ func newListCmd() *cobra.Command {
 		Long:    "Display all available dotclaude profiles with their status.",
 		Aliases: []string{"ls"},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			mgr := profile.NewManager(RepoDir, ClaudeDir)
+			mgr := newManager()
 
 			profiles, err := mgr.ListProfiles()
 			if err != nil {
@@ -35,12 +36,24 @@ func newListCmd() *cobra.Command {
 			fmt.Println()
 
 			// Print profiles
+			activeName := mgr.GetActiveProfileName()
+			if profile.IsStack(activeName) {
+				fmt.Printf("  Active stack: \033[1;32m%s\033[0m\n\n", strings.Join(profile.SplitStack(activeName), " + "))
+			}
 			for _, p := range profiles {
-				if p.IsActive {
+				if p.IsActive && p.Name != activeName {
+					fmt.Printf("  ▶ \033[1;32m%s\033[0m (active, in stack)\n", p.Name)
+				} else if p.IsActive {
 					fmt.Printf("  ▶ \033[1;32m%s\033[0m (active)\n", p.Name)
 				} else {
 					fmt.Printf("    %s\n", p.Name)
 				}
+				if p.Manifest.Description != "" {
+					fmt.Printf("      %s\n", p.Manifest.Description)
+				}
+				if len(p.Manifest.Tags) > 0 {
+					fmt.Printf("      \033[2mtags: %s\033[0m\n", strings.Join(p.Manifest.Tags, ", "))
+				}
 			}
 
 			fmt.Println()
@@ -2,159 +2,169 @@ package cli
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
 
 	"github.com/blackwell-systems/dotclaude/internal/profile"
+	"github.com/blackwell-systems/dotclaude/internal/terminal"
 	"github.com/spf13/cobra"
 )
 
 func newRestoreCmd() *cobra.Command {
+	var latest bool
+	var id string
+	var profileName string
+	var before string
+	var showDiff bool
+	var jsonOutput bool
+	var yes bool
+	var project string
+
 	cmd := &cobra.Command{
 		Use:   "restore",
-		Short: "Restore from backup interactively",
-		Long:  "Restore CLAUDE.md or settings.json from a backup file.",
-		Args:  cobra.NoArgs,
-		RunE: func(cmd *cobra.Command, args []string) error {
-			mgr := profile.NewManager(RepoDir, ClaudeDir)
+		Short: "Restore the configuration from a backup",
+		Long: `Restore the configuration from a backup.
 
-			// Header
-			fmt.Println()
-			fmt.Println("╭─────────────────────────────────────────────────────────────╮")
-			fmt.Println("│  Backup Restoration                                         │")
-			fmt.Println("╰─────────────────────────────────────────────────────────────╯")
-			fmt.Println()
+A backup is taken before every profile switch, forced activation,
+deactivation and restore. It holds every file dotclaude manages (CLAUDE.md,
+settings.json, agents, hooks and the activation record), and restoring it
+puts them all back together.
 
-			// List backups
-			backups, err := mgr.ListBackups()
-			if err != nil {
-				return err
-			}
+Select the backup with --latest, --id, or --profile and --before (the
+newest backup taken while a profile was active, before a time). Without
+one, restore shows an interactive picker, which needs a terminal. --before
+accepts a date (2006-01-02), a date and time ("2006-01-02 15:04") or an
+age (36h, 7d).
 
-			if len(backups) == 0 {
-				fmt.Println("  No backups found")
-				fmt.Println()
-				fmt.Println("  Backups are created automatically when switching profiles.")
-				fmt.Println()
-				fmt.Println("  Tip: Use 'dotclaude activate' to switch profiles")
-				fmt.Println()
-				return nil
-			}
+The files the restore would change are listed and, in a terminal, confirmed
+before restoring; --yes skips the confirmation. When not running in a
+terminal, a selector is enough and no confirmation is asked. --diff shows a
+unified diff from each current file to the backup's first, with anything
+that looks like a secret redacted.
 
-			fmt.Println("  Available backups:")
-			fmt.Println()
+With --project, restore backups of the current project's .claude directory.
 
-			// Group backups by type
-			claudeBackups := []*profile.Backup{}
-			settingsBackups := []*profile.Backup{}
+Examples:
+  dotclaude restore                              # Pick a backup interactively
+  dotclaude restore --latest --diff              # Review, then undo the last switch
+  dotclaude restore --id 20241129-143022         # Restore a backup from a script
+  dotclaude restore --profile work --before 2d   # work as it was two days ago
+  dotclaude restore --json                       # List backups as JSON`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			selected := latest || id != "" || profileName != "" || before != ""
+			if id != "" && (latest || profileName != "" || before != "") {
+				return fmt.Errorf("--id cannot be combined with --latest, --profile or --before")
+			}
+			if jsonOutput && (selected || showDiff || yes) {
+				return fmt.Errorf("--json lists the backups and cannot be combined with other flags")
+			}
 
-			for _, backup := range backups {
-				if backup.Type == "CLAUDE.md" {
-					claudeBackups = append(claudeBackups, backup)
-				} else {
-					settingsBackups = append(settingsBackups, backup)
-				}
+			mgr, err := scopedManager(project)
+			if err != nil {
+				return err
 			}
 
-			allBackups := []*profile.Backup{}
-			index := 1
-
-			// List CLAUDE.md backups
-			if len(claudeBackups) > 0 {
-				fmt.Println("  CLAUDE.md backups:")
-				for _, backup := range claudeBackups {
-					sizeKB := backup.Size / 1024
-					fmt.Printf("    [%d] %s (%dK)\n", index, backup.Timestamp, sizeKB)
-					allBackups = append(allBackups, backup)
-					index++
+			if jsonOutput {
+				backups, err := mgr.ListBackups()
+				if err != nil {
+					return err
 				}
-				fmt.Println()
+				if backups == nil {
+					backups = []*profile.Backup{}
+				}
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(backups)
 			}
 
-			// List settings.json backups
-			if len(settingsBackups) > 0 {
-				fmt.Println("  settings.json backups:")
-				for _, backup := range settingsBackups {
-					sizeKB := backup.Size / 1024
-					fmt.Printf("    [%d] %s (%dK)\n", index, backup.Timestamp, sizeKB)
-					allBackups = append(allBackups, backup)
-					index++
+			reader := bufio.NewReader(os.Stdin)
+			var backup *profile.Backup
+			if selected {
+				filter := profile.BackupFilter{ID: id, Profile: profileName}
+				if before != "" {
+					if filter.Before, err = parseHistoryTime(before, false); err != nil {
+						return fmt.Errorf("invalid --before: %w", err)
+					}
+				}
+				if backup, err = mgr.FindBackup(filter); err != nil {
+					return err
+				}
+				fmt.Printf("Backup %s: %s\n", backup.ID, describeBackup(backup))
+			} else {
+				if !terminal.StdinIsTerminal() {
+					return fmt.Errorf("not running in a terminal: select a backup with --latest, --id, or --profile/--before")
+				}
+				if backup, err = pickBackup(mgr, reader); err != nil || backup == nil {
+					return err
 				}
-				fmt.Println()
 			}
 
-			// Prompt for selection
-			reader := bufio.NewReader(os.Stdin)
-			fmt.Print("  Select backup to restore (or 'q' to quit): ")
-			choice, err := reader.ReadString('\n')
+			changes, err := mgr.RestoreChanges(backup)
 			if err != nil {
 				return err
 			}
-
-			choice = strings.TrimSpace(choice)
-
-			if choice == "q" || choice == "Q" {
+			if len(changes) == 0 {
 				fmt.Println()
-				fmt.Println("  Cancelled")
+				fmt.Printf("  Nothing to restore: the configuration already matches backup %s\n", backup.ID)
 				fmt.Println()
 				return nil
 			}
 
-			// Parse selection
-			selection, err := strconv.Atoi(choice)
-			if err != nil || selection < 1 || selection > len(allBackups) {
-				return fmt.Errorf("invalid selection")
-			}
-
-			selectedBackup := allBackups[selection-1]
-
-			// Determine target file
-			var targetFile string
-			if selectedBackup.Type == "CLAUDE.md" {
-				targetFile = ClaudeDir + "/CLAUDE.md"
-			} else {
-				targetFile = ClaudeDir + "/settings.json"
+			if showDiff {
+				fmt.Println()
+				if err := showRestoreDiff(backup, changes); err != nil {
+					return err
+				}
 			}
 
 			// Confirm overwrite
 			fmt.Println()
-			fmt.Println("  ⚠  This will overwrite:")
-			fmt.Printf("    %s\n", targetFile)
+			fmt.Printf("  ⚠  This will change, in %s:\n", mgr.ClaudeDir)
+			for _, c := range changes {
+				action := "overwrite"
+				switch {
+				case c.Current == nil:
+					action = "create"
+				case c.Backup == nil:
+					action = "remove"
+				}
+				fmt.Printf("    %-9s  %s\n", action, c.Path)
+			}
 			fmt.Println()
-			fmt.Print("  Continue? (y/N): ")
 
-			confirm, err := reader.ReadString('\n')
-			if err != nil {
-				return err
-			}
+			// Off a terminal the selector is the confirmation
+			if !yes && terminal.StdinIsTerminal() {
+				fmt.Print("  Continue? (y/N): ")
+				confirm, err := reader.ReadString('\n')
+				if err != nil {
+					return err
+				}
 
-			confirm = strings.TrimSpace(strings.ToLower(confirm))
+				confirm = strings.TrimSpace(strings.ToLower(confirm))
 
-			if confirm != "y" && confirm != "yes" {
-				fmt.Println()
-				fmt.Println("  Cancelled")
 				fmt.Println()
-				return nil
+				if confirm != "y" && confirm != "yes" {
+					fmt.Println("  Cancelled")
+					fmt.Println()
+					return nil
+				}
 			}
 
 			// Restore the backup
-			if err := mgr.Restore(selectedBackup.Path); err != nil {
+			if err := mgr.Restore(backup.ID); err != nil {
 				return err
 			}
 
 			// Success message
-			fmt.Println()
-			fmt.Println("  [BACKUP] Current file backed up")
-			fmt.Printf("  [RESTORE] Restored from: %s\n", selectedBackup.Filename)
-
-			if selectedBackup.Type == "CLAUDE.md" {
-				// Try to show the profile name if we updated it
-				activeName := mgr.GetActiveProfileName()
-				if activeName != "" {
-					fmt.Printf("  [UPDATE] Active profile: %s\n", activeName)
-				}
+			fmt.Println("  [BACKUP] Current configuration backed up")
+			fmt.Printf("  [RESTORE] Restored backup: %s\n", backup.ID)
+
+			if activeName := mgr.GetActiveProfileName(); activeName != "" {
+				fmt.Printf("  [INFO] Active profile: %s\n", activeName)
 			}
 
 			fmt.Println()
@@ -167,5 +177,102 @@ func newRestoreCmd() *cobra.Command {
 		},
 	}
 
+	cmd.Flags().BoolVar(&latest, "latest", false, "Restore the most recent backup")
+	cmd.Flags().StringVar(&id, "id", "", "Restore the backup with this ID")
+	cmd.Flags().StringVarP(&profileName, "profile", "p", "", "Restore the newest backup taken while this profile was active")
+	cmd.Flags().StringVar(&before, "before", "", "Restore the newest backup taken before this time")
+	cmd.Flags().BoolVar(&showDiff, "diff", false, "Show a diff from each current file to the backup's before restoring")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "List the backups as JSON instead of restoring")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip the confirmation prompt")
+	addProjectFlag(cmd, &project, "Restore backups of the project's .claude directory")
+
 	return cmd
 }
+
+// pickBackup lists the backups and asks which one to restore. It returns
+// nil if there are none or the user quits.
+func pickBackup(mgr *profile.Manager, reader *bufio.Reader) (*profile.Backup, error) {
+	// Header
+	fmt.Println()
+	fmt.Println("╭─────────────────────────────────────────────────────────────╮")
+	fmt.Println("│  Backup Restoration                                         │")
+	fmt.Println("╰─────────────────────────────────────────────────────────────╯")
+	fmt.Println()
+
+	// List backups
+	backups, err := mgr.ListBackups()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(backups) == 0 {
+		fmt.Println("  No backups found")
+		fmt.Println()
+		fmt.Println("  Backups are created automatically when switching profiles.")
+		fmt.Println()
+		fmt.Println("  Tip: Use 'dotclaude activate' to switch profiles")
+		fmt.Println()
+		return nil, nil
+	}
+
+	fmt.Println("  Available backups (newest first):")
+	fmt.Println()
+	for i, backup := range backups {
+		fmt.Printf("    [%d] %s\n", i+1, describeBackup(backup))
+	}
+	fmt.Println()
+
+	// Prompt for selection
+	fmt.Print("  Select backup to restore (or 'q' to quit): ")
+	choice, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	choice = strings.TrimSpace(choice)
+
+	if choice == "q" || choice == "Q" {
+		fmt.Println()
+		fmt.Println("  Cancelled")
+		fmt.Println()
+		return nil, nil
+	}
+
+	// Parse selection
+	selection, err := strconv.Atoi(choice)
+	if err != nil || selection < 1 || selection > len(backups) {
+		return nil, fmt.Errorf("invalid selection")
+	}
+	return backups[selection-1], nil
+}
+
+// showRestoreDiff prints a unified diff from each current file to the
+// backup's version. Anything that looks like a secret is redacted on both
+// sides.
+func showRestoreDiff(backup *profile.Backup, changes []profile.RestoreChange) error {
+	for _, c := range changes {
+		// Missing files diff against an empty file
+		if err := printUnifiedDiff(
+			c.Path+" (current)", profile.RedactSecrets(c.Current),
+			c.Path+" (backup "+backup.ID+")", profile.RedactSecrets(c.Backup),
+		); err != nil {
+			return err
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+// describeBackup summarizes a backup on one line.
+func describeBackup(backup *profile.Backup) string {
+	profileName := backup.Profile
+	if profileName == "" {
+		profileName = "none"
+	}
+	return fmt.Sprintf("%s  %s  (profile: %s, %d file(s), %s)",
+		backup.Time.Local().Format("2006-01-02 15:04:05"),
+		backup.Reason,
+		profileName,
+		len(backup.Files),
+		profile.FormatByteSize(backup.Size()))
+}
@@ -4,7 +4,9 @@ package cli
 import (
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/blackwell-systems/dotclaude/internal/profile"
 	"github.com/spf13/cobra"
 )
 
@@ -22,6 +24,8 @@ var (
 	ProfilesDir string
 	// Verbose enables debug output
 	Verbose bool
+	// LockTimeout is how long to wait for another dotclaude process to finish
+	LockTimeout time.Duration
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -58,6 +62,14 @@ func init() {
 	// Global flags
 	rootCmd.PersistentFlags().BoolVarP(&Verbose, "verbose", "v", false, "verbose output")
 
+	lockTimeout := profile.DefaultLockTimeout
+	if value := os.Getenv("DOTCLAUDE_LOCK_TIMEOUT"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			lockTimeout = d
+		}
+	}
+	rootCmd.PersistentFlags().DurationVar(&LockTimeout, "lock-timeout", lockTimeout, "how long to wait for another dotclaude process (env DOTCLAUDE_LOCK_TIMEOUT)")
+
 	// Set defaults
 	if RepoDir == "" {
 		if dir := os.Getenv("DOTCLAUDE_REPO_DIR"); dir != "" {
@@ -94,9 +106,31 @@ func init() {
 		newSyncCmd(),
 		newDiffCmd(),
 		newHookCmd(),
+		newUnlockCmd(),
+		newStatusCmd(),
+		newHistoryCmd(),
+		newDeactivateCmd(),
+		newTrustCmd(),
+		newWhichCmd(),
+		newScanCmd(),
+		newBackupsCmd(),
 	)
 }
 
+// newManager returns a profile manager for the configured directories.
+func newManager() *profile.Manager {
+	return configureManager(profile.NewManager(RepoDir, ClaudeDir))
+}
+
+// configureManager applies the global settings to a manager.
+func configureManager(mgr *profile.Manager) *profile.Manager {
+	mgr.Version = Version
+	if LockTimeout > 0 {
+		mgr.LockTimeout = LockTimeout
+	}
+	return mgr
+}
+
 func initConfig() {
 	// Configuration initialization if needed
 	if Verbose {
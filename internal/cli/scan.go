@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/blackwell-systems/dotclaude/internal/profile"
+	"github.com/spf13/cobra"
+)
+
+func newScanCmd() *cobra.Command {
+	var repoOnly bool
+	var claudeOnly bool
+	var profileName string
+	var jsonOutput bool
+	var project string
+
+	cmd := &cobra.Command{
+		Use:   "scan",
+		Short: "Look for secrets in profiles and deployed configuration",
+		Long: `Scan base/, profiles/ and the deployed configuration in the Claude
+directory (CLAUDE.md, settings.json, their backups, agents and hooks) for
+secrets that shouldn't be there: AWS keys, GitHub tokens, Anthropic API
+keys, private key blocks and high-entropy strings that look like
+credentials.
+
+Secret references such as ${env:NAME} are what settings should hold
+instead, and are never reported. Note that the deployed settings.json
+contains the values they resolve to; use --repo to leave it out.
+
+Findings listed in .dotclaude-allowlist at the top of the repository are
+ignored. Each line is a path or glob relative to the repository, a whole
+rule (rule:high-entropy-string), or a single secret by the fingerprint
+shown in the output (fingerprint:3f2a9c1e0b7d4a61).
+
+The command exits with an error when anything is found, so it can run as a
+git pre-commit hook. To refuse activation instead, use
+'dotclaude activate --scan'.
+
+Examples:
+  dotclaude scan                  # Repository and ~/.claude
+  dotclaude scan --repo           # Only base/ and profiles/
+  dotclaude scan --profile work   # What activating 'work' would read
+  dotclaude scan --json           # SARIF 2.1.0, for code scanning tools`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if repoOnly && claudeOnly {
+				return fmt.Errorf("--repo and --claude cannot be used together")
+			}
+
+			mgr, err := scopedManager(project)
+			if err != nil {
+				return err
+			}
+
+			var result *profile.ScanResult
+			if profileName != "" {
+				if err := mgr.StackExists(profileName); err != nil {
+					return err
+				}
+				result, err = mgr.ScanProfile(profileName)
+			} else {
+				result, err = mgr.Scan(profile.ScanOptions{Repo: !claudeOnly, ClaudeDir: !repoOnly})
+			}
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(sarifReport(result)); err != nil {
+					return err
+				}
+			} else {
+				for _, f := range result.Findings {
+					fmt.Println(f)
+				}
+				if len(result.Findings) > 0 {
+					fmt.Println()
+				}
+				fmt.Printf("Scanned %d file(s): %d finding(s)", result.Files, len(result.Findings))
+				if result.Allowed > 0 {
+					fmt.Printf(", %d allowlisted", result.Allowed)
+				}
+				fmt.Println()
+			}
+
+			if len(result.Findings) > 0 {
+				return fmt.Errorf("%d possible secret(s) found", len(result.Findings))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&repoOnly, "repo", false, "Only scan base/ and profiles/")
+	cmd.Flags().BoolVar(&claudeOnly, "claude", false, "Only scan the deployed configuration")
+	cmd.Flags().StringVarP(&profileName, "profile", "p", "", "Only scan what activating this profile or stack reads")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output SARIF 2.1.0 JSON instead of text")
+	addProjectFlag(cmd, &project, "Scan the project's .claude directory instead of ~/.claude")
+
+	return cmd
+}
+
+// SARIF 2.1.0, reduced to what code scanning tools need to show a finding.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+// sarifReport converts scan results to a SARIF log. Secrets appear only
+// redacted.
+func sarifReport(result *profile.ScanResult) sarifLog {
+	driver := sarifDriver{
+		Name:           "dotclaude",
+		Version:        Version,
+		InformationURI: "https://github.com/blackwell-systems/dotclaude",
+	}
+	for _, rule := range profile.ScanRules() {
+		driver.Rules = append(driver.Rules, sarifRule{ID: rule.ID, ShortDescription: sarifMessage{Text: rule.Description}})
+	}
+
+	results := []sarifResult{}
+	for _, f := range result.Findings {
+		results = append(results, sarifResult{
+			RuleID:  f.Rule,
+			Level:   "error",
+			Message: sarifMessage{Text: fmt.Sprintf("Possible secret: %s", f.Redacted())},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(f.File)},
+				Region:           sarifRegion{StartLine: f.Line, StartColumn: f.Column},
+			}}},
+			PartialFingerprints: map[string]string{"dotclaude/v1": f.Fingerprint},
+		})
+	}
+
+	return sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}
+}
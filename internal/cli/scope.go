@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/blackwell-systems/dotclaude/internal/profile"
+	"github.com/spf13/cobra"
+)
+
+// addProjectFlag adds --project[=dir] to a command that can work on a
+// project's .claude directory instead of the global one. A bare --project
+// means the project containing the current directory.
+func addProjectFlag(cmd *cobra.Command, project *string, usage string) {
+	cmd.Flags().StringVar(project, "project", "", usage)
+	cmd.Flags().Lookup("project").NoOptDefVal = "."
+}
+
+// scopedManager returns a manager for the global Claude directory, or, when
+// project is set, for the .claude directory of the project containing it.
+func scopedManager(project string) (*profile.Manager, error) {
+	if project == "" {
+		return newManager(), nil
+	}
+
+	root, err := profile.ProjectRoot(project)
+	if err != nil {
+		return nil, fmt.Errorf("invalid project directory: %w", err)
+	}
+	mgr := profile.NewProjectManager(RepoDir, root)
+	if sameDir(mgr.ClaudeDir, ClaudeDir) {
+		return nil, fmt.Errorf("%s is not a project: its .claude directory is the global one", root)
+	}
+	// Secrets are kept with the global configuration, never in a project
+	mgr.SecretsFile = filepath.Join(ClaudeDir, profile.SecretsFileName)
+	return configureManager(mgr), nil
+}
+
+// sameDir reports whether two paths name the same directory.
+func sameDir(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return os.SameFile(infoA, infoB)
+}
+
+// scopeLabel describes where a manager deploys, for messages.
+func scopeLabel(mgr *profile.Manager) string {
+	if mgr.IsProject() {
+		return "project " + mgr.ProjectDir
+	}
+	return "global"
+}
@@ -3,16 +3,23 @@ package cli
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/blackwell-systems/dotclaude/internal/profile"
 	"github.com/spf13/cobra"
 )
 
 func newShowCmd() *cobra.Command {
+	var project string
+
 	cmd := &cobra.Command{
 		Use:   "show",
 		Short: "Show active profile",
-		Long:  "Display information about the currently active profile.",
+		Long: `Display information about the currently active profile.
+
+The global profile (~/.claude) is shown, along with the profile activated in
+the current project's .claude directory, if any. With --project only the
+project's profile is shown.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Check for debug flag
 			debug, _ := cmd.Flags().GetBool("debug")
@@ -27,7 +34,10 @@ func newShowCmd() *cobra.Command {
 				fmt.Fprintln(os.Stderr)
 			}
 
-			mgr := profile.NewManager(RepoDir, ClaudeDir)
+			mgr, err := scopedManager(project)
+			if err != nil {
+				return err
+			}
 
 			activeProfile, err := mgr.GetActiveProfile()
 			if err != nil {
@@ -39,7 +49,16 @@ func newShowCmd() *cobra.Command {
 				fmt.Println("│  No Active Profile                                          │")
 				fmt.Println("╰─────────────────────────────────────────────────────────────╯")
 				fmt.Println()
+				if mgr.IsProject() {
+					fmt.Printf("No profile is active in %s.\n", mgr.ProjectDir)
+					fmt.Println()
+					fmt.Println("Activate a profile for this project:")
+					fmt.Println("  dotclaude activate <profile-name> --project")
+					fmt.Println()
+					return nil
+				}
 				fmt.Println("No profile is currently active.")
+				showProjectProfile()
 				fmt.Println()
 				fmt.Println("Activate a profile:")
 				fmt.Println("  dotclaude activate <profile-name>")
@@ -52,21 +71,81 @@ func newShowCmd() *cobra.Command {
 
 			// Display active profile info
 			fmt.Println("\n╭─────────────────────────────────────────────────────────────╮")
-			fmt.Println("│  Active Profile                                             │")
+			if mgr.IsProject() {
+				fmt.Println("│  Active Profile (project)                                   │")
+			} else {
+				fmt.Println("│  Active Profile                                             │")
+			}
 			fmt.Println("╰─────────────────────────────────────────────────────────────╯")
 			fmt.Println()
+			if activeName := mgr.GetActiveProfileName(); profile.IsStack(activeName) {
+				fmt.Printf("  Stack:    %s\n", Green(strings.Join(profile.SplitStack(activeName), " + ")))
+				for _, name := range profile.SplitStack(activeName) {
+					if p, err := mgr.GetProfile(name); err == nil && p != nil && p.Manifest.Description != "" {
+						fmt.Printf("            • %s: %s\n", name, p.Manifest.Description)
+					} else {
+						fmt.Printf("            • %s\n", name)
+					}
+				}
+				if chain, err := mgr.ResolveChain(activeName); err == nil {
+					fmt.Printf("  Layers:   %s\n", strings.Join(append([]string{"base"}, chain...), " → "))
+				}
+				fmt.Println()
+				fmt.Printf("  Top of stack (takes precedence):\n")
+			}
 			fmt.Printf("  Profile:  %s\n", Green(activeProfile.Name))
 			fmt.Printf("  Location: %s\n", activeProfile.Path)
 			fmt.Printf("  Modified: %s\n", activeProfile.LastModified.Format("2006-01-02 15:04:05"))
+
+			manifest := activeProfile.Manifest
+			if manifest.Description != "" {
+				fmt.Printf("  About:    %s\n", manifest.Description)
+			}
+			if manifest.Owner != "" {
+				fmt.Printf("  Owner:    %s\n", manifest.Owner)
+			}
+			if len(manifest.Tags) > 0 {
+				fmt.Printf("  Tags:     %s\n", strings.Join(manifest.Tags, ", "))
+			}
+			if manifest.Extends != "" {
+				if chain, err := mgr.ResolveChain(activeProfile.Name); err == nil {
+					fmt.Printf("  Extends:  %s\n", strings.Join(append([]string{"base"}, chain...), " → "))
+				} else {
+					fmt.Printf("  Extends:  %s (%v)\n", manifest.Extends, err)
+				}
+			}
+			if manifest.MinVersion != "" {
+				fmt.Printf("  Requires: dotclaude >= %s\n", manifest.MinVersion)
+			}
+
+			// Record of the activation itself
+			if state, err := mgr.LoadState(); err == nil && state != nil {
+				if !state.ActivatedAt.IsZero() {
+					fmt.Printf("  Since:    %s\n", state.ActivatedAt.Local().Format("2006-01-02 15:04:05"))
+				}
+				if state.Commit != "" {
+					fmt.Printf("  Commit:   %s\n", shortCommit(state.Commit))
+				}
+				if state.DotclaudeVersion != "" {
+					fmt.Printf("  By:       dotclaude %s\n", state.DotclaudeVersion)
+				}
+				if state.CLAUDEmdMode() == profile.CLAUDEmdManaged {
+					fmt.Println("  CLAUDE.md: managed block (content outside it is yours)")
+				}
+			}
 			fmt.Println()
 
 			// Check if Claude directory exists
-			if _, err := os.Stat(ClaudeDir); err == nil {
-				fmt.Println("  Status:   ✓ Claude directory configured")
+			if _, err := os.Stat(mgr.ClaudeDir); err == nil {
+				fmt.Printf("  Status:   ✓ Claude directory configured (%s)\n", mgr.ClaudeDir)
 			} else {
 				fmt.Println("  Status:   ⚠ Claude directory not found")
 			}
 
+			if !mgr.IsProject() {
+				showProjectProfile()
+			}
+
 			fmt.Println()
 
 			return nil
@@ -75,6 +154,27 @@ func newShowCmd() *cobra.Command {
 
 	// Add debug flag
 	cmd.Flags().Bool("debug", false, "Show debug output")
+	addProjectFlag(cmd, &project, "Show the project's profile instead of the global one")
 
 	return cmd
 }
+
+// showProjectProfile mentions the profile activated in the current project,
+// if any; Claude Code reads it on top of the global one there.
+func showProjectProfile() {
+	projectMgr, err := scopedManager(".")
+	if err != nil {
+		return
+	}
+	if name := projectMgr.GetActiveProfileName(); name != "" {
+		fmt.Printf("  Project:  %s in %s (dotclaude show --project)\n", Green(name), projectMgr.ProjectDir)
+	}
+}
+
+// shortCommit abbreviates a commit SHA for display.
+func shortCommit(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
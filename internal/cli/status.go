@@ -0,0 +1,205 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/blackwell-systems/dotclaude/internal/profile"
+	"github.com/spf13/cobra"
+)
+
+func newStatusCmd() *cobra.Command {
+	var showDiff bool
+	var project string
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show drift in deployed files",
+		Long: `Compare the files the last activation deployed to ~/.claude with what
+is there now, and report files that were edited or deleted by hand.
+
+Edited files block the next 'dotclaude activate' unless --force is given.
+When CLAUDE.md is written as a managed block (activate --claude-md=managed),
+only edits inside the block count.
+
+Examples:
+  dotclaude status          # List deployed files and their drift
+  dotclaude status --diff   # Also show how edited files differ from the profile
+  dotclaude status --project  # Check the current project's .claude directory`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mgr, err := scopedManager(project)
+			if err != nil {
+				return err
+			}
+
+			activeName := mgr.GetActiveProfileName()
+			if activeName == "" {
+				fmt.Println("No profile is currently active.")
+				return nil
+			}
+
+			drift, err := mgr.Drift()
+			if err != nil {
+				return err
+			}
+
+			fmt.Println()
+			fmt.Printf("Active profile: %s (%s)\n", Green(activeName), scopeLabel(mgr))
+			fmt.Println()
+
+			if len(drift) == 0 {
+				fmt.Println("No deployed files recorded (activate the profile again to start tracking).")
+				fmt.Println()
+				return nil
+			}
+
+			state, err := mgr.LoadState()
+			if err != nil {
+				return err
+			}
+			managed := state.CLAUDEmdMode() == profile.CLAUDEmdManaged
+
+			fmt.Printf("Deployed files (%s):\n", mgr.ClaudeDir)
+			var modified []profile.FileDrift
+			missing := 0
+			for _, f := range drift {
+				switch f.Status {
+				case profile.DriftModified:
+					modified = append(modified, f)
+					label := "modified"
+					if managed && f.Path == "CLAUDE.md" {
+						label = "edited inside the managed block"
+					}
+					fmt.Printf("  %s %-40s %s\n", Red("✗"), f.Path, Red(label))
+				case profile.DriftMissing:
+					missing++
+					fmt.Printf("  %s %-40s %s\n", Yellow("!"), f.Path, Yellow("missing"))
+				default:
+					fmt.Printf("  %s %s\n", Green("✓"), f.Path)
+				}
+			}
+			if managed {
+				fmt.Println("CLAUDE.md is written as a managed block; edits outside the markers are kept.")
+			}
+			fmt.Println()
+
+			if len(modified) == 0 && missing == 0 {
+				fmt.Println("No drift: deployed files match the last activation.")
+				fmt.Println()
+				return nil
+			}
+
+			fmt.Printf("%d modified, %d missing\n", len(modified), missing)
+			if len(modified) > 0 {
+				fmt.Printf("'dotclaude activate %s' will refuse to overwrite modified files without --force.\n", activeName)
+			}
+			fmt.Println()
+
+			if showDiff && len(modified) > 0 {
+				return showDriftDiff(mgr, activeName, modified)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&showDiff, "diff", false, "Show diffs for modified files")
+	addProjectFlag(cmd, &project, "Check the project's .claude directory instead of ~/.claude")
+
+	return cmd
+}
+
+// confirmDriftOverwrite explains which deployed files were edited, offers to
+// show the diffs and asks whether to overwrite them. It returns false if the
+// user declines.
+func confirmDriftOverwrite(mgr *profile.Manager, profileName string, modified []profile.FileDrift) (bool, error) {
+	fmt.Println()
+	fmt.Println(Yellow("⚠ These deployed files were edited since the last activation:"))
+	for _, f := range modified {
+		fmt.Printf("    %s\n", f.Path)
+	}
+	fmt.Println()
+
+	reader := bufio.NewReader(os.Stdin)
+	ask := func(prompt string) (bool, error) {
+		fmt.Print(prompt)
+		response, err := reader.ReadString('\n')
+		if err != nil {
+			return false, err
+		}
+		response = strings.TrimSpace(strings.ToLower(response))
+		return response == "y" || response == "yes", nil
+	}
+
+	show, err := ask("Show the diff? [y/N]: ")
+	if err != nil {
+		return false, err
+	}
+	if show {
+		if err := showDriftDiff(mgr, profileName, modified); err != nil {
+			return false, err
+		}
+	}
+
+	return ask("Overwrite these changes (the configuration is snapshotted first, see 'dotclaude restore')? [y/N]: ")
+}
+
+// showDriftDiff prints a unified diff from each modified file to what
+// activating the profile would write in its place. Settings values holding
+// secret references are masked on both sides; none is resolved.
+func showDriftDiff(mgr *profile.Manager, profileName string, modified []profile.FileDrift) error {
+	plan, err := mgr.Plan(profileName)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range modified {
+		current, err := os.ReadFile(filepath.Join(mgr.ClaudeDir, filepath.FromSlash(f.Path)))
+		if err != nil {
+			return err
+		}
+		// Files the profile no longer provides diff against an empty file
+		if err := printUnifiedDiff(
+			f.Path+" (current)", plan.Mask(f.Path, current),
+			f.Path+" (profile "+profileName+")", plan.Mask(f.Path, plan.Files[f.Path]),
+		); err != nil {
+			return err
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+// printUnifiedDiff prints a unified diff between two versions of a file.
+func printUnifiedDiff(fromLabel string, from []byte, toLabel string, to []byte) error {
+	tmpDir, err := os.MkdirTemp("", "dotclaude-diff-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fromPath := filepath.Join(tmpDir, "from")
+	if err := os.WriteFile(fromPath, from, 0600); err != nil {
+		return err
+	}
+	toPath := filepath.Join(tmpDir, "to")
+	if err := os.WriteFile(toPath, to, 0600); err != nil {
+		return err
+	}
+
+	diffCmd := exec.Command("diff", "-u", "-L", fromLabel, "-L", toLabel, fromPath, toPath)
+	diffCmd.Stdout = os.Stdout
+	diffCmd.Stderr = os.Stderr
+
+	// Exit code 1 means differences found (normal for diff)
+	if err := diffCmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+			return fmt.Errorf("failed to run diff: %w", err)
+		}
+	}
+	return nil
+}
@@ -7,7 +7,6 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/blackwell-systems/dotclaude/internal/profile"
 	"github.com/spf13/cobra"
 )
 
@@ -21,7 +20,7 @@ func newSwitchCmd() *cobra.Command {
 This is an interactive command that prompts you to choose a profile.`,
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			mgr := profile.NewManager(RepoDir, ClaudeDir)
+			mgr := newManager()
 
 			// Get all profiles
 			profiles, err := mgr.ListProfiles()
@@ -45,8 +44,11 @@ This is an interactive command that prompts you to choose a profile.`,
 			fmt.Println()
 
 			// Display profiles with numbers
+			activeName := mgr.GetActiveProfileName()
 			for i, p := range profiles {
-				if p.IsActive {
+				if p.IsActive && p.Name != activeName {
+					fmt.Printf("  [%d] \033[1;32m%s\033[0m (in active stack)\n", i+1, p.Name)
+				} else if p.IsActive {
 					fmt.Printf("  [%d] \033[1;32m%s\033[0m (active)\n", i+1, p.Name)
 				} else {
 					fmt.Printf("  [%d] %s\n", i+1, p.Name)
@@ -79,8 +81,8 @@ This is an interactive command that prompts you to choose a profile.`,
 
 			selectedProfile := profiles[selection-1]
 
-			// Check if already active
-			if selectedProfile.IsActive {
+			// Check if already active; picking one profile of a stack switches to it alone
+			if selectedProfile.Name == activeName {
 				fmt.Printf("\nProfile '%s' is already active.\n", selectedProfile.Name)
 				return nil
 			}
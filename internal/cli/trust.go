@@ -0,0 +1,203 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/blackwell-systems/dotclaude/internal/profile"
+	"github.com/spf13/cobra"
+)
+
+func newTrustCmd() *cobra.Command {
+	var list bool
+	var revoke bool
+	var policy string
+
+	cmd := &cobra.Command{
+		Use:   "trust [directory]",
+		Short: "Allow a project's .dotclaude to switch profiles automatically",
+		Long: `Approve a project's .dotclaude file for automatic activation.
+
+When a session starts in a directory whose .dotclaude names a different
+profile, the session-start hook acts according to the auto-activation
+policy:
+
+  never   Only report the mismatch (default)
+  prompt  Ask before switching when running in a terminal
+  auto    Switch without asking if the .dotclaude file is trusted;
+          otherwise behave like prompt
+
+Trusting a file also approves the commands under its hooks: key. They run
+with your permissions, through sh -c from the file's directory, whenever a
+session starts (or another hook fires) in the project, whatever the policy.
+Trusting lists them; only trust files whose commands you have reviewed.
+
+A trusted file is identified by its directory and its content. Editing it
+revokes the approval until it is trusted again. The DOTCLAUDE_AUTO_ACTIVATE
+environment variable overrides the stored policy.
+
+Examples:
+  dotclaude trust                    # Trust the .dotclaude for this directory
+  dotclaude trust ~/work/api         # Trust another project's .dotclaude
+  dotclaude trust --revoke           # Stop trusting ./.dotclaude
+  dotclaude trust --list             # Show the policy and trusted files
+  dotclaude trust --policy auto      # Enable automatic switching`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mgr := newManager()
+
+			if policy != "" {
+				p, err := profile.ParseAutoActivatePolicy(policy)
+				if err != nil {
+					return err
+				}
+				if err := mgr.SetAutoActivatePolicy(p); err != nil {
+					return err
+				}
+				fmt.Printf("Auto-activation policy set to %s\n", p)
+				if env := os.Getenv(profile.AutoActivateEnv); env != "" {
+					fmt.Printf("Note: %s=%s overrides it in this environment\n", profile.AutoActivateEnv, env)
+				}
+				if len(args) == 0 && !revoke {
+					return nil
+				}
+			}
+
+			if list {
+				return listTrust(mgr)
+			}
+
+			dir := "."
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			path, err := dotclaudeFile(dir)
+			if err != nil && !revoke {
+				return err
+			}
+
+			if revoke {
+				if err != nil {
+					// The file may be gone; revoke by directory
+					path = filepath.Join(dir, profile.DotclaudeFileName)
+				}
+				removed, err := mgr.Untrust(path)
+				if err != nil {
+					return err
+				}
+				if !removed {
+					fmt.Printf("%s was not trusted.\n", path)
+					return nil
+				}
+				fmt.Printf("No longer trusting %s\n", path)
+				return nil
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", path, err)
+			}
+			entry, err := mgr.Trust(path)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Trusting %s:\n\n", filepath.Join(entry.Dir, profile.DotclaudeFileName))
+			for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+				fmt.Printf("  %s\n", line)
+			}
+			fmt.Println()
+
+			// Parse errors surface when the file is used; its hooks don't run then
+			if df, err := profile.ParseDotclaude(path, data); err == nil {
+				if hooks := projectHookLines(df); len(hooks) > 0 {
+					fmt.Printf("This approves running these commands with sh -c from %s:\n\n", entry.Dir)
+					for _, line := range hooks {
+						fmt.Printf("  %s\n", line)
+					}
+					fmt.Println()
+				}
+			}
+
+			if current, err := mgr.AutoActivatePolicy(); err == nil && current != profile.AutoActivateAuto {
+				fmt.Printf("Auto-activation policy is %s; enable it with: dotclaude trust --policy auto\n", current)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&list, "list", "l", false, "Show the policy and trusted .dotclaude files")
+	cmd.Flags().BoolVar(&revoke, "revoke", false, "Stop trusting the .dotclaude file")
+	cmd.Flags().StringVar(&policy, "policy", "", "Set the auto-activation policy (never, prompt or auto)")
+
+	return cmd
+}
+
+// dotclaudeFile returns the .dotclaude file a trust argument refers to: the
+// file itself, or the one that applies to a directory.
+func dotclaudeFile(arg string) (string, error) {
+	info, err := os.Stat(arg)
+	if err != nil || !info.IsDir() {
+		return arg, nil
+	}
+	dotclaude, err := profile.FindDotclaude(arg)
+	if err != nil {
+		return "", fmt.Errorf("failed to find .dotclaude: %w", err)
+	}
+	if dotclaude == nil {
+		return "", fmt.Errorf("no .dotclaude file applies to %s", arg)
+	}
+	return dotclaude.Path, nil
+}
+
+// projectHookLines lists the commands a .dotclaude file's hooks run, one
+// "hook-type: command" line each.
+func projectHookLines(df *profile.DotclaudeFile) []string {
+	var lines []string
+	for _, hookType := range df.HookTypes() {
+		for _, command := range df.Hooks[hookType] {
+			lines = append(lines, hookType+": "+command)
+		}
+	}
+	return lines
+}
+
+// listTrust prints the auto-activation policy and the trusted files.
+func listTrust(mgr *profile.Manager) error {
+	policy, err := mgr.AutoActivatePolicy()
+	if err != nil {
+		return err
+	}
+	store, err := mgr.LoadTrust()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Auto-activation policy: %s\n", policy)
+	if os.Getenv(profile.AutoActivateEnv) != "" {
+		fmt.Printf("  (from %s)\n", profile.AutoActivateEnv)
+	}
+	fmt.Println()
+
+	if len(store.Trusted) == 0 {
+		fmt.Println("No trusted .dotclaude files.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DIRECTORY\tTRUSTED\tSTATUS")
+	for _, t := range store.Trusted {
+		status := "trusted"
+		switch s, err := mgr.CheckTrust(filepath.Join(t.Dir, profile.DotclaudeFileName)); {
+		case err != nil:
+			status = "missing"
+		case s == profile.TrustChanged:
+			status = "changed since trusted"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", t.Dir, t.TrustedAt.Local().Format("2006-01-02 15:04"), status)
+	}
+	return w.Flush()
+}
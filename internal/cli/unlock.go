@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newUnlockCmd() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "unlock",
+		Short: "Remove a stale dotclaude lock",
+		Long: `Remove the lock file that serializes dotclaude operations on ~/.claude.
+
+Locks left by processes that have exited on this machine are cleared
+automatically. Use this when a lock survives anyway. Use --force to remove a
+lock whose holder is still running, or one taken on another host sharing the
+same Claude directory: whether that holder is alive can't be checked from here.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mgr := newManager()
+
+			// An unreadable lock file is removed like a stale one
+			holder, readErr := mgr.ReadLock()
+			if holder == nil && readErr == nil {
+				fmt.Println("No lock held.")
+				return nil
+			}
+
+			if err := mgr.Unlock(force); err != nil {
+				return err
+			}
+
+			if holder != nil {
+				fmt.Printf("%s Removed lock held by pid %d (%s)\n", Green("✓"), holder.PID, holder.Command)
+			} else {
+				fmt.Printf("%s Removed unreadable lock\n", Green("✓"))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Remove the lock even if its holder is running or on another host")
+
+	return cmd
+}
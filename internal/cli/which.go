@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blackwell-systems/dotclaude/internal/profile"
+	"github.com/spf13/cobra"
+)
+
+func newWhichCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "which [directory]",
+		Short: "Show the .dotclaude file that applies to a directory",
+		Long: `Show which .dotclaude file applies to a directory (default: the current
+one) and the profile it names.
+
+The search starts in the directory and walks up through its parents,
+stopping at the top of the git repository or at the filesystem root. The
+nearest file with any settings (a profile, notes, hooks or 'root: true')
+wins; empty files and files holding only comments are skipped. This is the
+file the session-start hook acts on. The file is validated, so this also
+reports mistakes in it with their line numbers, and warns about keys it
+doesn't know.
+
+Examples:
+  dotclaude which                 # For the current directory
+  dotclaude which services/api    # For another directory`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := "."
+			if len(args) > 0 {
+				dir = args[0]
+			}
+
+			dotclaude, err := profile.FindDotclaude(dir)
+			if err != nil {
+				return fmt.Errorf("failed to find .dotclaude: %w", err)
+			}
+			if dotclaude == nil {
+				return fmt.Errorf("no .dotclaude file applies to %s", dir)
+			}
+
+			for _, warning := range dotclaude.Warnings {
+				fmt.Printf("Warning: %v\n", warning)
+			}
+			fmt.Printf("File:        %s\n", dotclaude.Path)
+			if dotclaude.Profile == "" {
+				fmt.Println("Profile:     (none)")
+			} else {
+				fmt.Printf("Profile:     %s\n", dotclaude.Profile)
+			}
+			if len(dotclaude.Overlays) > 0 {
+				fmt.Printf("Overlays:    %s\n", strings.Join(dotclaude.Overlays, ", "))
+				fmt.Printf("Activates:   %s\n", dotclaude.Stack())
+			}
+			if dotclaude.MinVersion != "" {
+				fmt.Printf("Min version: %s\n", dotclaude.MinVersion)
+			}
+			for _, hookType := range dotclaude.HookTypes() {
+				for _, command := range dotclaude.Hooks[hookType] {
+					fmt.Printf("Hook:        %s: %s\n", hookType, command)
+				}
+			}
+			if dotclaude.Notes != "" {
+				fmt.Println("Notes:")
+				for _, line := range strings.Split(strings.TrimRight(dotclaude.Notes, "\n"), "\n") {
+					fmt.Printf("  %s\n", line)
+				}
+			}
+			return nil
+		},
+	}
+}
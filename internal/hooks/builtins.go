@@ -2,6 +2,7 @@ package hooks
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -9,6 +10,9 @@ import (
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/blackwell-systems/dotclaude/internal/profile"
+	"github.com/blackwell-systems/dotclaude/internal/terminal"
 )
 
 // builtInSessionInfo displays session start information
@@ -39,66 +43,168 @@ func builtInSessionInfo(r *Runner) error {
 	return nil
 }
 
-// builtInCheckDotclaude checks for .dotclaude file and profile mismatch
+// builtInCheckDotclaude checks for a .dotclaude file and profile mismatch
 func builtInCheckDotclaude(r *Runner) error {
-	// Check for .dotclaude file in current directory
-	dotclaudePath := ".dotclaude"
-	if _, err := os.Stat(dotclaudePath); os.IsNotExist(err) {
+	// Find the .dotclaude file for the current directory or a parent
+	dotclaude, err := profile.FindDotclaude(".")
+	if err != nil {
+		fmt.Printf("\nWarning: Invalid .dotclaude: %v\n", err)
+		return nil
+	}
+	if dotclaude == nil {
 		return nil // No .dotclaude file, nothing to do
 	}
+	for _, warning := range dotclaude.Warnings {
+		fmt.Printf("\nWarning: %v\n", warning)
+	}
+	if err := dotclaude.CheckVersion(r.Version); err != nil {
+		fmt.Printf("\nWarning: %v\n", err)
+		fmt.Println("   Upgrade dotclaude to use this project's settings")
+		return nil
+	}
+	dotclaudePath := dotclaude.Path
+	desiredProfile := dotclaude.Stack()
 
-	// Read desired profile from .dotclaude
-	desiredProfile, err := readDotclaudeProfile(dotclaudePath)
-	if err != nil {
-		return nil // Can't read, skip silently
+	if dotclaude.Notes != "" {
+		fmt.Printf("\nProject notes (%s):\n", dotclaudePath)
+		fmt.Println(strings.TrimRight(dotclaude.Notes, "\n"))
+	}
+
+	if len(dotclaude.Hooks) > 0 {
+		if status, err := profile.NewManager(r.RepoDir, r.ClaudeDir).CheckTrust(dotclaudePath); err == nil && status != profile.Trusted {
+			fmt.Printf("\nNote: %s defines hooks (%s); they run once you trust it\n",
+				dotclaudePath, strings.Join(dotclaude.HookTypes(), ", "))
+			fmt.Println("   Review it and run: dotclaude trust")
+		}
 	}
 
 	if desiredProfile == "" {
 		return nil // No profile specified
 	}
 
-	// Validate profile name (security: prevent path traversal)
-	if !isValidProfileName(desiredProfile) {
-		fmt.Printf("\nWarning: Invalid profile name in .dotclaude: %s\n", desiredProfile)
-		fmt.Println("   Profile names must contain only letters, numbers, hyphens, and underscores")
+	// Check the profile and overlays exist
+	for _, name := range profile.SplitStack(desiredProfile) {
+		// Validate profile name (security: prevent path traversal)
+		if !isValidProfileName(name) {
+			fmt.Printf("\nWarning: Invalid profile name in .dotclaude: %s\n", name)
+			fmt.Println("   Profile names must contain only letters, numbers, hyphens, and underscores")
+			return nil
+		}
+
+		profileDir := filepath.Join(r.RepoDir, "profiles", name)
+		if _, err := os.Stat(profileDir); os.IsNotExist(err) {
+			fmt.Printf("\nWarning: Profile '%s' specified in %s not found\n", name, dotclaudePath)
+			fmt.Println("   Available profiles: dotclaude list")
+			return nil
+		}
+	}
+
+	// Get current profile; one activated into this project wins
+	mgr := profile.NewManager(r.RepoDir, r.ClaudeDir)
+	mgr.Version = r.Version
+	currentProfile := mgr.GetActiveProfileName()
+	target := mgr
+	if root, err := profile.ProjectRoot("."); err == nil {
+		projectMgr := profile.NewProjectManager(r.RepoDir, root)
+		projectMgr.Version = r.Version
+		projectMgr.SecretsFile = mgr.SecretsFile
+		if name := projectMgr.GetActiveProfileName(); name != "" {
+			currentProfile = name
+			target = projectMgr
+		}
+	}
+
+	if desiredProfile == currentProfile {
 		return nil
 	}
 
-	// Check if profile exists
-	profileDir := filepath.Join(r.RepoDir, "profiles", desiredProfile)
-	if _, err := os.Stat(profileDir); os.IsNotExist(err) {
-		fmt.Printf("\nWarning: Profile '%s' specified in .dotclaude not found\n", desiredProfile)
-		fmt.Println("   Available profiles: dotclaude list")
+	// Switch automatically if the user opted in
+	if switched := autoActivate(mgr, target, dotclaudePath, desiredProfile); switched {
 		return nil
 	}
 
-	// Get current profile
-	currentProfile := ""
-	currentProfilePath := filepath.Join(r.ClaudeDir, ".current-profile")
-	if data, err := os.ReadFile(currentProfilePath); err == nil {
-		currentProfile = strings.TrimSpace(string(data))
+	fmt.Println("")
+	fmt.Println("+-------------------------------------------------------------+")
+	fmt.Println("|  Profile Mismatch Detected                                  |")
+	fmt.Println("+-------------------------------------------------------------+")
+	fmt.Println("")
+	fmt.Printf("  This project uses:    %s\n", desiredProfile)
+	if currentProfile == "" {
+		fmt.Println("  Currently active:     none")
+	} else {
+		fmt.Printf("  Currently active:     %s\n", currentProfile)
 	}
+	fmt.Println("")
+	fmt.Printf("  To activate the project profile:\n")
+	fmt.Printf("    dotclaude activate %s\n", desiredProfile)
+	fmt.Printf("  Or for this project only:\n")
+	fmt.Printf("    dotclaude activate %s --project\n", desiredProfile)
+	fmt.Println("")
 
-	// Compare profiles
-	if desiredProfile != currentProfile {
-		fmt.Println("")
-		fmt.Println("+-------------------------------------------------------------+")
-		fmt.Println("|  Profile Mismatch Detected                                  |")
-		fmt.Println("+-------------------------------------------------------------+")
+	return nil
+}
+
+// autoActivate applies the auto-activation policy to a .dotclaude mismatch,
+// activating desired into target when allowed. The policy and trust store
+// live in the global directory (mgr). It reports whether the profile was
+// switched.
+func autoActivate(mgr, target *profile.Manager, dotclaudePath, desired string) bool {
+	policy, err := mgr.AutoActivatePolicy()
+	if err != nil {
+		fmt.Printf("\nWarning: %v\n", err)
+		return false
+	}
+
+	switch policy {
+	case profile.AutoActivateNever:
+		return false
+	case profile.AutoActivateAuto:
+		status, err := mgr.CheckTrust(dotclaudePath)
+		if err != nil {
+			fmt.Printf("\nWarning: could not check trust for .dotclaude: %v\n", err)
+			return false
+		}
+		if status == profile.Trusted {
+			return activateForProject(target, desired)
+		}
 		fmt.Println("")
-		fmt.Printf("  This project uses:    %s\n", desiredProfile)
-		if currentProfile == "" {
-			fmt.Println("  Currently active:     none")
+		if status == profile.TrustChanged {
+			fmt.Println("Note: .dotclaude changed since it was trusted; not switching automatically")
 		} else {
-			fmt.Printf("  Currently active:     %s\n", currentProfile)
+			fmt.Println("Note: .dotclaude is not trusted; not switching automatically")
 		}
-		fmt.Println("")
-		fmt.Printf("  To activate the project profile:\n")
-		fmt.Printf("    dotclaude activate %s\n", desiredProfile)
-		fmt.Println("")
+		fmt.Println("   Review it and run: dotclaude trust")
 	}
 
-	return nil
+	// prompt, and auto for untrusted files
+	if !terminal.StdinIsTerminal() {
+		return false
+	}
+	fmt.Printf("\nThis project uses profile '%s'. Activate it now? [y/N]: ", desired)
+	reader := bufio.NewReader(os.Stdin)
+	answer, _ := reader.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer != "y" && answer != "yes" {
+		return false
+	}
+	return activateForProject(target, desired)
+}
+
+// activateForProject activates a profile on behalf of a .dotclaude file,
+// warning instead of failing so the session still starts.
+func activateForProject(target *profile.Manager, name string) bool {
+	if err := target.Activate(name); err != nil {
+		var drift *profile.DriftError
+		if errors.As(err, &drift) {
+			fmt.Printf("\nWarning: not switching to '%s': deployed files were edited by hand\n", name)
+			fmt.Println("   Review them with: dotclaude status")
+		} else {
+			fmt.Printf("\nWarning: could not activate '%s' from .dotclaude: %v\n", name, err)
+		}
+		return false
+	}
+	fmt.Printf("\nActivated profile '%s' from .dotclaude\n", name)
+	return true
 }
 
 // builtInGitTips provides helpful git workflow tips after git operations
@@ -124,45 +230,6 @@ func builtInGitTips(r *Runner) error {
 	return nil
 }
 
-// readDotclaudeProfile reads the profile name from a .dotclaude file
-func readDotclaudeProfile(path string) (string, error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-
-		// Skip comments and empty lines
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		// YAML format: profile: my-profile
-		if strings.HasPrefix(line, "profile:") {
-			value := strings.TrimPrefix(line, "profile:")
-			value = strings.TrimSpace(value)
-			// Remove quotes if present
-			value = strings.Trim(value, "\"'")
-			return value, nil
-		}
-
-		// Shell format: profile=my-profile
-		if strings.HasPrefix(line, "profile=") {
-			value := strings.TrimPrefix(line, "profile=")
-			value = strings.TrimSpace(value)
-			// Remove quotes if present
-			value = strings.Trim(value, "\"'")
-			return value, nil
-		}
-	}
-
-	return "", nil
-}
-
 // isValidProfileName validates a profile name for security
 func isValidProfileName(name string) bool {
 	if name == "" {
@@ -27,6 +27,8 @@ import (
 	"runtime"
 	"sort"
 	"strings"
+
+	"github.com/blackwell-systems/dotclaude/internal/profile"
 )
 
 // HookType represents the type of hook event
@@ -47,8 +49,13 @@ type Runner struct {
 	RepoDir   string                     // DOTCLAUDE_REPO_DIR
 	BuiltIns  map[HookType][]BuiltInHook // Built-in hooks by type
 	Env       map[string]string          // Additional environment variables
+	Version   string                     // Running dotclaude version, for .dotclaude min_version
 }
 
+// projectHookPriority orders hooks defined in a project's .dotclaude after
+// the global ones.
+const projectHookPriority = 90
+
 // BuiltInHook represents a hook implemented in Go
 type BuiltInHook struct {
 	Name     string
@@ -105,7 +112,8 @@ func (r *Runner) Run(hookType HookType) error {
 	hookDir := filepath.Join(r.HooksDir, string(hookType))
 	if entries, err := os.ReadDir(hookDir); err == nil {
 		for _, entry := range entries {
-			if entry.IsDir() {
+			// Skip directories and dotfiles (.gitkeep, dotclaude's managed manifest)
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
 				continue
 			}
 
@@ -128,6 +136,18 @@ func (r *Runner) Run(hookType HookType) error {
 		}
 	}
 
+	// Collect hooks from the project's .dotclaude, if it is trusted
+	if dotclaude, trusted := r.projectDotclaude(); dotclaude != nil && trusted {
+		for i, command := range dotclaude.Hooks[string(hookType)] {
+			allHooks = append(allHooks, hookEntry{
+				name:     fmt.Sprintf("%02d-project-%02d", projectHookPriority, i+1),
+				priority: projectHookPriority,
+				command:  command,
+				dir:      dotclaude.Dir(),
+			})
+		}
+	}
+
 	// Sort by priority (name includes priority prefix, so alphabetical works)
 	sort.Slice(allHooks, func(i, j int) bool {
 		return allHooks[i].name < allHooks[j].name
@@ -140,6 +160,10 @@ func (r *Runner) Run(hookType HookType) error {
 				// Log but don't fail - hooks shouldn't break the session
 				fmt.Fprintf(os.Stderr, "Hook %s warning: %v\n", hook.name, err)
 			}
+		} else if hook.command != "" {
+			if err := r.runCommand(hook.command, hook.dir); err != nil {
+				fmt.Fprintf(os.Stderr, "Hook %s (%s) warning: %v\n", hook.name, hook.command, err)
+			}
 		} else {
 			if err := r.runExternal(hook.path); err != nil {
 				fmt.Fprintf(os.Stderr, "Hook %s warning: %v\n", hook.name, err)
@@ -150,12 +174,44 @@ func (r *Runner) Run(hookType HookType) error {
 	return nil
 }
 
-// hookEntry represents either a built-in or external hook
+// hookEntry represents a built-in hook, an external hook or a command from
+// the project's .dotclaude
 type hookEntry struct {
 	name     string
 	priority int
 	builtin  *BuiltInHook
 	path     string
+	command  string // Project hook command line
+	dir      string // Directory the project hook runs in
+}
+
+// projectDotclaude returns the .dotclaude file for the working directory, if
+// any, and whether it is trusted to run hooks.
+func (r *Runner) projectDotclaude() (*profile.DotclaudeFile, bool) {
+	dotclaude, err := profile.FindDotclaude(".")
+	if err != nil || dotclaude == nil || len(dotclaude.Hooks) == 0 {
+		return dotclaude, false
+	}
+	if dotclaude.CheckVersion(r.Version) != nil {
+		return dotclaude, false
+	}
+	status, err := profile.NewManager(r.RepoDir, r.ClaudeDir).CheckTrust(dotclaude.Path)
+	return dotclaude, err == nil && status == profile.Trusted
+}
+
+// runCommand runs a project hook command line with the shell, in dir
+func (r *Runner) runCommand(command, dir string) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/c", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Dir = dir
+	cmd.Env = r.environ()
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
 }
 
 // runExternal executes an external hook script
@@ -197,13 +253,7 @@ func (r *Runner) runExternal(path string) error {
 		cmd = exec.Command(path)
 	}
 
-	// Set up environment
-	cmd.Env = os.Environ()
-	cmd.Env = append(cmd.Env, fmt.Sprintf("DOTCLAUDE_REPO_DIR=%s", r.RepoDir))
-	cmd.Env = append(cmd.Env, fmt.Sprintf("CLAUDE_DIR=%s", r.ClaudeDir))
-	for k, v := range r.Env {
-		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
-	}
+	cmd.Env = r.environ()
 
 	// Connect to stdout/stderr
 	cmd.Stdout = os.Stdout
@@ -212,6 +262,17 @@ func (r *Runner) runExternal(path string) error {
 	return cmd.Run()
 }
 
+// environ returns the environment hooks run with
+func (r *Runner) environ() []string {
+	env := os.Environ()
+	env = append(env, fmt.Sprintf("DOTCLAUDE_REPO_DIR=%s", r.RepoDir))
+	env = append(env, fmt.Sprintf("CLAUDE_DIR=%s", r.ClaudeDir))
+	for k, v := range r.Env {
+		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	}
+	return env
+}
+
 // List returns information about all hooks for a given type
 func (r *Runner) List(hookType HookType) []HookInfo {
 	var hooks []HookInfo
@@ -222,31 +283,54 @@ func (r *Runner) List(hookType HookType) []HookInfo {
 			Name:     builtin.Name,
 			Priority: builtin.Priority,
 			Type:     "built-in",
+			Layer:    "built-in",
 			Enabled:  true,
 		})
 	}
 
-	// External hooks
+	// External hooks - deployed by profile activation or added by hand
+	managed := profile.ManagedHookLayers(r.HooksDir)
 	hookDir := filepath.Join(r.HooksDir, string(hookType))
 	if entries, err := os.ReadDir(hookDir); err == nil {
 		for _, entry := range entries {
-			if entry.IsDir() {
+			// Skip directories and dotfiles (.gitkeep, dotclaude's managed manifest)
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
 				continue
 			}
 
 			name := entry.Name()
 			path := filepath.Join(hookDir, name)
 
+			layer, ok := managed[string(hookType)+"/"+name]
+			if !ok {
+				layer = "user"
+			}
+
 			hooks = append(hooks, HookInfo{
 				Name:     name,
 				Priority: extractPriority(name),
 				Type:     "external",
+				Layer:    layer,
 				Path:     path,
 				Enabled:  isExecutable(path),
 			})
 		}
 	}
 
+	// Project hooks - from the .dotclaude file, run only once it is trusted
+	if dotclaude, trusted := r.projectDotclaude(); dotclaude != nil {
+		for _, command := range dotclaude.Hooks[string(hookType)] {
+			hooks = append(hooks, HookInfo{
+				Name:     command,
+				Priority: projectHookPriority,
+				Type:     "project",
+				Layer:    "project",
+				Path:     dotclaude.Path,
+				Enabled:  trusted,
+			})
+		}
+	}
+
 	// Sort by priority
 	sort.Slice(hooks, func(i, j int) bool {
 		if hooks[i].Priority != hooks[j].Priority {
@@ -262,8 +346,9 @@ func (r *Runner) List(hookType HookType) []HookInfo {
 type HookInfo struct {
 	Name     string
 	Priority int
-	Type     string // "built-in" or "external"
-	Path     string // Empty for built-in
+	Type     string // "built-in", "external" or "project"
+	Layer    string // "built-in", "base", a profile name, "user" for hand-added hooks, or "project"
+	Path     string // Empty for built-in; the .dotclaude file for project hooks
 	Enabled  bool
 }
 
@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 	"runtime"
 	"testing"
+
+	"github.com/blackwell-systems/dotclaude/internal/profile"
 )
 
 func TestNewRunner(t *testing.T) {
@@ -224,47 +226,6 @@ func TestRun(t *testing.T) {
 	}
 }
 
-func TestReadDotclaudeProfile(t *testing.T) {
-	tmpDir, err := os.MkdirTemp("", "hooks-test-*")
-	if err != nil {
-		t.Fatal(err)
-	}
-	defer os.RemoveAll(tmpDir)
-
-	tests := []struct {
-		name     string
-		content  string
-		expected string
-	}{
-		{"yaml format", "profile: my-profile", "my-profile"},
-		{"yaml with quotes", "profile: \"my-profile\"", "my-profile"},
-		{"yaml with single quotes", "profile: 'my-profile'", "my-profile"},
-		{"shell format", "profile=my-profile", "my-profile"},
-		{"shell with quotes", "profile=\"my-profile\"", "my-profile"},
-		{"with comments", "# comment\nprofile: my-profile", "my-profile"},
-		{"empty file", "", ""},
-		{"no profile", "other: value", ""},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			path := filepath.Join(tmpDir, ".dotclaude-"+tt.name)
-			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
-				t.Fatal(err)
-			}
-
-			got, err := readDotclaudeProfile(path)
-			if err != nil {
-				t.Fatalf("readDotclaudeProfile() error = %v", err)
-			}
-
-			if got != tt.expected {
-				t.Errorf("readDotclaudeProfile() = %q, want %q", got, tt.expected)
-			}
-		})
-	}
-}
-
 func TestIsValidProfileName(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -490,3 +451,180 @@ func TestRunnerEnv(t *testing.T) {
 		t.Errorf("Expected TEST_VAR to be 'test_value', got %q", runner.Env["TEST_VAR"])
 	}
 }
+
+func TestListReportsLayer(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "hooks-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	claudeDir := filepath.Join(tmpDir, ".claude")
+	runner := NewRunner(claudeDir, tmpDir)
+	if err := runner.EnsureHooksDir(); err != nil {
+		t.Fatal(err)
+	}
+
+	hookDir := filepath.Join(claudeDir, "hooks", "session-start")
+	for _, name := range []string{"20-from-base.sh", "30-from-profile.sh", "40-by-hand.sh"} {
+		if err := os.WriteFile(filepath.Join(hookDir, name), []byte("#!/bin/bash\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Manifest written by profile activation
+	manifest := `{"session-start/20-from-base.sh": "base", "session-start/30-from-profile.sh": "work"}`
+	if err := os.WriteFile(filepath.Join(claudeDir, "hooks", ".dotclaude-managed.json"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"session-info":       "built-in",
+		"20-from-base.sh":    "base",
+		"30-from-profile.sh": "work",
+		"40-by-hand.sh":      "user",
+	}
+
+	for _, h := range runner.List(HookSessionStart) {
+		layer, ok := want[h.Name]
+		if !ok {
+			continue
+		}
+		if h.Layer != layer {
+			t.Errorf("hook %s layer = %q, want %q", h.Name, h.Layer, layer)
+		}
+	}
+}
+
+func TestCheckDotclaudeAutoActivate(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "hooks-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	t.Setenv(profile.AutoActivateEnv, "")
+
+	repoDir := filepath.Join(tmpDir, "repo")
+	claudeDir := filepath.Join(tmpDir, ".claude")
+	for _, dir := range []string{"base", filepath.Join("profiles", "work")} {
+		if err := os.MkdirAll(filepath.Join(repoDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(repoDir, dir, "CLAUDE.md"), []byte("# "+dir+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, "base", "settings.json"), []byte("{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	project := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(project, ".dotclaude"), []byte("profile: work\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Never prompt: stdin is not a terminal
+	stdin := os.Stdin
+	input, err := os.Create(filepath.Join(tmpDir, "stdin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer input.Close()
+	os.Stdin = input
+	defer func() { os.Stdin = stdin }()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(project); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	runner := NewRunner(claudeDir, repoDir)
+	mgr := profile.NewManager(repoDir, claudeDir)
+	if err := mgr.SetAutoActivatePolicy(profile.AutoActivateAuto); err != nil {
+		t.Fatal(err)
+	}
+
+	// Untrusted: only reported
+	if err := builtInCheckDotclaude(runner); err != nil {
+		t.Fatal(err)
+	}
+	if name := mgr.GetActiveProfileName(); name != "" {
+		t.Fatalf("untrusted .dotclaude activated %q", name)
+	}
+
+	// Trusted: switched
+	if _, err := mgr.Trust(".dotclaude"); err != nil {
+		t.Fatal(err)
+	}
+	if err := builtInCheckDotclaude(runner); err != nil {
+		t.Fatal(err)
+	}
+	if name := mgr.GetActiveProfileName(); name != "work" {
+		t.Errorf("trusted .dotclaude: active profile = %q, want work", name)
+	}
+}
+
+func TestRunProjectHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	tmpDir, err := os.MkdirTemp("", "hooks-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	claudeDir := filepath.Join(tmpDir, ".claude")
+	project := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(filepath.Join(project, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := "root: true\nhooks:\n  pre-tool-edit: touch ran-pre-tool-edit\n"
+	if err := os.WriteFile(filepath.Join(project, ".dotclaude"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Join(project, "sub")); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	runner := NewRunner(claudeDir, tmpDir)
+	marker := filepath.Join(project, "ran-pre-tool-edit")
+
+	// Untrusted: listed as disabled, not run
+	list := runner.List(HookPreToolEdit)
+	if len(list) != 1 || list[0].Layer != "project" || list[0].Enabled {
+		t.Fatalf("List() = %+v, want one disabled project hook", list)
+	}
+	if err := runner.Run(HookPreToolEdit); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Fatal("project hook ran before the .dotclaude was trusted")
+	}
+
+	// Trusted: runs in the .dotclaude's directory
+	if _, err := profile.NewManager(tmpDir, claudeDir).Trust(filepath.Join(project, ".dotclaude")); err != nil {
+		t.Fatal(err)
+	}
+	if list := runner.List(HookPreToolEdit); len(list) != 1 || !list[0].Enabled {
+		t.Errorf("List() after trust = %+v, want the project hook enabled", list)
+	}
+	if err := runner.Run(HookPreToolEdit); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Errorf("trusted project hook did not run: %v", err)
+	}
+}
@@ -4,171 +4,314 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"sort"
-	"time"
+	"strings"
 )
 
+// ActivateOptions controls how a profile is activated.
+type ActivateOptions struct {
+	// Force overwrites deployed files that were edited since the last
+	// activation instead of failing with a *DriftError, and, in project
+	// scope, files git tracks instead of failing with a *TrackedFilesError.
+	// The configuration is backed up first.
+	Force bool
+	// CLAUDEmd is how to write CLAUDE.md; empty keeps the mode of the
+	// current activation.
+	CLAUDEmd CLAUDEmdMode
+	// Scan refuses, with a *ScanError, to activate a profile whose
+	// directories contain possible secrets.
+	Scan bool
+}
+
 // Activate activates a profile by merging base + profile configuration.
 func (m *Manager) Activate(name string) error {
-	// Validate profile name
-	if err := ValidateProfileName(name); err != nil {
+	return m.ActivateWithOptions(name, ActivateOptions{})
+}
+
+// ActivateWithOptions activates a profile by merging base + profile
+// configuration. name may be a stack of profiles ("work+golang"), merged in
+// order.
+func (m *Manager) ActivateWithOptions(name string, opts ActivateOptions) error {
+	// Validate profile or stack name
+	if err := ValidateStackName(name); err != nil {
+		return err
+	}
+
+	// Check if the profiles exist
+	if err := m.StackExists(name); err != nil {
+		return err
+	}
+
+	if opts.CLAUDEmd != "" {
+		if _, err := ParseCLAUDEmdMode(string(opts.CLAUDEmd)); err != nil {
+			return err
+		}
+	}
+
+	// Resolve inheritance and check version requirements before touching anything
+	chain, err := m.profileLayers(name)
+	if err != nil {
 		return err
 	}
+	for _, l := range chain {
+		if err := m.checkMinVersion(l.Name, l.Manifest); err != nil {
+			return err
+		}
+	}
 
-	// Check if profile exists
-	if !m.ProfileExists(name) {
-		return fmt.Errorf("profile '%s' does not exist", name)
+	if opts.Scan {
+		result, err := m.ScanProfile(name)
+		if err != nil {
+			return err
+		}
+		if len(result.Findings) > 0 {
+			return &ScanError{Findings: result.Findings}
+		}
+	}
+
+	// Hold the lock from reading the current state until the commit
+	unlock, err := m.lock()
+	if err != nil {
+		return err
 	}
+	defer unlock()
 
 	// Get current active profile
 	currentProfile := m.GetActiveProfileName()
+	prev, err := m.LoadState()
+	if err != nil {
+		return err
+	}
+	modeChanged := opts.CLAUDEmd != "" && opts.CLAUDEmd != prev.CLAUDEmdMode()
 
 	// Ensure Claude directory exists
 	if err := os.MkdirAll(m.ClaudeDir, 0755); err != nil {
 		return fmt.Errorf("failed to create Claude directory: %w", err)
 	}
 
-	// Backup existing files if switching profiles
-	if currentProfile != name {
-		if err := m.backupFile("CLAUDE.md"); err != nil {
-			return fmt.Errorf("failed to backup CLAUDE.md: %w", err)
-		}
-		if err := m.backupFile("settings.json"); err != nil {
-			return fmt.Errorf("failed to backup settings.json: %w", err)
+	// Refuse to clobber hand edits to deployed files
+	modified, err := m.modifiedFiles()
+	if err != nil {
+		return fmt.Errorf("failed to check deployed files: %w", err)
+	}
+	if len(modified) > 0 && !opts.Force {
+		return &DriftError{Files: modified}
+	}
+
+	// Snapshot the configuration if switching profiles or overwriting edits
+	if currentProfile != name || len(modified) > 0 || modeChanged {
+		if _, err := m.snapshot("before activating " + name); err != nil {
+			return fmt.Errorf("failed to back up configuration: %w", err)
 		}
 	}
 
-	// Merge base + profile CLAUDE.md
-	if err := m.mergeCLAUDEmd(name); err != nil {
-		return fmt.Errorf("failed to merge CLAUDE.md: %w", err)
+	// Stage every output, then swap them all into place at once so a failure
+	// leaves the previous configuration untouched
+	tx, err := m.begin()
+	if err != nil {
+		return err
 	}
 
-	// Apply settings.json
-	if err := m.applySettings(name); err != nil {
-		return fmt.Errorf("failed to apply settings: %w", err)
+	if err := m.stageActivation(tx, name, opts); err != nil {
+		tx.Abort()
+		return err
 	}
 
-	// Mark as active
-	stateFile := filepath.Join(m.ClaudeDir, ".current-profile")
-	if err := os.WriteFile(stateFile, []byte(name), 0644); err != nil {
-		return fmt.Errorf("failed to write state file: %w", err)
+	// .git/info/exclude doesn't hide changes to committed project files
+	if m.IsProject() && !opts.Force {
+		if err := m.checkTracked(tx); err != nil {
+			tx.Abort()
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to activate profile (previous configuration restored): %w", err)
+	}
+
+	// Keep generated project files out of git status
+	if m.IsProject() {
+		if err := m.excludeDeployed(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: could not update .git/info/exclude: %v\n", err)
+		}
 	}
 
+	m.logHistory(HistoryEntry{Action: HistoryActivate, From: currentProfile, To: name})
 	return nil
 }
 
-// backupFile creates a timestamped backup of a file in the Claude directory.
-// Keeps only the 5 most recent backups.
-func (m *Manager) backupFile(filename string) error {
-	sourcePath := filepath.Join(m.ClaudeDir, filename)
+// stageActivation stages everything activating a profile writes.
+func (m *Manager) stageActivation(tx *transaction, name string, opts ActivateOptions) error {
+	prev, err := m.LoadState()
+	if err != nil {
+		return err
+	}
+	mode := opts.CLAUDEmd
+	if mode == "" {
+		mode = prev.CLAUDEmdMode()
+	}
 
-	// Skip if file doesn't exist
-	if _, err := os.Stat(sourcePath); os.IsNotExist(err) {
-		return nil
+	// Merge base + profile CLAUDE.md
+	if err := m.mergeCLAUDEmd(tx, name, mode, prev); err != nil {
+		return fmt.Errorf("failed to merge CLAUDE.md: %w", err)
 	}
 
-	// Create backup with timestamp
-	timestamp := time.Now().Format("20060102-150405")
-	backupPath := filepath.Join(m.ClaudeDir, fmt.Sprintf("%s.backup.%s", filename, timestamp))
+	// Apply settings.json
+	if err := m.applySettings(tx, name); err != nil {
+		return fmt.Errorf("failed to apply settings: %w", err)
+	}
 
-	// Copy file
-	data, err := os.ReadFile(sourcePath)
-	if err != nil {
-		return fmt.Errorf("failed to read source file: %w", err)
+	// Deploy agents
+	if err := m.deployAgents(tx, name); err != nil {
+		return fmt.Errorf("failed to deploy agents: %w", err)
 	}
 
-	if err := os.WriteFile(backupPath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write backup: %w", err)
+	// Deploy hooks; they run from the global directory only
+	if !m.IsProject() {
+		if err := m.deployHooks(tx, name); err != nil {
+			return fmt.Errorf("failed to deploy hooks: %w", err)
+		}
 	}
 
-	// Cleanup old backups (keep only 5 most recent)
-	if err := m.cleanupBackups(filename, 5); err != nil {
-		// Log but don't fail on cleanup errors
-		fmt.Fprintf(os.Stderr, "warning: failed to cleanup old backups: %v\n", err)
+	// Mark as active, recording what was deployed for drift detection
+	if err := m.stageState(tx, name, prev, mode); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
 	}
 
 	return nil
 }
 
-// cleanupBackups removes old backup files, keeping only the N most recent.
-func (m *Manager) cleanupBackups(filename string, keep int) error {
-	pattern := filepath.Join(m.ClaudeDir, fmt.Sprintf("%s.backup.*", filename))
-	matches, err := filepath.Glob(pattern)
+// mergeCLAUDEmd merges base/CLAUDE.md + the CLAUDE.md of every profile in the
+// inheritance chain into Claude directory. In managed mode only the managed
+// block is replaced.
+func (m *Manager) mergeCLAUDEmd(tx *transaction, profileName string, mode CLAUDEmdMode, prev *State) error {
+	outputPath := filepath.Join(m.ClaudeDir, "CLAUDE.md")
+
+	merged, err := m.MergedCLAUDEmd(profileName)
 	if err != nil {
 		return err
 	}
 
-	// Sort by modification time (newest first)
-	sort.Slice(matches, func(i, j int) bool {
-		infoI, errI := os.Stat(matches[i])
-		infoJ, errJ := os.Stat(matches[j])
-		if errI != nil || errJ != nil {
-			return false
-		}
-		return infoI.ModTime().After(infoJ.ModTime())
-	})
-
-	// Remove old backups beyond the keep limit
-	for i := keep; i < len(matches); i++ {
-		if err := os.Remove(matches[i]); err != nil {
+	if mode == CLAUDEmdManaged {
+		content, err := m.managedCLAUDEmd(outputPath, profileName, merged, prev)
+		if err != nil {
 			return err
 		}
+		if err := tx.WriteFile(outputPath, content, 0644); err != nil {
+			return fmt.Errorf("failed to write merged CLAUDE.md: %w", err)
+		}
+		// Edits outside the block aren't drift
+		tx.RecordHash(outputPath, managedBlockHash(content))
+		return nil
+	}
+
+	// Write merged content
+	if err := tx.WriteFile(outputPath, merged, 0644); err != nil {
+		return fmt.Errorf("failed to write merged CLAUDE.md: %w", err)
 	}
 
 	return nil
 }
 
-// mergeCLAUDEmd merges base/CLAUDE.md + profile/CLAUDE.md into Claude directory.
-func (m *Manager) mergeCLAUDEmd(profileName string) error {
-	basePath := filepath.Join(m.RepoDir, "base", "CLAUDE.md")
-	profilePath := filepath.Join(m.ProfilesDir, profileName, "CLAUDE.md")
-	outputPath := filepath.Join(m.ClaudeDir, "CLAUDE.md")
-
-	// Read base CLAUDE.md
-	baseContent, err := os.ReadFile(basePath)
+// MergedCLAUDEmd returns the CLAUDE.md content that activating the profile
+// would deploy: base/CLAUDE.md followed by each profile in the inheritance
+// chain, root ancestor first, separated by profile headers. For a stack each
+// profile's chain follows the previous one's, and host overlays follow the
+// layer they belong to. CLAUDE.md.tmpl files are rendered as templates (see
+// TemplateData) and include directives are expanded before merging.
+func (m *Manager) MergedCLAUDEmd(profileName string) ([]byte, error) {
+	layers, err := m.layers(profileName)
 	if err != nil {
-		return fmt.Errorf("failed to read base CLAUDE.md: %w", err)
+		return nil, err
 	}
 
-	// Read profile CLAUDE.md
-	profileContent, err := os.ReadFile(profilePath)
-	if err != nil {
-		return fmt.Errorf("failed to read profile CLAUDE.md: %w", err)
+	var data *TemplateData
+	templateData := func() *TemplateData {
+		if data == nil {
+			data = m.templateData(profileName, layers)
+		}
+		return data
 	}
+	includes := &includeExpander{m: m, data: templateData}
 
-	// Merge with separator
-	separator := fmt.Sprintf("\n\n# =========================================\n# Profile: %s\n# =========================================\n\n", profileName)
-	merged := string(baseContent) + separator + string(profileContent)
+	var merged strings.Builder
+	for _, l := range layers {
+		path, err := claudeMDSource(l)
+		if err != nil {
+			return nil, err
+		}
 
-	// Write merged content
-	if err := os.WriteFile(outputPath, []byte(merged), 0644); err != nil {
-		return fmt.Errorf("failed to write merged CLAUDE.md: %w", err)
+		content, err := os.ReadFile(path)
+		if os.IsNotExist(err) && l.optionalCLAUDEmd() {
+			continue // Contributes only settings, agents or hooks
+		}
+		if err != nil {
+			if l.Name == "base" {
+				return nil, fmt.Errorf("failed to read base CLAUDE.md: %w", err)
+			}
+			return nil, fmt.Errorf("failed to read profile CLAUDE.md: %w", err)
+		}
+
+		if strings.HasSuffix(path, TemplateSuffix) {
+			content, err = renderTemplate(m.relPath(path), content, templateData())
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		content, err = includes.expand(m.relPath(path), content, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if l.Host != "" {
+			fmt.Fprintf(&merged, "\n\n# =========================================\n# Host overlay: %s\n# =========================================\n\n", l.Name)
+		} else if l.Name != "base" {
+			// Merge with separator
+			header := l.Name
+			if l.Name != l.Via {
+				header = fmt.Sprintf("%s (extended by %s)", l.Name, l.Via)
+			}
+			fmt.Fprintf(&merged, "\n\n# =========================================\n# Profile: %s\n# =========================================\n\n", header)
+		}
+		merged.Write(content)
 	}
 
-	return nil
+	return []byte(merged.String()), nil
 }
 
-// applySettings copies settings.json from profile (or base if profile doesn't have one).
-func (m *Manager) applySettings(profileName string) error {
-	profileSettingsPath := filepath.Join(m.ProfilesDir, profileName, "settings.json")
-	baseSettingsPath := filepath.Join(m.RepoDir, "base", "settings.json")
+// applySettings deep-merges base and profile settings.json into Claude
+// directory, resolving secret references. Settings holding secrets are only
+// readable by the user. A plan gets the values holding references masked
+// instead, resolving nothing.
+func (m *Manager) applySettings(tx *transaction, profileName string) error {
 	outputPath := filepath.Join(m.ClaudeDir, "settings.json")
 
-	// Try profile settings first
-	sourcePath := profileSettingsPath
-	if _, err := os.Stat(sourcePath); os.IsNotExist(err) {
-		// Fall back to base settings
-		sourcePath = baseSettingsPath
+	data, err := m.MergedSettings(profileName)
+	if err != nil {
+		return err
 	}
 
-	// Read settings
-	data, err := os.ReadFile(sourcePath)
+	settings, err := decodeSettings(data)
 	if err != nil {
-		return fmt.Errorf("failed to read settings: %w", err)
+		return err
+	}
+	resolver := newSecretResolver(m)
+	resolver.mask = tx.planned != nil
+	if settings, err = resolver.resolveSettings(settings, nil); err != nil {
+		return err
+	}
+	if data, err = encodeSettings(settings); err != nil {
+		return err
+	}
+
+	mode := os.FileMode(0644)
+	if len(resolver.paths) > 0 {
+		mode = 0600
+		tx.secretPaths = append(tx.secretPaths, resolver.paths...)
 	}
 
 	// Write settings
-	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+	if err := tx.WriteFile(outputPath, data, mode); err != nil {
 		return fmt.Errorf("failed to write settings: %w", err)
 	}
 
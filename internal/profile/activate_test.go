@@ -97,112 +97,13 @@ func TestActivate(t *testing.T) {
 		}
 
 		// Verify backup was created
-		backups, _ := filepath.Glob(filepath.Join(claudeDir, "CLAUDE.md.backup.*"))
-		if len(backups) == 0 {
-			t.Error("Backup should have been created when switching profiles")
+		backups, err := mgr.ListBackups()
+		if err != nil || len(backups) == 0 || backups[0].Reason != "before activating profile-2" {
+			t.Errorf("Backup should have been created when switching profiles: %v", err)
 		}
 	})
 }
 
-func TestBackupFile(t *testing.T) {
-	tmpDir, err := os.MkdirTemp("", "dotclaude-test-*")
-	if err != nil {
-		t.Fatal(err)
-	}
-	defer os.RemoveAll(tmpDir)
-
-	claudeDir := filepath.Join(tmpDir, ".claude")
-	if err := os.MkdirAll(claudeDir, 0755); err != nil {
-		t.Fatal(err)
-	}
-
-	mgr := NewManager(tmpDir, claudeDir)
-
-	t.Run("backup non-existent file", func(t *testing.T) {
-		// Should not error for non-existent file
-		err := mgr.backupFile("non-existent.txt")
-		if err != nil {
-			t.Errorf("backupFile() should not error for non-existent file: %v", err)
-		}
-	})
-
-	t.Run("backup existing file", func(t *testing.T) {
-		// Create file
-		filePath := filepath.Join(claudeDir, "CLAUDE.md")
-		if err := os.WriteFile(filePath, []byte("test content"), 0644); err != nil {
-			t.Fatal(err)
-		}
-
-		err := mgr.backupFile("CLAUDE.md")
-		if err != nil {
-			t.Fatalf("backupFile() error = %v", err)
-		}
-
-		// Verify backup was created
-		backups, err := filepath.Glob(filepath.Join(claudeDir, "CLAUDE.md.backup.*"))
-		if err != nil {
-			t.Fatal(err)
-		}
-
-		if len(backups) == 0 {
-			t.Error("Backup file should have been created")
-		}
-
-		// Verify backup content
-		backupContent, err := os.ReadFile(backups[0])
-		if err != nil {
-			t.Fatal(err)
-		}
-
-		if string(backupContent) != "test content" {
-			t.Errorf("Backup content = %q, want %q", string(backupContent), "test content")
-		}
-	})
-}
-
-func TestCleanupBackups(t *testing.T) {
-	tmpDir, err := os.MkdirTemp("", "dotclaude-test-*")
-	if err != nil {
-		t.Fatal(err)
-	}
-	defer os.RemoveAll(tmpDir)
-
-	claudeDir := filepath.Join(tmpDir, ".claude")
-	if err := os.MkdirAll(claudeDir, 0755); err != nil {
-		t.Fatal(err)
-	}
-
-	mgr := NewManager(tmpDir, claudeDir)
-
-	// Create multiple backup files
-	for i := 0; i < 7; i++ {
-		timestamp := time.Now().Add(time.Duration(i) * time.Second).Format("20060102-150405")
-		backupPath := filepath.Join(claudeDir, "CLAUDE.md.backup."+timestamp)
-		if err := os.WriteFile(backupPath, []byte("backup"), 0644); err != nil {
-			t.Fatal(err)
-		}
-		time.Sleep(10 * time.Millisecond) // Ensure different mod times
-	}
-
-	// Verify we have 7 backups
-	backups, _ := filepath.Glob(filepath.Join(claudeDir, "CLAUDE.md.backup.*"))
-	if len(backups) != 7 {
-		t.Fatalf("Should have 7 backups before cleanup, got %d", len(backups))
-	}
-
-	// Cleanup, keeping only 3
-	err = mgr.cleanupBackups("CLAUDE.md", 3)
-	if err != nil {
-		t.Fatalf("cleanupBackups() error = %v", err)
-	}
-
-	// Verify only 3 remain
-	backups, _ = filepath.Glob(filepath.Join(claudeDir, "CLAUDE.md.backup.*"))
-	if len(backups) != 3 {
-		t.Errorf("Should have 3 backups after cleanup, got %d", len(backups))
-	}
-}
-
 func TestMergeCLAUDEmd(t *testing.T) {
 	tmpDir, cleanup := setupTestRepo(t)
 	defer cleanup()
@@ -221,7 +122,7 @@ func TestMergeCLAUDEmd(t *testing.T) {
 	}
 
 	t.Run("merge CLAUDE.md", func(t *testing.T) {
-		err := mgr.mergeCLAUDEmd("merge-test")
+		err := commitStaged(mgr, func(tx *transaction) error { return mgr.mergeCLAUDEmd(tx, "merge-test", CLAUDEmdReplace, nil) })
 		if err != nil {
 			t.Fatalf("mergeCLAUDEmd() error = %v", err)
 		}
@@ -249,7 +150,7 @@ func TestMergeCLAUDEmd(t *testing.T) {
 	})
 
 	t.Run("merge non-existent profile", func(t *testing.T) {
-		err := mgr.mergeCLAUDEmd("non-existent")
+		err := commitStaged(mgr, func(tx *transaction) error { return mgr.mergeCLAUDEmd(tx, "non-existent", CLAUDEmdReplace, nil) })
 		if err == nil {
 			t.Error("mergeCLAUDEmd() should error for non-existent profile")
 		}
@@ -280,7 +181,7 @@ func TestApplySettings(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		err := mgr.applySettings("settings-test")
+		err := commitStaged(mgr, func(tx *transaction) error { return mgr.applySettings(tx, "settings-test") })
 		if err != nil {
 			t.Fatalf("applySettings() error = %v", err)
 		}
@@ -291,8 +192,12 @@ func TestApplySettings(t *testing.T) {
 			t.Fatalf("Failed to read settings: %v", err)
 		}
 
-		if string(content) != profileSettings {
-			t.Errorf("Settings = %q, want %q", string(content), profileSettings)
+		// Profile keys are merged on top of base keys
+		if !strings.Contains(string(content), `"profile": "custom"`) {
+			t.Errorf("Settings should contain profile content, got %q", string(content))
+		}
+		if !strings.Contains(string(content), `"key": "value"`) {
+			t.Errorf("Settings should keep base content, got %q", string(content))
 		}
 	})
 
@@ -306,7 +211,7 @@ func TestApplySettings(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		err := mgr.applySettings("no-settings")
+		err := commitStaged(mgr, func(tx *transaction) error { return mgr.applySettings(tx, "no-settings") })
 		if err != nil {
 			t.Fatalf("applySettings() error = %v", err)
 		}
@@ -409,79 +314,6 @@ func TestActivate_ErrorPaths(t *testing.T) {
 	})
 }
 
-func TestCleanupBackups_EdgeCases(t *testing.T) {
-	tmpDir, err := os.MkdirTemp("", "dotclaude-test-*")
-	if err != nil {
-		t.Fatal(err)
-	}
-	defer os.RemoveAll(tmpDir)
-
-	claudeDir := filepath.Join(tmpDir, ".claude")
-	if err := os.MkdirAll(claudeDir, 0755); err != nil {
-		t.Fatal(err)
-	}
-
-	mgr := NewManager(tmpDir, claudeDir)
-
-	t.Run("cleanup with no backups", func(t *testing.T) {
-		err := mgr.cleanupBackups("CLAUDE.md", 5)
-		if err != nil {
-			t.Errorf("cleanupBackups() should not error for no backups: %v", err)
-		}
-	})
-
-	t.Run("cleanup with fewer than limit", func(t *testing.T) {
-		// Create 2 backups
-		for i := 0; i < 2; i++ {
-			timestamp := time.Now().Add(time.Duration(i) * time.Second).Format("20060102-150405")
-			backupPath := filepath.Join(claudeDir, "test.backup."+timestamp)
-			if err := os.WriteFile(backupPath, []byte("backup"), 0644); err != nil {
-				t.Fatal(err)
-			}
-		}
-
-		err := mgr.cleanupBackups("test", 5)
-		if err != nil {
-			t.Errorf("cleanupBackups() error = %v", err)
-		}
-
-		// Should still have 2 backups
-		backups, _ := filepath.Glob(filepath.Join(claudeDir, "test.backup.*"))
-		if len(backups) != 2 {
-			t.Errorf("Should have 2 backups, got %d", len(backups))
-		}
-	})
-
-	t.Run("cleanup with exactly limit", func(t *testing.T) {
-		// Clear old backups
-		oldBackups, _ := filepath.Glob(filepath.Join(claudeDir, "exact.backup.*"))
-		for _, b := range oldBackups {
-			os.Remove(b)
-		}
-
-		// Create exactly 3 backups
-		for i := 0; i < 3; i++ {
-			timestamp := time.Now().Add(time.Duration(i) * time.Second).Format("20060102-150405")
-			backupPath := filepath.Join(claudeDir, "exact.backup."+timestamp)
-			if err := os.WriteFile(backupPath, []byte("backup"), 0644); err != nil {
-				t.Fatal(err)
-			}
-			time.Sleep(10 * time.Millisecond)
-		}
-
-		err := mgr.cleanupBackups("exact", 3)
-		if err != nil {
-			t.Errorf("cleanupBackups() error = %v", err)
-		}
-
-		// Should still have 3 backups
-		backups, _ := filepath.Glob(filepath.Join(claudeDir, "exact.backup.*"))
-		if len(backups) != 3 {
-			t.Errorf("Should have 3 backups, got %d", len(backups))
-		}
-	})
-}
-
 func TestMergeCLAUDEmd_ErrorPaths(t *testing.T) {
 	tmpDir, cleanup := setupTestRepo(t)
 	defer cleanup()
@@ -502,7 +334,7 @@ func TestMergeCLAUDEmd_ErrorPaths(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		err := mgr.mergeCLAUDEmd("test-merge")
+		err := commitStaged(mgr, func(tx *transaction) error { return mgr.mergeCLAUDEmd(tx, "test-merge", CLAUDEmdReplace, nil) })
 		if err == nil {
 			t.Error("mergeCLAUDEmd() should error when base CLAUDE.md is missing")
 		}
@@ -534,7 +366,7 @@ func TestApplySettings_ErrorPaths(t *testing.T) {
 		}
 
 		mgr := NewManager(tmpDir, claudeDir)
-		err = mgr.applySettings("no-settings-anywhere")
+		err = commitStaged(mgr, func(tx *transaction) error { return mgr.applySettings(tx, "no-settings-anywhere") })
 		if err == nil {
 			t.Error("applySettings() should error when no settings exist")
 		}
@@ -0,0 +1,247 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// managedManifestFile lists the files dotclaude deployed into a directory
+// (agents/, hooks/) and the layer each came from, so they can be cleaned up
+// on the next activation without touching files the user added by hand.
+const managedManifestFile = ".dotclaude-managed.json"
+
+// Agent is a subagent deployed to <ClaudeDir>/agents.
+type Agent struct {
+	Name    string
+	Layer   string // "base" or the name of the profile that provided it
+	Source  string // Path of the agent definition in the repository
+	Content []byte // Rendered Claude Code agent file (Markdown with frontmatter)
+}
+
+// agentDefinition is the JSON agent format used in base/agents/<name>/definition.json.
+type agentDefinition struct {
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	Instructions string   `json:"instructions"`
+	Tools        []string `json:"tools"`
+	Model        string   `json:"model"`
+}
+
+// ListAgents returns the agents activating the profile would deploy, sorted by name.
+// Agents come from base/agents and the agents directory of each profile in the
+// inheritance chain; an agent in a later layer replaces one with the same name.
+// A profile whose manifest lists agents contributes only those.
+func (m *Manager) ListAgents(profileName string) ([]*Agent, error) {
+	byName := make(map[string]*Agent)
+
+	layers, err := m.layers(profileName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, l := range layers {
+		agentsDir := filepath.Join(l.Dir, "agents")
+		entries, err := os.ReadDir(agentsDir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read agents directory: %w", err)
+		}
+
+		found := make(map[string]bool)
+		for _, entry := range entries {
+			agent, err := loadAgent(agentsDir, entry)
+			if err != nil {
+				return nil, err
+			}
+			if agent == nil {
+				continue
+			}
+			found[agent.Name] = true
+			if l.Manifest != nil && !l.Manifest.contributesAgent(agent.Name) {
+				continue
+			}
+			agent.Layer = l.Name
+			byName[agent.Name] = agent
+		}
+
+		if l.Manifest != nil {
+			for _, name := range l.Manifest.Agents {
+				if !found[name] {
+					return nil, fmt.Errorf("profile '%s' lists agent '%s', which is not in %s", l.Name, name, m.relPath(agentsDir))
+				}
+			}
+		}
+	}
+
+	agents := make([]*Agent, 0, len(byName))
+	for _, agent := range byName {
+		agents = append(agents, agent)
+	}
+	sort.Slice(agents, func(i, j int) bool {
+		return agents[i].Name < agents[j].Name
+	})
+
+	return agents, nil
+}
+
+// loadAgent reads a single agent from an agents directory entry.
+// Supported layouts are <name>/definition.json and <name>.md; anything else is ignored.
+func loadAgent(agentsDir string, entry os.DirEntry) (*Agent, error) {
+	path := filepath.Join(agentsDir, entry.Name())
+
+	if !entry.IsDir() {
+		if filepath.Ext(entry.Name()) != ".md" {
+			return nil, nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read agent %s: %w", path, err)
+		}
+		return &Agent{
+			Name:    strings.TrimSuffix(entry.Name(), ".md"),
+			Source:  path,
+			Content: content,
+		}, nil
+	}
+
+	definitionPath := filepath.Join(path, "definition.json")
+	data, err := os.ReadFile(definitionPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read agent %s: %w", definitionPath, err)
+	}
+
+	var def agentDefinition
+	if err := json.Unmarshal(data, &def); err != nil {
+		return nil, fmt.Errorf("invalid agent definition %s: %w", definitionPath, err)
+	}
+	if def.Name == "" {
+		def.Name = entry.Name()
+	}
+	if err := ValidateProfileName(def.Name); err != nil {
+		return nil, fmt.Errorf("invalid agent name in %s: %w", definitionPath, err)
+	}
+
+	return &Agent{
+		Name:    def.Name,
+		Source:  definitionPath,
+		Content: renderAgent(def),
+	}, nil
+}
+
+// renderAgent converts a JSON agent definition into Claude Code's agent file
+// format: YAML frontmatter followed by the system prompt.
+func renderAgent(def agentDefinition) []byte {
+	var b strings.Builder
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "name: %s\n", yamlScalar(def.Name))
+	fmt.Fprintf(&b, "description: %s\n", yamlScalar(def.Description))
+	if len(def.Tools) > 0 {
+		fmt.Fprintf(&b, "tools: %s\n", yamlScalar(strings.Join(def.Tools, ", ")))
+	}
+	if def.Model != "" {
+		fmt.Fprintf(&b, "model: %s\n", yamlScalar(def.Model))
+	}
+	b.WriteString("---\n\n")
+	b.WriteString(def.Instructions)
+	if !strings.HasSuffix(def.Instructions, "\n") {
+		b.WriteString("\n")
+	}
+	return []byte(b.String())
+}
+
+// yamlScalar returns s as a YAML scalar, double-quoting it when a plain
+// scalar would be misread.
+func yamlScalar(s string) string {
+	if s == "" || s != strings.TrimSpace(s) ||
+		strings.ContainsAny(s, ":#'\"\n[]{}&*!|>%@`") {
+		quoted, _ := json.Marshal(s)
+		return string(quoted)
+	}
+	return s
+}
+
+// deployAgents writes the profile's agents to <ClaudeDir>/agents and removes
+// agents a previous activation deployed that are no longer provided.
+func (m *Manager) deployAgents(tx *transaction, profileName string) error {
+	agents, err := m.ListAgents(profileName)
+	if err != nil {
+		return err
+	}
+
+	agentsDir := filepath.Join(m.ClaudeDir, "agents")
+	if err := removeManaged(tx, agentsDir); err != nil {
+		return fmt.Errorf("failed to remove previous agents: %w", err)
+	}
+
+	if len(agents) == 0 {
+		return nil
+	}
+
+	managed := make(map[string]string, len(agents))
+	for _, agent := range agents {
+		filename := agent.Name + ".md"
+		if err := tx.WriteFile(filepath.Join(agentsDir, filename), agent.Content, 0644); err != nil {
+			return fmt.Errorf("failed to write agent %s: %w", agent.Name, err)
+		}
+		managed[filename] = agent.Layer
+	}
+
+	return writeManaged(tx, agentsDir, managed)
+}
+
+// readManaged returns the files recorded in a directory's managed manifest,
+// mapped to the layer that provided them.
+func readManaged(dir string) (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, managedManifestFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+
+	managed := make(map[string]string)
+	if err := json.Unmarshal(data, &managed); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", managedManifestFile, err)
+	}
+	return managed, nil
+}
+
+// writeManaged records the files dotclaude deployed into dir.
+func writeManaged(tx *transaction, dir string, managed map[string]string) error {
+	data, err := json.MarshalIndent(managed, "", "  ")
+	if err != nil {
+		return err
+	}
+	return tx.WriteFile(filepath.Join(dir, managedManifestFile), append(data, '\n'), 0644)
+}
+
+// removeManaged stages the removal of every file listed in dir's managed
+// manifest, and of the manifest itself.
+func removeManaged(tx *transaction, dir string) error {
+	managed, err := readManaged(dir)
+	if err != nil {
+		return err
+	}
+
+	for rel := range managed {
+		// Never follow manifest entries outside the managed directory
+		clean := filepath.Clean(filepath.FromSlash(rel))
+		if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			continue
+		}
+		tx.Remove(filepath.Join(dir, clean))
+	}
+
+	tx.Remove(filepath.Join(dir, managedManifestFile))
+	return nil
+}
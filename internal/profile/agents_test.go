@@ -0,0 +1,186 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeAgentDefinition creates <dir>/agents/<name>/definition.json
+func writeAgentDefinition(t *testing.T, dir, name, description string) {
+	t.Helper()
+
+	agentDir := filepath.Join(dir, "agents", name)
+	if err := os.MkdirAll(agentDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	definition := `{"name": "` + name + `", "description": "` + description + `", "instructions": "# ` + name + `\n\nDo the thing.", "tools": ["Read", "Grep"]}`
+	if err := os.WriteFile(filepath.Join(agentDir, "definition.json"), []byte(definition), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListAgents(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+
+	baseDir := filepath.Join(tmpDir, "base")
+	profileDir := filepath.Join(tmpDir, "profiles", "agents-test")
+	if err := os.MkdirAll(profileDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	writeAgentDefinition(t, baseDir, "reviewer", "Base reviewer")
+	writeAgentDefinition(t, baseDir, "planner", "Base planner")
+	writeAgentDefinition(t, profileDir, "reviewer", "Profile reviewer")
+
+	// Markdown agents are deployed as-is
+	if err := os.WriteFile(filepath.Join(profileDir, "agents", "writer.md"), []byte("---\nname: writer\n---\nWrite.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	agents, err := mgr.ListAgents("agents-test")
+	if err != nil {
+		t.Fatalf("ListAgents() error = %v", err)
+	}
+
+	if len(agents) != 3 {
+		t.Fatalf("ListAgents() returned %d agents, want 3", len(agents))
+	}
+
+	byName := make(map[string]*Agent)
+	for _, a := range agents {
+		byName[a.Name] = a
+	}
+
+	t.Run("profile overrides base on collision", func(t *testing.T) {
+		reviewer := byName["reviewer"]
+		if reviewer == nil {
+			t.Fatal("reviewer agent missing")
+		}
+		if reviewer.Layer != "agents-test" {
+			t.Errorf("reviewer layer = %q, want %q", reviewer.Layer, "agents-test")
+		}
+		if !strings.Contains(string(reviewer.Content), "description: Profile reviewer") {
+			t.Errorf("reviewer should use profile definition, got:\n%s", reviewer.Content)
+		}
+	})
+
+	t.Run("base agent kept", func(t *testing.T) {
+		if planner := byName["planner"]; planner == nil || planner.Layer != "base" {
+			t.Error("planner should come from base")
+		}
+	})
+
+	t.Run("markdown agent copied", func(t *testing.T) {
+		if writer := byName["writer"]; writer == nil || string(writer.Content) != "---\nname: writer\n---\nWrite.\n" {
+			t.Error("writer.md should be deployed verbatim")
+		}
+	})
+}
+
+func TestListAgentsManifestFilter(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+
+	profileDir := writeProfile(t, tmpDir, "picky", "")
+	writeAgentDefinition(t, profileDir, "reviewer", "Reviewer")
+	writeAgentDefinition(t, profileDir, "planner", "Planner")
+	manifestPath := filepath.Join(profileDir, "profile.yaml")
+
+	if err := os.WriteFile(manifestPath, []byte("agents: [reviewer]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	agents, err := mgr.ListAgents("picky")
+	if err != nil {
+		t.Fatalf("ListAgents() error = %v", err)
+	}
+	if len(agents) != 1 || agents[0].Name != "reviewer" {
+		t.Errorf("ListAgents() = %v, want only reviewer", agents)
+	}
+
+	if err := os.WriteFile(manifestPath, []byte("agents: [reviewer, missing]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mgr.ListAgents("picky"); err == nil || !strings.Contains(err.Error(), "'missing'") {
+		t.Errorf("ListAgents() error = %v, want missing agent error", err)
+	}
+}
+
+func TestRenderAgent(t *testing.T) {
+	content := string(renderAgent(agentDefinition{
+		Name:         "gap-analysis",
+		Description:  "Analyzes gaps: features and more",
+		Instructions: "# Gap Analysis",
+		Tools:        []string{"Read", "WebSearch"},
+		Model:        "opus",
+	}))
+
+	expected := []string{
+		"---\nname: gap-analysis\n",
+		`description: "Analyzes gaps: features and more"`,
+		"tools: Read, WebSearch\n",
+		"model: opus\n",
+		"---\n\n# Gap Analysis\n",
+	}
+	for _, want := range expected {
+		if !strings.Contains(content, want) {
+			t.Errorf("rendered agent missing %q:\n%s", want, content)
+		}
+	}
+}
+
+func TestDeployAgents(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	claudeDir := filepath.Join(tmpDir, ".claude")
+	mgr := NewManager(tmpDir, claudeDir)
+	agentsDir := filepath.Join(claudeDir, "agents")
+
+	for _, name := range []string{"first", "second"} {
+		profileDir := filepath.Join(tmpDir, "profiles", name)
+		if err := os.MkdirAll(profileDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(profileDir, "CLAUDE.md"), []byte("# "+name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeAgentDefinition(t, filepath.Join(tmpDir, "profiles", "first"), "first-only", "Only in first")
+
+	// A user-managed agent must survive activations
+	if err := os.MkdirAll(agentsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	userAgent := filepath.Join(agentsDir, "mine.md")
+	if err := os.WriteFile(userAgent, []byte("mine"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("activation deploys agents", func(t *testing.T) {
+		if err := mgr.Activate("first"); err != nil {
+			t.Fatalf("Activate() error = %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(agentsDir, "first-only.md")); err != nil {
+			t.Errorf("first-only.md should be deployed: %v", err)
+		}
+	})
+
+	t.Run("switching removes previous agents", func(t *testing.T) {
+		if err := mgr.Activate("second"); err != nil {
+			t.Fatalf("Activate() error = %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(agentsDir, "first-only.md")); !os.IsNotExist(err) {
+			t.Error("first-only.md should have been removed when switching profiles")
+		}
+		if _, err := os.Stat(userAgent); err != nil {
+			t.Error("user-managed agent should not be removed")
+		}
+	})
+}
@@ -0,0 +1,454 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BackupsDir holds, under ClaudeDir, one snapshot directory per backup.
+// Each snapshot has a manifest.json listing every managed file as it was
+// when the snapshot was taken. File contents live in a shared objects/ store
+// named by their hash, so content that doesn't change between snapshots is
+// stored once.
+const BackupsDir = ".dotclaude-backups"
+
+const (
+	backupManifestFile = "manifest.json"
+	backupObjectsDir   = "objects"
+	// backupIDFormat is the timestamp snapshots are named by; snapshots
+	// taken within the same second get a -2, -3, ... suffix.
+	backupIDFormat = "20060102-150405"
+)
+
+// legacyBackupFiles are the files older versions copied to
+// <name>.backup.<timestamp> next to the live configuration.
+var legacyBackupFiles = []string{"CLAUDE.md", "settings.json"}
+
+// Backup is a snapshot of the configuration dotclaude manages in ClaudeDir.
+type Backup struct {
+	ID string `json:"id"`
+	// Time is when the snapshot was taken.
+	Time time.Time `json:"time"`
+	// Profile is the profile that was active, if any.
+	Profile string `json:"profile,omitempty"`
+	// Reason describes the operation that took the snapshot.
+	Reason string `json:"reason"`
+	// Files lists the saved files, sorted by path.
+	Files []BackupFile `json:"files"`
+	// Partial marks a snapshot imported from legacy backup files, which hold
+	// only CLAUDE.md or settings.json. Restoring it leaves other files alone.
+	Partial bool `json:"partial,omitempty"`
+
+	dir string
+}
+
+// BackupFile is one file in a snapshot.
+type BackupFile struct {
+	// Path is relative to ClaudeDir and slash-separated.
+	Path string      `json:"path"`
+	Size int64       `json:"size"`
+	Mode os.FileMode `json:"mode"`
+	Hash string      `json:"hash"`
+}
+
+// Size returns the total size of the files in the snapshot.
+func (b *Backup) Size() int64 {
+	var size int64
+	for _, f := range b.Files {
+		size += f.Size
+	}
+	return size
+}
+
+// File returns the snapshot's copy of a file, or nil if it has none.
+func (b *Backup) File(rel string) *BackupFile {
+	for i := range b.Files {
+		if b.Files[i].Path == rel {
+			return &b.Files[i]
+		}
+	}
+	return nil
+}
+
+// ReadBackupFile returns the content a snapshot saved for a file.
+func (m *Manager) ReadBackupFile(b *Backup, rel string) ([]byte, error) {
+	if b.File(rel) == nil {
+		return nil, fmt.Errorf("backup %s has no %s", b.ID, rel)
+	}
+	f := b.File(rel)
+	path, err := m.objectPath(f.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("backup %s: %w", b.ID, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+	if hashContent(data) != f.Hash {
+		return nil, fmt.Errorf("backup %s: stored content of %s is corrupt", b.ID, rel)
+	}
+	return data, nil
+}
+
+// backupsPath returns the snapshot directory.
+func (m *Manager) backupsPath() string {
+	return filepath.Join(m.ClaudeDir, BackupsDir)
+}
+
+// objectPath returns where the content with a hash is stored:
+// objects/<first two hex digits>/<the rest>.
+func (m *Manager) objectPath(hash string) (string, error) {
+	digest, ok := strings.CutPrefix(hash, "sha256:")
+	if !ok || len(digest) != 64 || strings.Trim(digest, "0123456789abcdef") != "" {
+		return "", fmt.Errorf("invalid content hash %q", hash)
+	}
+	return filepath.Join(m.backupsPath(), backupObjectsDir, digest[:2], digest[2:]), nil
+}
+
+// writeObject stores content under its hash, unless it is already stored.
+// Objects are private; the mode a file had is kept in the manifest.
+func (m *Manager) writeObject(hash string, data []byte) error {
+	path, err := m.objectPath(hash)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	// Written under a temporary name, so an object is either whole or absent
+	tmp := path + ".tmp"
+	if err := writeFileSync(tmp, data, 0600); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// managedFiles returns the files a snapshot covers that exist now, relative
+// to ClaudeDir and slash-separated: CLAUDE.md and settings.json, everything
+// the last activation deployed (agents, hooks), and dotclaude's own records
+// of the activation, so that restoring a snapshot restores all of it.
+func (m *Manager) managedFiles() ([]string, error) {
+	candidates := map[string]bool{
+		"CLAUDE.md":                     true,
+		"settings.json":                 true,
+		StateFileName:                   true,
+		legacyStateFile:                 true,
+		"agents/" + managedManifestFile: true,
+		"hooks/" + managedManifestFile:  true,
+	}
+
+	state, err := m.LoadState()
+	if err != nil {
+		return nil, err
+	}
+	if state != nil {
+		for rel := range state.Files {
+			candidates[rel] = true
+		}
+	}
+
+	originals := filepath.Join(m.ClaudeDir, OriginalsDir)
+	err = filepath.WalkDir(originals, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == originals {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			rel, err := filepath.Rel(m.ClaudeDir, path)
+			if err != nil {
+				return err
+			}
+			candidates[filepath.ToSlash(rel)] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list saved originals: %w", err)
+	}
+
+	var files []string
+	for rel := range candidates {
+		info, err := os.Lstat(filepath.Join(m.ClaudeDir, filepath.FromSlash(rel)))
+		if err == nil && info.Mode().IsRegular() {
+			files = append(files, rel)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// snapshot saves every managed file in a new backup and prunes old ones.
+// It returns nil if there is nothing to save. The caller holds the lock.
+func (m *Manager) snapshot(reason string) (*Backup, error) {
+	if err := m.importLegacyBackups(); err != nil {
+		return nil, err
+	}
+
+	files, err := m.managedFiles()
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	backup := &Backup{
+		ID:      m.newBackupID(now),
+		Time:    now.UTC().Truncate(time.Second),
+		Profile: m.GetActiveProfileName(),
+		Reason:  reason,
+	}
+	contents := make(map[string][]byte, len(files))
+	for _, rel := range files {
+		path := filepath.Join(m.ClaudeDir, filepath.FromSlash(rel))
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to back up %s: %w", rel, err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to back up %s: %w", rel, err)
+		}
+		contents[rel] = data
+		backup.Files = append(backup.Files, BackupFile{
+			Path: rel,
+			Size: int64(len(data)),
+			Mode: info.Mode().Perm(),
+			Hash: hashContent(data),
+		})
+	}
+
+	if err := m.writeBackup(backup, contents); err != nil {
+		return nil, err
+	}
+
+	// Cleanup old snapshots according to the retention policy
+	retention, err := m.BackupRetention()
+	if err == nil {
+		_, err = m.prune(retention, false)
+	}
+	if err != nil {
+		// Log but don't fail on cleanup errors
+		fmt.Fprintf(os.Stderr, "warning: failed to cleanup old backups: %v\n", err)
+	}
+	return backup, nil
+}
+
+// newBackupID returns an unused snapshot ID for a time.
+func (m *Manager) newBackupID(t time.Time) string {
+	base := t.Format(backupIDFormat)
+	id := base
+	for n := 2; ; n++ {
+		if _, err := os.Lstat(filepath.Join(m.backupsPath(), id)); os.IsNotExist(err) {
+			return id
+		}
+		id = base + "-" + strconv.Itoa(n)
+	}
+}
+
+// writeBackup stores a snapshot's contents and writes its manifest. The
+// backup directory is private, as settings may hold secrets. The snapshot
+// directory is assembled under a temporary name and renamed into place, so
+// a failure never leaves a half-written snapshot, and an existing snapshot
+// is never overwritten.
+func (m *Manager) writeBackup(backup *Backup, contents map[string][]byte) error {
+	if err := os.MkdirAll(m.backupsPath(), 0700); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	for _, f := range backup.Files {
+		if err := m.writeObject(f.Hash, contents[f.Path]); err != nil {
+			return fmt.Errorf("failed to write backup: %w", err)
+		}
+	}
+
+	tmp, err := os.MkdirTemp(m.backupsPath(), ".partial-")
+	if err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	data, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileSync(filepath.Join(tmp, backupManifestFile), append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	dir := filepath.Join(m.backupsPath(), backup.ID)
+	if err := os.Rename(tmp, dir); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	backup.dir = dir
+	return nil
+}
+
+// loadBackup reads a snapshot's manifest.
+func (m *Manager) loadBackup(id string) (*Backup, error) {
+	if id == "" || id == backupObjectsDir || strings.ContainsAny(id, `/\`) || strings.HasPrefix(id, ".") {
+		return nil, fmt.Errorf("invalid backup ID %q", id)
+	}
+	dir := filepath.Join(m.backupsPath(), id)
+	data, err := os.ReadFile(filepath.Join(dir, backupManifestFile))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("backup %s not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup %s: %w", id, err)
+	}
+
+	var backup Backup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, fmt.Errorf("invalid backup manifest %s: %w", filepath.Join(dir, backupManifestFile), err)
+	}
+	backup.ID = id
+	backup.dir = dir
+	return &backup, nil
+}
+
+// loadBackups reads every snapshot, newest first. Unreadable snapshots are
+// skipped.
+func (m *Manager) loadBackups() ([]*Backup, error) {
+	entries, err := os.ReadDir(m.backupsPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	var backups []*Backup
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || entry.Name() == backupObjectsDir {
+			continue
+		}
+		backup, err := m.loadBackup(entry.Name())
+		if err != nil {
+			continue // Skip invalid backups
+		}
+		backups = append(backups, backup)
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].Time.Equal(backups[j].Time) {
+			return backups[i].Time.After(backups[j].Time)
+		}
+		return backupIDLess(backups[j].ID, backups[i].ID)
+	})
+	return backups, nil
+}
+
+// backupIDLess orders snapshot IDs by time, then by the suffix of those
+// taken within the same second.
+func backupIDLess(a, b string) bool {
+	stampA, seqA := splitBackupID(a)
+	stampB, seqB := splitBackupID(b)
+	if stampA != stampB {
+		return stampA < stampB
+	}
+	return seqA < seqB
+}
+
+// splitBackupID splits an ID into its timestamp and sequence number (1 for
+// the first snapshot of a second).
+func splitBackupID(id string) (string, int) {
+	if len(id) > len(backupIDFormat) && id[len(backupIDFormat)] == '-' {
+		if n, err := strconv.Atoi(id[len(backupIDFormat)+1:]); err == nil {
+			return id[:len(backupIDFormat)], n
+		}
+	}
+	return id, 1
+}
+
+// findLegacyBackups returns the legacy backup files in ClaudeDir.
+func (m *Manager) findLegacyBackups() ([]string, error) {
+	var paths []string
+	for _, filename := range legacyBackupFiles {
+		matches, err := filepath.Glob(filepath.Join(m.ClaudeDir, filename+".backup.*"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to find %s backups: %w", filename, err)
+		}
+		paths = append(paths, matches...)
+	}
+	return paths, nil
+}
+
+// importLegacyBackups turns legacy <name>.backup.<timestamp> files into
+// partial snapshots, pairing a CLAUDE.md and a settings.json backup taken in
+// the same second, and removes them. The caller holds the lock.
+func (m *Manager) importLegacyBackups() error {
+	paths, err := m.findLegacyBackups()
+	if err != nil || len(paths) == 0 {
+		return err
+	}
+
+	byStamp := make(map[string][]string)
+	for _, path := range paths {
+		for _, filename := range legacyBackupFiles {
+			if stamp, ok := strings.CutPrefix(filepath.Base(path), filename+".backup."); ok {
+				byStamp[stamp] = append(byStamp[stamp], path)
+			}
+		}
+	}
+	stamps := make([]string, 0, len(byStamp))
+	for stamp := range byStamp {
+		stamps = append(stamps, stamp)
+	}
+	sort.Strings(stamps)
+
+	for _, stamp := range stamps {
+		backup := &Backup{Reason: "imported from legacy backup files", Partial: true}
+		contents := make(map[string][]byte)
+		for _, path := range byStamp[stamp] {
+			info, err := os.Stat(path)
+			if err != nil {
+				return fmt.Errorf("failed to import backup: %w", err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to import backup: %w", err)
+			}
+			rel := strings.TrimSuffix(filepath.Base(path), ".backup."+stamp)
+			contents[rel] = data
+			backup.Files = append(backup.Files, BackupFile{
+				Path: rel,
+				Size: int64(len(data)),
+				Mode: info.Mode().Perm(),
+				Hash: hashContent(data),
+			})
+			if t := info.ModTime(); t.After(backup.Time) {
+				backup.Time = t
+			}
+		}
+		// The timestamp in the name is local time
+		if t, err := time.ParseInLocation(backupIDFormat, stamp, time.Local); err == nil {
+			backup.Time = t
+		}
+		backup.Time = backup.Time.UTC().Truncate(time.Second)
+		sort.Slice(backup.Files, func(i, j int) bool { return backup.Files[i].Path < backup.Files[j].Path })
+		backup.ID = m.newBackupID(backup.Time.Local())
+
+		if err := m.writeBackup(backup, contents); err != nil {
+			return err
+		}
+		for _, path := range byStamp[stamp] {
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("failed to remove imported backup: %w", err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	claudeDir := filepath.Join(tmpDir, ".claude")
+	mgr := NewManager(tmpDir, claudeDir)
+
+	t.Run("nothing to back up", func(t *testing.T) {
+		backup, err := mgr.snapshot("test")
+		if err != nil || backup != nil {
+			t.Errorf("snapshot() = %v, %v, want nothing for an empty directory", backup, err)
+		}
+	})
+
+	workDir := writeProfile(t, tmpDir, "work", "")
+	writeAgentDefinition(t, workDir, "reviewer", "Work reviewer")
+	if err := mgr.Activate("work"); err != nil {
+		t.Fatal(err)
+	}
+	settingsPath := filepath.Join(claudeDir, "settings.json")
+	if err := os.Chmod(settingsPath, 0600); err != nil {
+		t.Fatal(err)
+	}
+	// Not dotclaude's: left out of snapshots
+	if err := os.WriteFile(filepath.Join(claudeDir, "notes.txt"), []byte("mine"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("every managed file", func(t *testing.T) {
+		backup, err := mgr.snapshot("test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var paths []string
+		for _, f := range backup.Files {
+			paths = append(paths, f.Path)
+		}
+		want := []string{StateFileName, "CLAUDE.md", "agents/" + managedManifestFile, "agents/reviewer.md", "settings.json"}
+		if len(paths) != len(want) {
+			t.Fatalf("snapshot files = %v, want %v", paths, want)
+		}
+		for i := range want {
+			if paths[i] != want[i] {
+				t.Errorf("snapshot files = %v, want %v", paths, want)
+				break
+			}
+		}
+		if backup.Profile != "work" || backup.Reason != "test" {
+			t.Errorf("backup = %+v", backup)
+		}
+
+		data, err := mgr.ReadBackupFile(backup, "agents/reviewer.md")
+		if err != nil || string(data) != readString(t, filepath.Join(claudeDir, "agents", "reviewer.md")) {
+			t.Errorf("ReadBackupFile() = %q, %v", data, err)
+		}
+		if _, err := mgr.ReadBackupFile(backup, "notes.txt"); err == nil {
+			t.Error("ReadBackupFile() should fail for a file not in the backup")
+		}
+
+		if runtime.GOOS != "windows" {
+			if f := backup.File("settings.json"); f.Mode != 0600 {
+				t.Errorf("settings.json mode = %o, want 600", f.Mode)
+			}
+			info, err := os.Stat(filepath.Join(claudeDir, BackupsDir))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if perm := info.Mode().Perm(); perm != 0700 {
+				t.Errorf("backup directory mode = %o, want 700", perm)
+			}
+		}
+	})
+
+	t.Run("same second never collides", func(t *testing.T) {
+		seen := make(map[string]bool)
+		for i := 0; i < 3; i++ {
+			backup, err := mgr.snapshot("test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if seen[backup.ID] {
+				t.Fatalf("snapshot ID %s reused", backup.ID)
+			}
+			seen[backup.ID] = true
+		}
+	})
+
+	t.Run("unchanged content stored once", func(t *testing.T) {
+		countObjects := func() int {
+			n := 0
+			filepath.WalkDir(filepath.Join(claudeDir, BackupsDir, backupObjectsDir), func(path string, d os.DirEntry, err error) error {
+				if err == nil && !d.IsDir() {
+					n++
+				}
+				return nil
+			})
+			return n
+		}
+		before := countObjects()
+		if _, err := mgr.snapshot("test"); err != nil {
+			t.Fatal(err)
+		}
+		if got := countObjects(); got != before {
+			t.Errorf("an unchanged snapshot stored %d new object(s)", got-before)
+		}
+
+		if err := os.WriteFile(settingsPath, []byte(`{"changed": true}`), 0600); err != nil {
+			t.Fatal(err)
+		}
+		backup, err := mgr.snapshot("test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := countObjects(); got != before+1 {
+			t.Errorf("changing one file stored %d new object(s), want 1", got-before)
+		}
+		if data, err := mgr.ReadBackupFile(backup, "settings.json"); err != nil || string(data) != `{"changed": true}` {
+			t.Errorf("ReadBackupFile() = %q, %v", data, err)
+		}
+	})
+
+	t.Run("corrupt content detected", func(t *testing.T) {
+		backups, err := mgr.ListBackups()
+		if err != nil {
+			t.Fatal(err)
+		}
+		path, err := mgr.objectPath(backups[0].File("CLAUDE.md").Hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("tampered"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := mgr.ReadBackupFile(backups[0], "CLAUDE.md"); err == nil {
+			t.Error("ReadBackupFile() should fail when the stored content doesn't match its hash")
+		}
+	})
+}
@@ -10,16 +10,39 @@ import (
 
 // Create creates a new profile from the template.
 func (m *Manager) Create(name string) error {
+	return m.CreateWithManifest(name, nil)
+}
+
+// CreateWithManifest creates a new profile from the template and writes the
+// given manifest into it. A nil manifest keeps whatever the template provides.
+func (m *Manager) CreateWithManifest(name string, manifest *Manifest) error {
 	// Validate profile name
 	if err := ValidateProfileName(name); err != nil {
 		return err
 	}
 
+	// Serialize with other dotclaude processes
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Check if profile already exists
 	if m.ProfileExists(name) {
 		return fmt.Errorf("profile '%s' already exists", name)
 	}
 
+	// Check the parent before creating anything
+	if manifest != nil && manifest.Extends != "" {
+		if err := ValidateProfileName(manifest.Extends); err != nil {
+			return err
+		}
+		if !m.ProfileExists(manifest.Extends) {
+			return fmt.Errorf("cannot extend '%s': profile does not exist", manifest.Extends)
+		}
+	}
+
 	// Ensure profiles directory exists
 	if err := os.MkdirAll(m.ProfilesDir, 0755); err != nil {
 		return fmt.Errorf("failed to create profiles directory: %w", err)
@@ -39,8 +62,30 @@ func (m *Manager) Create(name string) error {
 		return fmt.Errorf("failed to copy template: %w", err)
 	}
 
+	if manifest != nil {
+		// Replace the template's manifest, if any, in its own format
+		existing, err := LoadManifest(profileDir)
+		if err != nil {
+			return err
+		}
+		manifest.path = existing.path
+		if err := SaveManifest(profileDir, manifest); err != nil {
+			return err
+		}
+	}
+
+	// Don't commit secrets that came with the template
+	result, err := m.scanPaths([]string{profileDir})
+	if err != nil {
+		return err
+	}
+	commit := len(result.Findings) == 0
+	if !commit {
+		fmt.Fprintf(os.Stderr, "warning: %s contains %d possible secret(s); skipped the initial commit (run: dotclaude scan)\n", m.relPath(profileDir), len(result.Findings))
+	}
+
 	// Initialize git repository in profile
-	if err := initGitRepo(profileDir, name); err != nil {
+	if err := initGitRepo(profileDir, name, commit); err != nil {
 		return fmt.Errorf("failed to initialize git: %w", err)
 	}
 
@@ -122,8 +167,9 @@ func copyFile(src, dst string) error {
 	return nil
 }
 
-// initGitRepo initializes a git repository in the profile directory.
-func initGitRepo(profileDir, profileName string) error {
+// initGitRepo initializes a git repository in the profile directory and,
+// if commit is set, commits its files.
+func initGitRepo(profileDir, profileName string, commit bool) error {
 	// Check if git is available
 	if _, err := exec.LookPath("git"); err != nil {
 		// Git not available, skip initialization (not fatal)
@@ -136,6 +182,9 @@ func initGitRepo(profileDir, profileName string) error {
 	if err := cmd.Run(); err != nil {
 		return err
 	}
+	if !commit {
+		return nil
+	}
 
 	// Add all files
 	cmd = exec.Command("git", "add", ".")
@@ -97,6 +97,35 @@ func TestCreate(t *testing.T) {
 	})
 }
 
+func TestCreateWithManifest(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+	writeProfile(t, tmpDir, "work", "")
+
+	manifest := &Manifest{Description: "Client work", Owner: "me", Tags: []string{"client"}, Extends: "work"}
+	if err := mgr.CreateWithManifest("client", manifest); err != nil {
+		t.Fatalf("CreateWithManifest() error = %v", err)
+	}
+
+	loaded, err := LoadManifest(filepath.Join(tmpDir, "profiles", "client"))
+	if err != nil {
+		t.Fatalf("LoadManifest() error = %v", err)
+	}
+	if loaded.Description != "Client work" || loaded.Extends != "work" || loaded.Owner != "me" {
+		t.Errorf("manifest = %+v", loaded)
+	}
+
+	err = mgr.CreateWithManifest("orphan", &Manifest{Extends: "missing"})
+	if err == nil {
+		t.Error("CreateWithManifest() should error when the parent does not exist")
+	}
+	if mgr.ProfileExists("orphan") {
+		t.Error("CreateWithManifest() should not leave a profile behind on error")
+	}
+}
+
 func TestCopyDir(t *testing.T) {
 	// Create source directory with structure
 	srcDir, err := os.MkdirTemp("", "dotclaude-src-*")
@@ -0,0 +1,158 @@
+package profile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DeactivateOptions controls how a profile is deactivated.
+type DeactivateOptions struct {
+	// KeepFiles leaves the deployed files in place and only drops dotclaude's
+	// record of them, so they become ordinary unmanaged files.
+	KeepFiles bool
+	// Force removes deployed files even if they were edited since activation,
+	// instead of failing with a *DriftError. The configuration is snapshotted
+	// first (see Restore).
+	Force bool
+}
+
+// Deactivate returns ClaudeDir to an unmanaged state: the files the active
+// profile deployed are removed, or replaced by whatever was there before
+// dotclaude first overwrote them, and the activation state is cleared. A
+// managed CLAUDE.md loses its managed block and keeps the rest.
+func (m *Manager) Deactivate(opts DeactivateOptions) error {
+	// Serialize with other dotclaude processes
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	state, err := m.LoadState()
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("no profile is active")
+	}
+
+	if !opts.KeepFiles {
+		// Refuse to throw away hand edits
+		modified, err := m.modifiedFiles()
+		if err != nil {
+			return fmt.Errorf("failed to check deployed files: %w", err)
+		}
+		if len(modified) > 0 && !opts.Force {
+			return &DriftError{Files: modified}
+		}
+
+		if _, err := m.snapshot("before deactivating"); err != nil {
+			return fmt.Errorf("failed to back up configuration: %w", err)
+		}
+	}
+
+	tx, err := m.begin()
+	if err != nil {
+		return err
+	}
+	if err := m.stageDeactivation(tx, state, opts.KeepFiles); err != nil {
+		tx.Abort()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to deactivate profile (configuration left unchanged): %w", err)
+	}
+
+	// Only empty directories are left of the saved originals
+	os.RemoveAll(filepath.Join(m.ClaudeDir, OriginalsDir))
+
+	if m.IsProject() {
+		if err := m.updateGitExclude(nil); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: could not update .git/info/exclude: %v\n", err)
+		}
+	}
+
+	m.logHistory(HistoryEntry{Action: HistoryDeactivate, From: state.Profile})
+	return nil
+}
+
+// stageDeactivation stages the removal of everything the state describes.
+func (m *Manager) stageDeactivation(tx *transaction, state *State, keepFiles bool) error {
+	originals := make(map[string]bool, len(state.Originals))
+	for _, rel := range state.Originals {
+		originals[rel] = true
+	}
+
+	if !keepFiles {
+		// Deployed files go; originals come back, including those of files
+		// a later profile had already removed
+		for _, dir := range []string{"agents", "hooks"} {
+			if err := removeManaged(tx, filepath.Join(m.ClaudeDir, dir)); err != nil {
+				return err
+			}
+		}
+		deployed := state.Files
+		if len(deployed) == 0 {
+			// Migrated state without hashes; these are always deployed
+			deployed = map[string]string{"CLAUDE.md": "", "settings.json": ""}
+		}
+		for rel := range deployed {
+			if rel == "CLAUDE.md" && state.CLAUDEmdMode() == CLAUDEmdManaged {
+				if err := m.stageManagedRemoval(tx); err != nil {
+					return err
+				}
+				continue
+			}
+			if !originals[rel] {
+				tx.Remove(filepath.Join(m.ClaudeDir, filepath.FromSlash(rel)))
+			}
+		}
+		for _, rel := range state.Originals {
+			saved := m.originalPath(rel)
+			info, err := os.Stat(saved)
+			if err != nil {
+				return fmt.Errorf("failed to read original %s: %w", rel, err)
+			}
+			data, err := os.ReadFile(saved)
+			if err != nil {
+				return fmt.Errorf("failed to read original %s: %w", rel, err)
+			}
+			if err := tx.WriteFile(filepath.Join(m.ClaudeDir, filepath.FromSlash(rel)), data, info.Mode().Perm()); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Drop the bookkeeping either way
+	for rel := range originals {
+		tx.Remove(m.originalPath(rel))
+	}
+	for _, dir := range []string{"agents", "hooks"} {
+		tx.Remove(filepath.Join(m.ClaudeDir, dir, managedManifestFile))
+	}
+	tx.Remove(m.StateFile)
+	tx.Remove(filepath.Join(m.ClaudeDir, legacyStateFile))
+	return nil
+}
+
+// stageManagedRemoval stages removing the managed block from CLAUDE.md. The
+// file goes too if nothing else is left in it.
+func (m *Manager) stageManagedRemoval(tx *transaction) error {
+	path := filepath.Join(m.ClaudeDir, "CLAUDE.md")
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read CLAUDE.md: %w", err)
+	}
+
+	user := withoutManagedBlock(data)
+	if strings.TrimSpace(user) == "" {
+		tx.Remove(path)
+		return nil
+	}
+	return tx.WriteFile(path, []byte(user), 0644)
+}
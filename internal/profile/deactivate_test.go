@@ -0,0 +1,145 @@
+package profile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDeactivateRemovesDeployedFiles(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+	writeProfile(t, tmpDir, "work", "")
+	writeAgentDefinition(t, filepath.Join(tmpDir, "profiles", "work"), "reviewer", "Reviews code")
+
+	if err := mgr.Activate("work"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(mgr.ClaudeDir, "agents", "reviewer.md")); err != nil {
+		t.Fatalf("agent should be deployed: %v", err)
+	}
+	if err := mgr.Deactivate(DeactivateOptions{}); err != nil {
+		t.Fatalf("Deactivate() error = %v", err)
+	}
+
+	for _, rel := range []string{"CLAUDE.md", "settings.json", "agents/reviewer.md", "agents/" + managedManifestFile, StateFileName} {
+		if _, err := os.Stat(filepath.Join(mgr.ClaudeDir, rel)); !os.IsNotExist(err) {
+			t.Errorf("%s should be removed", rel)
+		}
+	}
+	if got := mgr.GetActiveProfileName(); got != "" {
+		t.Errorf("active profile = %q, want none", got)
+	}
+
+	entries, err := mgr.History(HistoryFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := entries[len(entries)-1]; last.Action != HistoryDeactivate || last.From != "work" || last.To != "" {
+		t.Errorf("last history entry = %+v, want deactivation of work", last)
+	}
+
+	if err := mgr.Deactivate(DeactivateOptions{}); err == nil {
+		t.Error("Deactivate() with nothing active should fail")
+	}
+}
+
+func TestDeactivateRestoresOriginals(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+	writeProfile(t, tmpDir, "work", "")
+	writeProfile(t, tmpDir, "personal", "")
+
+	// Configuration the user had before trying dotclaude
+	if err := os.MkdirAll(mgr.ClaudeDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	claudeMD := filepath.Join(mgr.ClaudeDir, "CLAUDE.md")
+	if err := os.WriteFile(claudeMD, []byte("# my own notes\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mgr.Activate("work"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.Activate("personal"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, claudeMD); got == "# my own notes\n" {
+		t.Fatal("activation should have replaced CLAUDE.md")
+	}
+
+	if err := mgr.Deactivate(DeactivateOptions{}); err != nil {
+		t.Fatalf("Deactivate() error = %v", err)
+	}
+	if got := readString(t, claudeMD); got != "# my own notes\n" {
+		t.Errorf("CLAUDE.md = %q, want the pre-dotclaude original", got)
+	}
+	if info, err := os.Stat(claudeMD); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("restored CLAUDE.md mode = %v, %v, want 0600", info.Mode(), err)
+	}
+	if _, err := os.Stat(filepath.Join(mgr.ClaudeDir, "settings.json")); !os.IsNotExist(err) {
+		t.Error("settings.json had no original and should be removed")
+	}
+	if _, err := os.Stat(filepath.Join(mgr.ClaudeDir, OriginalsDir)); !os.IsNotExist(err) {
+		t.Error("saved originals should be cleaned up")
+	}
+}
+
+func TestDeactivateKeepFiles(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+	writeProfile(t, tmpDir, "work", "")
+	if err := mgr.Activate("work"); err != nil {
+		t.Fatal(err)
+	}
+	deployed := readString(t, filepath.Join(mgr.ClaudeDir, "CLAUDE.md"))
+
+	if err := mgr.Deactivate(DeactivateOptions{KeepFiles: true}); err != nil {
+		t.Fatalf("Deactivate() error = %v", err)
+	}
+	if got := readString(t, filepath.Join(mgr.ClaudeDir, "CLAUDE.md")); got != deployed {
+		t.Errorf("CLAUDE.md = %q, want it kept", got)
+	}
+	if _, err := os.Stat(mgr.StateFile); !os.IsNotExist(err) {
+		t.Error("state file should be removed")
+	}
+}
+
+func TestDeactivateRefusesDrift(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+	writeProfile(t, tmpDir, "work", "")
+	if err := mgr.Activate("work"); err != nil {
+		t.Fatal(err)
+	}
+	claudeMD := filepath.Join(mgr.ClaudeDir, "CLAUDE.md")
+	if err := os.WriteFile(claudeMD, []byte("edited"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var driftErr *DriftError
+	if err := mgr.Deactivate(DeactivateOptions{}); !errors.As(err, &driftErr) {
+		t.Fatalf("Deactivate() error = %v, want *DriftError", err)
+	}
+	if got := readString(t, claudeMD); got != "edited" {
+		t.Error("refused deactivation should leave files alone")
+	}
+
+	if err := mgr.Deactivate(DeactivateOptions{Force: true}); err != nil {
+		t.Fatalf("Deactivate(Force) error = %v", err)
+	}
+	backups, err := mgr.ListBackups()
+	if err != nil || len(backups) == 0 {
+		t.Errorf("forced deactivation should back up the edited CLAUDE.md")
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 )
 
 // Delete removes a profile.
@@ -18,11 +20,30 @@ func (m *Manager) Delete(name string) error {
 		return fmt.Errorf("profile '%s' does not exist", name)
 	}
 
-	// Check if profile is currently active
+	// Serialize with other dotclaude processes
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Check if profile is currently active, alone or in a stack
 	activeProfile := m.GetActiveProfileName()
 	if activeProfile == name {
 		return fmt.Errorf("cannot delete active profile '%s' (deactivate it first)", name)
 	}
+	if containsString(SplitStack(activeProfile), name) {
+		return fmt.Errorf("cannot delete profile '%s': it is part of the active stack '%s' (deactivate it first)", name, activeProfile)
+	}
+
+	// Profiles that extend it could no longer be activated
+	children, err := m.extendingProfiles(name)
+	if err != nil {
+		return err
+	}
+	if len(children) > 0 {
+		return fmt.Errorf("cannot delete profile '%s': extended by %s (change their extends first)", name, strings.Join(children, ", "))
+	}
 
 	// Delete profile directory
 	profilePath := filepath.Join(m.ProfilesDir, name)
@@ -30,5 +51,38 @@ func (m *Manager) Delete(name string) error {
 		return fmt.Errorf("failed to delete profile: %w", err)
 	}
 
+	m.logHistory(HistoryEntry{Action: HistoryDelete, From: activeProfile, To: activeProfile, Target: name})
 	return nil
 }
+
+// extendingProfiles returns, sorted, the profiles whose inheritance chain
+// includes name. Chains are followed as far as they resolve, so a broken
+// manifest further up doesn't hide a child.
+func (m *Manager) extendingProfiles(name string) ([]string, error) {
+	entries, err := os.ReadDir(m.ProfilesDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profiles directory: %w", err)
+	}
+
+	var children []string
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == name {
+			continue
+		}
+		seen := map[string]bool{entry.Name(): true}
+		for parent := entry.Name(); ; {
+			manifest, err := LoadManifest(filepath.Join(m.ProfilesDir, parent))
+			if err != nil || manifest.Extends == "" || seen[manifest.Extends] {
+				break
+			}
+			parent = manifest.Extends
+			if parent == name {
+				children = append(children, entry.Name())
+				break
+			}
+			seen[parent] = true
+		}
+	}
+	sort.Strings(children)
+	return children, nil
+}
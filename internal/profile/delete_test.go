@@ -3,6 +3,7 @@ package profile
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -69,6 +70,27 @@ func TestDelete(t *testing.T) {
 		}
 	})
 
+	t.Run("delete extended profile", func(t *testing.T) {
+		writeProfile(t, tmpDir, "parent", "")
+		writeProfile(t, tmpDir, "child", "parent")
+		writeProfile(t, tmpDir, "grandchild", "child")
+
+		err := mgr.Delete("parent")
+		if err == nil || !strings.Contains(err.Error(), "extended by child, grandchild") {
+			t.Fatalf("Delete() error = %v, want it to name the profiles extending parent", err)
+		}
+		if _, err := os.Stat(filepath.Join(profilesDir, "parent")); err != nil {
+			t.Error("an extended profile should not have been deleted")
+		}
+
+		// Leaves first
+		for _, name := range []string{"grandchild", "child", "parent"} {
+			if err := mgr.Delete(name); err != nil {
+				t.Errorf("Delete(%s) error = %v", name, err)
+			}
+		}
+	})
+
 	t.Run("delete with invalid name", func(t *testing.T) {
 		err := mgr.Delete("invalid/name")
 		if err == nil {
@@ -0,0 +1,362 @@
+package profile
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DotclaudeFileName is the per-project file naming the profile a project uses.
+const DotclaudeFileName = ".dotclaude"
+
+// DotclaudeFile is a parsed .dotclaude file.
+//
+// The file is YAML:
+//
+//	profile: work                 # Profile the project uses
+//	overlays: [golang, security]  # Profiles layered on top of it
+//	min_version: 1.2.0            # Oldest dotclaude that understands the file
+//	root: true                    # Don't look in parent directories
+//	hooks:                        # Commands run from the file's directory
+//	  session-start: ./scripts/check-env.sh
+//	  post-tool-bash: [make lint]
+//	notes: |                      # Printed into the session context
+//	  Run tests with make test.
+//
+// The one-line shell style (profile=work) is also accepted.
+type DotclaudeFile struct {
+	// Path is the absolute path of the file.
+	Path string `yaml:"-"`
+	// Profile is the profile (or stack, "work+golang") the project uses;
+	// empty if none is named.
+	Profile string `yaml:"profile"`
+	// Overlays are profiles stacked on top of Profile.
+	Overlays []string `yaml:"overlays"`
+	// MinVersion is the oldest dotclaude version the file is written for.
+	MinVersion string `yaml:"min_version"`
+	// Root stops the upward search at this file, so .dotclaude files in
+	// parent directories don't apply. Any setting does that; root: true is
+	// for a file with nothing else to say.
+	Root bool `yaml:"root"`
+	// Hooks maps a hook type ("session-start") to commands run for it, from
+	// the file's directory. They only run once the file is trusted.
+	Hooks map[string]commandList `yaml:"hooks"`
+	// Notes are shown at session start, adding them to Claude's context.
+	Notes string `yaml:"notes"`
+	// Warnings are problems that don't stop the file from being used, such
+	// as keys this version doesn't know (a newer one may).
+	Warnings []*DotclaudeError `yaml:"-"`
+
+	// empty is set when the file has no settings, only comments
+	empty bool
+}
+
+// Dir returns the directory containing the file; project hooks run there.
+func (df *DotclaudeFile) Dir() string {
+	return filepath.Dir(df.Path)
+}
+
+// Stack returns the profile or stack the project uses: Profile with the
+// Overlays stacked on top.
+func (df *DotclaudeFile) Stack() string {
+	if df.Profile == "" {
+		return ""
+	}
+	return JoinStack(append(SplitStack(df.Profile), df.Overlays...))
+}
+
+// HookTypes returns the hook types the file defines commands for, sorted.
+func (df *DotclaudeFile) HookTypes() []string {
+	types := make([]string, 0, len(df.Hooks))
+	for hookType := range df.Hooks {
+		types = append(types, hookType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// commandList is a list of commands that may also be written as one string.
+type commandList []string
+
+// UnmarshalYAML accepts a single command or a sequence of them.
+func (c *commandList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*c = commandList{node.Value}
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*c = list
+	return nil
+}
+
+// DotclaudeError is a problem in a .dotclaude file.
+type DotclaudeError struct {
+	Path string
+	Line int // 0 if the problem isn't tied to a line
+	Msg  string
+}
+
+func (e *DotclaudeError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.Path, e.Line, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Msg)
+}
+
+var (
+	// shellLine matches the shell-style format, key=value.
+	shellLine = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*\s*=`)
+	// hookTypePattern matches hook type names such as session-start.
+	hookTypePattern = regexp.MustCompile(`^[a-z]+(-[a-z]+)*$`)
+	// yamlLine extracts the line number from a yaml.v3 error message.
+	yamlLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+)
+
+// ReadDotclaudeFile reads and validates a .dotclaude file. Problems are
+// reported as a *DotclaudeError with the offending line.
+func ReadDotclaudeFile(path string) (*DotclaudeFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDotclaude(abs, data)
+}
+
+// ParseDotclaude parses the content of the .dotclaude file at path.
+func ParseDotclaude(path string, data []byte) (*DotclaudeFile, error) {
+	df := &DotclaudeFile{Path: path}
+	var lines map[string]int
+	var err error
+	if isShellStyle(data) {
+		lines, err = parseShellStyle(df, data)
+	} else {
+		lines, err = parseYAMLStyle(df, data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := df.validate(lines); err != nil {
+		return nil, err
+	}
+	df.empty = len(lines) == 0
+	return df, nil
+}
+
+// isShellStyle reports whether every setting in data is written key=value.
+func isShellStyle(data []byte) bool {
+	found := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !shellLine.MatchString(line) {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// parseShellStyle parses profile=name and root=true lines, returning the
+// line of each key.
+func parseShellStyle(df *DotclaudeFile, data []byte) (map[string]int, error) {
+	lines := make(map[string]int)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), "\"'")
+		if _, dup := lines[key]; dup {
+			return nil, &DotclaudeError{Path: df.Path, Line: n, Msg: fmt.Sprintf("%s is set twice", key)}
+		}
+		lines[key] = n
+
+		switch key {
+		case "profile":
+			df.Profile = value
+		case "root":
+			switch value {
+			case "true":
+				df.Root = true
+			case "false":
+			default:
+				return nil, &DotclaudeError{Path: df.Path, Line: n, Msg: fmt.Sprintf("root must be true or false, not %q", value)}
+			}
+		default:
+			df.Warnings = append(df.Warnings, &DotclaudeError{Path: df.Path, Line: n,
+				Msg: fmt.Sprintf("unknown key %q ignored (the shell style only supports profile and root; use YAML for the rest)", key)})
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// dotclaudeKeys are the keys a YAML .dotclaude may contain.
+var dotclaudeKeys = []string{"profile", "overlays", "min_version", "root", "hooks", "notes"}
+
+// parseYAMLStyle decodes a YAML .dotclaude, returning the line of each
+// top-level key.
+func parseYAMLStyle(df *DotclaudeFile, data []byte) (map[string]int, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, yamlError(df.Path, err)
+	}
+	lines := make(map[string]int)
+	if len(doc.Content) == 0 {
+		return lines, nil // Empty or only comments
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, &DotclaudeError{Path: df.Path, Line: root.Line,
+			Msg: "expected settings such as 'profile: name'"}
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key := root.Content[i]
+		if !containsString(dotclaudeKeys, key.Value) {
+			df.Warnings = append(df.Warnings, &DotclaudeError{Path: df.Path, Line: key.Line,
+				Msg: fmt.Sprintf("unknown key %q ignored (known keys: %s)", key.Value, strings.Join(dotclaudeKeys, ", "))})
+		}
+		if _, dup := lines[key.Value]; dup {
+			return nil, &DotclaudeError{Path: df.Path, Line: key.Line, Msg: fmt.Sprintf("%s is set twice", key.Value)}
+		}
+		lines[key.Value] = key.Line
+	}
+
+	if err := root.Decode(df); err != nil {
+		return nil, yamlError(df.Path, err)
+	}
+	return lines, nil
+}
+
+// yamlError converts a yaml.v3 error into a *DotclaudeError, keeping the
+// line number it reports.
+func yamlError(path string, err error) error {
+	msg := err.Error()
+	if typeErr, ok := err.(*yaml.TypeError); ok && len(typeErr.Errors) > 0 {
+		msg = typeErr.Errors[0]
+	}
+	if m := yamlLine.FindStringSubmatch(msg); m != nil {
+		var line int
+		fmt.Sscanf(m[1], "%d", &line)
+		return &DotclaudeError{Path: path, Line: line, Msg: m[2]}
+	}
+	return &DotclaudeError{Path: path, Msg: strings.TrimPrefix(msg, "yaml: ")}
+}
+
+// validate checks the values, using lines to point at the offending key.
+func (df *DotclaudeFile) validate(lines map[string]int) error {
+	fail := func(key, format string, args ...interface{}) error {
+		return &DotclaudeError{Path: df.Path, Line: lines[key], Msg: fmt.Sprintf(format, args...)}
+	}
+
+	if df.Profile != "" {
+		if err := ValidateStackName(df.Profile); err != nil {
+			return fail("profile", "%v", err)
+		}
+	}
+	if _, set := lines["profile"]; set && df.Profile == "" {
+		return fail("profile", "profile is empty")
+	}
+	if len(df.Overlays) > 0 && df.Profile == "" {
+		return fail("overlays", "overlays need a profile to go on top of")
+	}
+	for _, overlay := range df.Overlays {
+		if err := ValidateProfileName(overlay); err != nil {
+			return fail("overlays", "invalid overlay: %v", err)
+		}
+		if containsString(SplitStack(df.Profile), overlay) {
+			return fail("overlays", "overlay '%s' is already in the profile", overlay)
+		}
+	}
+	if df.MinVersion != "" {
+		if _, err := parseVersion(df.MinVersion); err != nil {
+			return fail("min_version", "%v", err)
+		}
+	}
+	for _, hookType := range df.HookTypes() {
+		if !hookTypePattern.MatchString(hookType) {
+			return fail("hooks", "invalid hook type %q", hookType)
+		}
+		for _, command := range df.Hooks[hookType] {
+			if strings.TrimSpace(command) == "" {
+				return fail("hooks", "empty command for %s", hookType)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckVersion returns an error if the file requires a newer dotclaude than
+// version. An empty version (a development build) satisfies everything.
+func (df *DotclaudeFile) CheckVersion(version string) error {
+	if df.MinVersion == "" || version == "" {
+		return nil
+	}
+	cmp, err := CompareVersions(version, df.MinVersion)
+	if err != nil {
+		return nil // Not a release version
+	}
+	if cmp < 0 {
+		return fmt.Errorf("%s requires dotclaude >= %s (running %s)", df.Path, df.MinVersion, version)
+	}
+	return nil
+}
+
+// FindDotclaude looks for the .dotclaude file that applies to dir: the
+// nearest one in dir or its parents with any settings, whether a profile,
+// notes, hooks or just root: true. Files that are empty or only hold
+// comments are skipped. The search stops at the top of dir's git work tree
+// or at the filesystem root. It returns nil if no file applies.
+func FindDotclaude(dir string) (*DotclaudeFile, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	top, _ := gitPaths(abs)
+	if resolved, err := filepath.EvalSymlinks(top); err == nil {
+		top = resolved
+	}
+
+	for {
+		path := filepath.Join(abs, DotclaudeFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			df, err := ReadDotclaudeFile(path)
+			if err != nil {
+				return nil, err
+			}
+			if !df.empty {
+				return df, nil
+			}
+		}
+
+		parent := filepath.Dir(abs)
+		if abs == top || parent == abs {
+			return nil, nil
+		}
+		abs = parent
+	}
+}
@@ -0,0 +1,323 @@
+package profile
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadDotclaudeFile(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+		root     bool
+	}{
+		{"yaml format", "profile: my-profile", "my-profile", false},
+		{"yaml with quotes", "profile: \"my-profile\"", "my-profile", false},
+		{"yaml with single quotes", "profile: 'my-profile'", "my-profile", false},
+		{"shell format", "profile=my-profile", "my-profile", false},
+		{"shell with quotes", "profile=\"my-profile\"", "my-profile", false},
+		{"with comments", "# comment\nprofile: my-profile", "my-profile", false},
+		{"empty file", "", "", false},
+		{"only comments", "# nothing yet\n", "", false},
+		{"root", "root: true\nprofile: my-profile", "my-profile", true},
+		{"root only", "root=true", "", true},
+		{"root false", "root: false", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tmpDir, ".dotclaude-"+tt.name)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ReadDotclaudeFile(path)
+			if err != nil {
+				t.Fatalf("ReadDotclaudeFile() error = %v", err)
+			}
+			if got.Profile != tt.expected {
+				t.Errorf("ReadDotclaudeFile().Profile = %q, want %q", got.Profile, tt.expected)
+			}
+			if got.Root != tt.root {
+				t.Errorf("ReadDotclaudeFile().Root = %v, want %v", got.Root, tt.root)
+			}
+		})
+	}
+}
+
+func TestParseDotclaudeSchema(t *testing.T) {
+	content := `# Project settings
+profile: work
+overlays: [golang, security]
+min_version: 1.2.0
+hooks:
+  session-start: ./scripts/check-env.sh
+  post-tool-bash:
+    - make lint
+    - make vet
+notes: |
+  Run tests with make test.
+  Never commit to main.
+`
+	df, err := ParseDotclaude("/project/.dotclaude", []byte(content))
+	if err != nil {
+		t.Fatalf("ParseDotclaude() error = %v", err)
+	}
+
+	if df.Profile != "work" {
+		t.Errorf("Profile = %q, want work", df.Profile)
+	}
+	if want := []string{"golang", "security"}; !reflect.DeepEqual(df.Overlays, want) {
+		t.Errorf("Overlays = %v, want %v", df.Overlays, want)
+	}
+	if df.MinVersion != "1.2.0" {
+		t.Errorf("MinVersion = %q, want 1.2.0", df.MinVersion)
+	}
+	if want := []string{"post-tool-bash", "session-start"}; !reflect.DeepEqual(df.HookTypes(), want) {
+		t.Errorf("HookTypes() = %v, want %v", df.HookTypes(), want)
+	}
+	if got := df.Hooks["session-start"]; len(got) != 1 || got[0] != "./scripts/check-env.sh" {
+		t.Errorf("session-start hooks = %v, want the single command", got)
+	}
+	if got := df.Hooks["post-tool-bash"]; len(got) != 2 || got[1] != "make vet" {
+		t.Errorf("post-tool-bash hooks = %v, want both commands", got)
+	}
+	if df.Notes != "Run tests with make test.\nNever commit to main.\n" {
+		t.Errorf("Notes = %q", df.Notes)
+	}
+	if df.Dir() != "/project" {
+		t.Errorf("Dir() = %q, want /project", df.Dir())
+	}
+	if df.Stack() != "work+golang+security" {
+		t.Errorf("Stack() = %q, want work+golang+security", df.Stack())
+	}
+
+	stacked, err := ParseDotclaude("/project/.dotclaude", []byte("profile: work+golang\noverlays: [security]\n"))
+	if err != nil {
+		t.Fatalf("ParseDotclaude() with a stack error = %v", err)
+	}
+	if stacked.Stack() != "work+golang+security" {
+		t.Errorf("Stack() = %q, want work+golang+security", stacked.Stack())
+	}
+}
+
+func TestParseDotclaudeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		line    int
+		msg     string
+	}{
+		{"invalid profile", "# comment\nprofile: ../etc", 2, "invalid profile name"},
+		{"empty profile", "profile: ''", 1, "profile is empty"},
+		{"overlays without profile", "root: true\noverlays: [golang]", 2, "need a profile"},
+		{"invalid overlay", "profile: work\noverlays: [go/lang]", 2, "invalid overlay"},
+		{"overlay is profile", "profile: work\noverlays: [work]", 2, "already in the profile"},
+		{"overlay in stack", "profile: work+golang\noverlays: [golang]", 2, "already in the profile"},
+		{"invalid stack", "profile: work++golang", 1, "empty profile name"},
+		{"bad version", "profile: work\nmin_version: soon", 2, "invalid version"},
+		{"bad hook type", "hooks:\n  Session Start: x\nprofile: work", 1, "invalid hook type"},
+		{"empty hook command", "profile: work\nhooks:\n  session-start: ''", 2, "empty command"},
+		{"wrong type", "profile: work\nroot: maybe", 2, "cannot unmarshal"},
+		{"shell root value", "root=maybe", 1, "true or false"},
+		{"duplicate", "profile: a\nprofile: b", 2, ""},
+		{"not a mapping", "- profile: work", 1, "expected settings"},
+		{"bad yaml", "profile: work\n  notes: [", 2, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDotclaude("/p/.dotclaude", []byte(tt.content))
+			var dcErr *DotclaudeError
+			if !errors.As(err, &dcErr) {
+				t.Fatalf("ParseDotclaude() error = %v, want a *DotclaudeError", err)
+			}
+			if dcErr.Line != tt.line {
+				t.Errorf("error line = %d, want %d (%v)", dcErr.Line, tt.line, err)
+			}
+			if !strings.Contains(dcErr.Msg, tt.msg) {
+				t.Errorf("error = %q, want it to mention %q", dcErr.Msg, tt.msg)
+			}
+			if !strings.HasPrefix(err.Error(), "/p/.dotclaude:") {
+				t.Errorf("error %q should start with the file path", err)
+			}
+		})
+	}
+}
+
+func TestParseDotclaudeUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		line    int
+	}{
+		{"yaml", "profile: work\nformatter: prettier\nnotes: Use make.\n", 2},
+		{"shell", "profile=work\nother=value\n", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			df, err := ParseDotclaude("/p/.dotclaude", []byte(tt.content))
+			if err != nil {
+				t.Fatalf("ParseDotclaude() error = %v, want unknown keys to be warnings", err)
+			}
+			if df.Profile != "work" {
+				t.Errorf("Profile = %q, want work", df.Profile)
+			}
+			if len(df.Warnings) != 1 || df.Warnings[0].Line != tt.line || !strings.Contains(df.Warnings[0].Msg, "unknown key") {
+				t.Errorf("Warnings = %v, want one unknown key warning on line %d", df.Warnings, tt.line)
+			}
+		})
+	}
+
+	df, err := ParseDotclaude("/p/.dotclaude", []byte("profile: work\nnotes: Use make.\n"))
+	if err != nil || df.Notes != "Use make." || len(df.Warnings) != 0 {
+		t.Errorf("ParseDotclaude() = %+v, %v, want known keys parsed without warnings", df, err)
+	}
+}
+
+func TestDotclaudeCheckVersion(t *testing.T) {
+	df := &DotclaudeFile{Path: "/p/.dotclaude", MinVersion: "1.2.0"}
+
+	if err := df.CheckVersion("1.1.0"); err == nil {
+		t.Error("CheckVersion(1.1.0) should fail for min_version 1.2.0")
+	}
+	for _, version := range []string{"1.2.0", "2.0.0", "", "dev"} {
+		if err := df.CheckVersion(version); err != nil {
+			t.Errorf("CheckVersion(%q) error = %v", version, err)
+		}
+	}
+}
+
+func TestFindDotclaude(t *testing.T) {
+	tmpDir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(rel, content string) {
+		t.Helper()
+		path := filepath.Join(tmpDir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("code/.dotclaude", "profile: personal\n")
+	write("code/api/services/users/main.go", "package main\n")
+	write("code/api/.dotclaude", "profile: work\n")
+	write("code/lib/.dotclaude", "# no profile here\n")
+	write("code/docs/.dotclaude", "notes: Build with make docs.\n")
+	write("code/docs/guide/x.md", "# guide\n")
+	write("code/tools/.dotclaude", "hooks:\n  session-start: ./check.sh\n")
+	write("code/tools/cmd/x.go", "package main\n")
+	write("code/lib/src/lib.go", "package lib\n")
+	write("code/vendor/.dotclaude", "root: true\n")
+	write("code/vendor/pkg/x.go", "package x\n")
+	write("code/sandbox/.dotclaude", "root: true\nprofile: sandbox\n")
+	write("code/sandbox/deep/x.go", "package x\n")
+
+	tests := []struct {
+		dir  string
+		want string // Path relative to tmpDir, "" for none
+	}{
+		{"code", "code/.dotclaude"},
+		{"code/api", "code/api/.dotclaude"},
+		{"code/api/services/users", "code/api/.dotclaude"},
+		{"code/lib/src", "code/.dotclaude"}, // Skips a file with only comments
+		{"code/docs/guide", "code/docs/.dotclaude"},
+		{"code/tools/cmd", "code/tools/.dotclaude"},
+		{"code/vendor/pkg", "code/vendor/.dotclaude"},
+		{"code/sandbox/deep", "code/sandbox/.dotclaude"},
+		{".", ""},
+	}
+
+	for _, tt := range tests {
+		got, err := FindDotclaude(filepath.Join(tmpDir, tt.dir))
+		if err != nil {
+			t.Fatalf("FindDotclaude(%s) error = %v", tt.dir, err)
+		}
+		if tt.want == "" {
+			if got != nil {
+				t.Errorf("FindDotclaude(%s) = %s, want none", tt.dir, got.Path)
+			}
+			continue
+		}
+		if got == nil {
+			t.Errorf("FindDotclaude(%s) = none, want %s", tt.dir, tt.want)
+			continue
+		}
+		if want := filepath.Join(tmpDir, tt.want); got.Path != want {
+			t.Errorf("FindDotclaude(%s) = %s, want %s", tt.dir, got.Path, want)
+		}
+	}
+
+	// root: true without a profile means no profile applies
+	got, _ := FindDotclaude(filepath.Join(tmpDir, "code/vendor/pkg"))
+	if got == nil || got.Profile != "" || !got.Root {
+		t.Errorf("FindDotclaude(vendor/pkg) = %+v, want the root file with no profile", got)
+	}
+
+	// Notes and hooks without a profile are found, not skipped
+	if got, _ := FindDotclaude(filepath.Join(tmpDir, "code/docs/guide")); got == nil || got.Profile != "" || got.Notes != "Build with make docs." {
+		t.Errorf("FindDotclaude(docs/guide) = %+v, want the notes-only file", got)
+	}
+	if got, _ := FindDotclaude(filepath.Join(tmpDir, "code/tools/cmd")); got == nil || len(got.Hooks["session-start"]) != 1 {
+		t.Errorf("FindDotclaude(tools/cmd) = %+v, want the hooks-only file", got)
+	}
+
+	// A misspelled profile key is found, with a warning, rather than skipped
+	write("code/typo/.dotclaude", "profle: work\n")
+	if got, err := FindDotclaude(filepath.Join(tmpDir, "code/typo")); err != nil || got == nil || len(got.Warnings) != 1 || got.Warnings[0].Line != 1 {
+		t.Errorf("FindDotclaude(typo) = %+v, %v, want the file with an unknown key warning on line 1", got, err)
+	}
+}
+
+func TestFindDotclaudeStopsAtGitTopLevel(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	tmpDir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, ".dotclaude"), []byte("profile: outer\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	repo := filepath.Join(tmpDir, "repo")
+	sub := filepath.Join(repo, "sub")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("git", "init", "-q")
+	cmd.Dir = repo
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+
+	got, err := FindDotclaude(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Errorf("FindDotclaude() = %s, want none: the search stops at the repository root", got.Path)
+	}
+
+	if err := os.WriteFile(filepath.Join(repo, ".dotclaude"), []byte("profile: inner\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err = FindDotclaude(sub)
+	if err != nil || got == nil || got.Profile != "inner" {
+		t.Errorf("FindDotclaude() = %+v, %v, want the repository's file", got, err)
+	}
+}
@@ -0,0 +1,223 @@
+package profile
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DriftStatus describes how a deployed file compares to what was deployed.
+type DriftStatus string
+
+const (
+	// DriftNone means the file is unchanged since activation.
+	DriftNone DriftStatus = "unchanged"
+	// DriftModified means the file was edited after activation.
+	DriftModified DriftStatus = "modified"
+	// DriftMissing means the file was deleted after activation.
+	DriftMissing DriftStatus = "missing"
+)
+
+// FileDrift is the drift status of one deployed file.
+type FileDrift struct {
+	Path   string // Relative to ClaudeDir, slash-separated
+	Status DriftStatus
+}
+
+// DriftError is returned by activation when deployed files were edited by
+// hand and would be overwritten.
+type DriftError struct {
+	Files []FileDrift
+}
+
+func (e *DriftError) Error() string {
+	paths := make([]string, len(e.Files))
+	for i, f := range e.Files {
+		paths[i] = f.Path
+	}
+	return fmt.Sprintf("refusing to overwrite locally modified files: %s (use --force to overwrite)", strings.Join(paths, ", "))
+}
+
+// hashContent returns the hash recorded for deployed content.
+func hashContent(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// hashFile returns the content hash of a file.
+func hashFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return hashContent(data), nil
+}
+
+// deployedHash returns the hash to compare with the one state records for a
+// deployed file: the hash of its content, or of its managed block for a
+// managed CLAUDE.md ("" if the block is gone).
+func deployedHash(state *State, rel string, data []byte) string {
+	if rel == "CLAUDE.md" && state.CLAUDEmdMode() == CLAUDEmdManaged {
+		return managedBlockHash(data)
+	}
+	return hashContent(data)
+}
+
+// updateDeployedHash records new content for a file dotclaude itself replaced
+// outside of activation (e.g. by restoring a backup), so it isn't reported as
+// drift. Files that aren't tracked are ignored.
+func (m *Manager) updateDeployedHash(path string, data []byte) error {
+	rel, err := filepath.Rel(m.ClaudeDir, path)
+	if err != nil {
+		return err
+	}
+
+	state, err := m.LoadState()
+	if err != nil || state == nil {
+		return err
+	}
+	if _, ok := state.Files[filepath.ToSlash(rel)]; !ok {
+		return nil
+	}
+	state.Files[filepath.ToSlash(rel)] = deployedHash(state, filepath.ToSlash(rel), data)
+	return m.saveState(state)
+}
+
+// isBookkeeping reports whether a path relative to ClaudeDir is one of
+// dotclaude's own state files rather than deployed configuration, including
+// anything inside a dotclaude directory such as the saved originals.
+func isBookkeeping(rel string) bool {
+	base := filepath.Base(rel)
+	top := strings.SplitN(filepath.ToSlash(rel), "/", 2)[0]
+	return strings.HasPrefix(base, ".dotclaude") || strings.HasPrefix(top, ".dotclaude") || base == legacyStateFile
+}
+
+// Drift compares every file the last activation deployed with what is on
+// disk now, sorted by path. For a managed CLAUDE.md only the managed block
+// is compared. When the state records no hash for CLAUDE.md (it was lost,
+// or dropped by deactivate --keep-files), a managed block is checked
+// against the hash in its begin marker.
+func (m *Manager) Drift() ([]FileDrift, error) {
+	state, err := m.LoadState()
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]string{}
+	if state != nil {
+		files = state.Files
+	}
+
+	drift := make([]FileDrift, 0, len(files)+1)
+	if _, ok := files["CLAUDE.md"]; !ok {
+		f, err := m.markerDrift()
+		if err != nil {
+			return nil, err
+		}
+		if f != nil {
+			drift = append(drift, *f)
+		}
+	}
+	for rel, want := range files {
+		status := DriftNone
+		data, err := os.ReadFile(filepath.Join(m.ClaudeDir, filepath.FromSlash(rel)))
+		switch {
+		case os.IsNotExist(err):
+			status = DriftMissing
+		case err != nil:
+			return nil, fmt.Errorf("failed to read %s: %w", rel, err)
+		case deployedHash(state, rel, data) != want:
+			status = DriftModified
+		}
+		drift = append(drift, FileDrift{Path: rel, Status: status})
+	}
+
+	sort.Slice(drift, func(i, j int) bool {
+		return drift[i].Path < drift[j].Path
+	})
+	return drift, nil
+}
+
+// markerDrift checks a managed block in CLAUDE.md against the hash its begin
+// marker records. It returns nil if there is no block with a hash.
+func (m *Manager) markerDrift() (*FileDrift, error) {
+	data, err := os.ReadFile(filepath.Join(m.ClaudeDir, "CLAUDE.md"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CLAUDE.md: %w", err)
+	}
+	block, ok := findManagedBlock(data)
+	if !ok || block.Hash == "" {
+		return nil, nil
+	}
+	status := DriftNone
+	if hashContent([]byte(block.Body)) != block.Hash {
+		status = DriftModified
+	}
+	return &FileDrift{Path: "CLAUDE.md", Status: status}, nil
+}
+
+// modifiedFiles returns the deployed files that were edited since activation.
+func (m *Manager) modifiedFiles() ([]FileDrift, error) {
+	drift, err := m.Drift()
+	if err != nil {
+		return nil, err
+	}
+
+	var modified []FileDrift
+	for _, f := range drift {
+		if f.Status == DriftModified {
+			modified = append(modified, f)
+		}
+	}
+	return modified, nil
+}
+
+// ActivationPlan is what activating a profile would deploy.
+type ActivationPlan struct {
+	// Files maps each path relative to ClaudeDir (slash-separated) to the
+	// content it would get. Settings values holding secret references are
+	// SecretMask: nothing is resolved, so no ${cmd:...} runs.
+	Files map[string][]byte
+	// secretPaths are the settings.json values holding secret references,
+	// for Mask.
+	secretPaths [][]string
+}
+
+// Mask returns the content of the file at path (relative to ClaudeDir, like
+// the keys of Files) with the settings values that hold secret references
+// replaced by SecretMask, so planned or deployed content can be shown.
+func (p *ActivationPlan) Mask(path string, data []byte) []byte {
+	if path != "settings.json" {
+		return data
+	}
+	return maskSettings(data, p.secretPaths)
+}
+
+// Plan returns what activating a profile would deploy. Nothing is written
+// and no secret reference is resolved.
+func (m *Manager) Plan(name string) (*ActivationPlan, error) {
+	if err := ValidateStackName(name); err != nil {
+		return nil, err
+	}
+
+	tx := newPlan()
+	if err := m.stageActivation(tx, name, ActivateOptions{}); err != nil {
+		return nil, err
+	}
+
+	plan := &ActivationPlan{Files: make(map[string][]byte, len(tx.planned)), secretPaths: tx.secretPaths}
+	for path, data := range tx.planned {
+		rel, err := filepath.Rel(m.ClaudeDir, path)
+		if err != nil || isBookkeeping(rel) {
+			continue
+		}
+		plan.Files[filepath.ToSlash(rel)] = data
+	}
+	return plan, nil
+}
@@ -0,0 +1,127 @@
+package profile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDrift(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+	profileDir := writeProfile(t, tmpDir, "work", "")
+	writeAgentDefinition(t, profileDir, "reviewer", "Reviewer")
+
+	if err := mgr.Activate("work"); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses := func() map[string]DriftStatus {
+		t.Helper()
+		drift, err := mgr.Drift()
+		if err != nil {
+			t.Fatalf("Drift() error = %v", err)
+		}
+		result := make(map[string]DriftStatus)
+		for _, f := range drift {
+			result[f.Path] = f.Status
+		}
+		return result
+	}
+
+	t.Run("clean after activation", func(t *testing.T) {
+		got := statuses()
+		for _, path := range []string{"CLAUDE.md", "settings.json", "agents/reviewer.md"} {
+			if got[path] != DriftNone {
+				t.Errorf("%s status = %q, want %q", path, got[path], DriftNone)
+			}
+		}
+		for path := range got {
+			if isBookkeeping(path) {
+				t.Errorf("bookkeeping file %s should not be tracked", path)
+			}
+		}
+	})
+
+	t.Run("modified and missing", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(mgr.ClaudeDir, "CLAUDE.md"), []byte("hand edit\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Remove(filepath.Join(mgr.ClaudeDir, "agents", "reviewer.md")); err != nil {
+			t.Fatal(err)
+		}
+
+		got := statuses()
+		if got["CLAUDE.md"] != DriftModified {
+			t.Errorf("CLAUDE.md status = %q, want %q", got["CLAUDE.md"], DriftModified)
+		}
+		if got["agents/reviewer.md"] != DriftMissing {
+			t.Errorf("agents/reviewer.md status = %q, want %q", got["agents/reviewer.md"], DriftMissing)
+		}
+	})
+
+	t.Run("activate refuses to overwrite edits", func(t *testing.T) {
+		err := mgr.Activate("work")
+		var driftErr *DriftError
+		if !errors.As(err, &driftErr) {
+			t.Fatalf("Activate() error = %v, want *DriftError", err)
+		}
+		if len(driftErr.Files) != 1 || driftErr.Files[0].Path != "CLAUDE.md" {
+			t.Errorf("DriftError.Files = %v, want only CLAUDE.md", driftErr.Files)
+		}
+		if got := readString(t, filepath.Join(mgr.ClaudeDir, "CLAUDE.md")); got != "hand edit\n" {
+			t.Error("refused activation should leave the edited file alone")
+		}
+	})
+
+	t.Run("force overwrites and backs up edits", func(t *testing.T) {
+		if err := mgr.ActivateWithOptions("work", ActivateOptions{Force: true}); err != nil {
+			t.Fatalf("ActivateWithOptions(Force) error = %v", err)
+		}
+
+		backups, err := mgr.ListBackups()
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, backup := range backups {
+			if data, err := mgr.ReadBackupFile(backup, "CLAUDE.md"); err == nil && string(data) == "hand edit\n" {
+				found = true
+			}
+		}
+		if !found {
+			t.Error("forced activation should back up the edited CLAUDE.md")
+		}
+
+		for path, status := range statuses() {
+			if status != DriftNone {
+				t.Errorf("%s status = %q after forced activation", path, status)
+			}
+		}
+	})
+}
+
+func TestPlan(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+	writeProfile(t, tmpDir, "work", "")
+
+	plan, err := mgr.Plan("work")
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if _, ok := plan.Files["CLAUDE.md"]; !ok {
+		t.Error("Plan() should include CLAUDE.md")
+	}
+	if _, ok := plan.Files[StateFileName]; ok {
+		t.Error("Plan() should leave out bookkeeping files")
+	}
+	if _, err := os.Stat(filepath.Join(mgr.ClaudeDir, "CLAUDE.md")); !os.IsNotExist(err) {
+		t.Error("Plan() should not write anything")
+	}
+}
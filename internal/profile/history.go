@@ -0,0 +1,137 @@
+package profile
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// HistoryFileName is the append-only log of state changes, in ClaudeDir.
+// It holds one JSON HistoryEntry per line.
+const HistoryFileName = ".dotclaude-history.jsonl"
+
+// History actions.
+const (
+	HistoryActivate   = "activate"
+	HistoryDeactivate = "deactivate"
+	HistoryRestore    = "restore"
+	HistoryDelete     = "delete"
+)
+
+// HistoryEntry records one operation that changed dotclaude's state.
+type HistoryEntry struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	// From and To are the active profile before and after the operation.
+	// They are equal for operations that don't switch profiles.
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+	// Target is what the operation acted on other than the active profile:
+	// the deleted profile or the restored backup.
+	Target  string `json:"target,omitempty"`
+	Commit  string `json:"commit,omitempty"`  // Repo HEAD at the time
+	Command string `json:"command,omitempty"` // Command line that triggered it
+	Dir     string `json:"cwd,omitempty"`     // Working directory it ran in
+}
+
+// HistoryFilter selects history entries. Zero fields match everything.
+type HistoryFilter struct {
+	// Profile matches entries that switched from or to the profile, alone
+	// or in a stack, or deleted it.
+	Profile string
+	Since   time.Time // Entries at or after this time
+	Until   time.Time // Entries before this time
+}
+
+// matches reports whether the entry passes the filter.
+func (f HistoryFilter) matches(e HistoryEntry) bool {
+	if f.Profile != "" && !inStack(e.From, f.Profile) && !inStack(e.To, f.Profile) &&
+		!(e.Action == HistoryDelete && e.Target == f.Profile) {
+		return false
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !e.Time.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// inStack reports whether profileName is name or one of the profiles of the
+// stack name.
+func inStack(name, profileName string) bool {
+	return name != "" && (name == profileName || containsString(SplitStack(name), profileName))
+}
+
+// historyPath returns the path of the history log.
+func (m *Manager) historyPath() string {
+	return filepath.Join(m.ClaudeDir, HistoryFileName)
+}
+
+// recordHistory appends an entry to the history log, filling in the time,
+// repo commit, command line and working directory. Callers hold the lock.
+func (m *Manager) recordHistory(entry HistoryEntry) error {
+	entry.Time = time.Now().UTC()
+	entry.Commit = gitHead(m.RepoDir)
+	entry.Command = commandLine()
+	if dir, err := os.Getwd(); err == nil {
+		entry.Dir = dir
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(m.historyPath(), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history log: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write history log: %w", err)
+	}
+	return f.Close()
+}
+
+// logHistory records an entry, warning instead of failing: the operation it
+// describes has already happened.
+func (m *Manager) logHistory(entry HistoryEntry) {
+	if err := m.recordHistory(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: could not record history: %v\n", err)
+	}
+}
+
+// History returns the logged entries that match filter, oldest first. Lines
+// that can't be parsed, such as one cut short by a crash, are skipped.
+func (m *Manager) History(filter HistoryFilter) ([]HistoryEntry, error) {
+	f, err := os.Open(m.historyPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read history log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if filter.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history log: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,107 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHistoryRecordsOperations(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+	writeProfile(t, tmpDir, "work", "")
+	writeProfile(t, tmpDir, "personal", "")
+	writeProfile(t, tmpDir, "old", "")
+
+	if err := mgr.Activate("work"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.Activate("personal"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.Delete("old"); err != nil {
+		t.Fatal(err)
+	}
+	backups, err := mgr.ListBackups()
+	if err != nil || len(backups) == 0 {
+		t.Fatalf("ListBackups() = %v, %v, want a backup from the switch", backups, err)
+	}
+	if err := mgr.Restore(backups[0].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := mgr.History(HistoryFilter{})
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	want := []HistoryEntry{
+		{Action: HistoryActivate, From: "", To: "work"},
+		{Action: HistoryActivate, From: "work", To: "personal"},
+		{Action: HistoryDelete, From: "personal", To: "personal", Target: "old"},
+		{Action: HistoryRestore, From: "personal", To: "work", Target: backups[0].ID},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("History() returned %d entries, want %d: %+v", len(entries), len(want), entries)
+	}
+	cwd, _ := os.Getwd()
+	for i, e := range entries {
+		w := want[i]
+		if e.Action != w.Action || e.From != w.From || e.To != w.To || e.Target != w.Target {
+			t.Errorf("entry %d = %+v, want %+v", i, e, w)
+		}
+		if e.Time.IsZero() || e.Command == "" || e.Dir != cwd {
+			t.Errorf("entry %d missing time, command or cwd: %+v", i, e)
+		}
+	}
+}
+
+func TestHistoryFilter(t *testing.T) {
+	claudeDir := t.TempDir()
+	mgr := NewManager(t.TempDir(), claudeDir)
+
+	day := time.Date(2025, 1, 6, 12, 0, 0, 0, time.UTC)
+	log := `{"time":"2025-01-05T12:00:00Z","action":"activate","to":"work"}
+{"time":"2025-01-06T12:00:00Z","action":"activate","from":"work","to":"personal"}
+not json
+{"time":"2025-01-07T12:00:00Z","action":"delete","from":"personal","to":"personal","target":"work"}
+{"time":"2025-01-08T12:00:00Z","action":"activate","from":"personal","to":"oss"}
+{"time":"2025-01-09T12:00:00Z","acti`
+	if err := os.WriteFile(filepath.Join(claudeDir, HistoryFileName), []byte(log), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		filter HistoryFilter
+		want   int
+	}{
+		{"all, skipping bad lines", HistoryFilter{}, 4},
+		{"by profile", HistoryFilter{Profile: "work"}, 3},
+		{"since", HistoryFilter{Since: day}, 3},
+		{"until is exclusive", HistoryFilter{Until: day}, 1},
+		{"range and profile", HistoryFilter{Profile: "personal", Since: day, Until: day.AddDate(0, 0, 2)}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := mgr.History(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != tt.want {
+				t.Errorf("History() returned %d entries, want %d: %+v", len(entries), tt.want, entries)
+			}
+		})
+	}
+}
+
+func TestHistoryEmpty(t *testing.T) {
+	mgr := NewManager(t.TempDir(), t.TempDir())
+
+	entries, err := mgr.History(HistoryFilter{})
+	if err != nil || len(entries) != 0 {
+		t.Errorf("History() = %v, %v, want nothing", entries, err)
+	}
+}
@@ -0,0 +1,148 @@
+package profile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// HookFile is a hook script deployed to <ClaudeDir>/hooks/<type>/.
+type HookFile struct {
+	Type   string // Hook type directory, e.g. "session-start"
+	Name   string // File name including its priority prefix, e.g. "20-check.sh"
+	Layer  string // "base" or the name of the profile that provided it
+	Source string // Path of the hook in the repository
+}
+
+// RelPath returns the hook's path relative to the hooks directory.
+func (h *HookFile) RelPath() string {
+	return h.Type + "/" + h.Name
+}
+
+// ListHooks returns the hooks activating the profile would deploy, sorted by
+// type and name. Hooks come from base/hooks/<type>/ and the hooks/<type>/
+// directory of each profile in the inheritance chain; a hook in a later layer
+// replaces one with the same type and file name. A profile whose manifest
+// lists hooks contributes only those.
+func (m *Manager) ListHooks(profileName string) ([]*HookFile, error) {
+	byPath := make(map[string]*HookFile)
+
+	layers, err := m.layers(profileName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, l := range layers {
+		hooksDir := filepath.Join(l.Dir, "hooks")
+		found := make(map[string]bool)
+		typeEntries, err := os.ReadDir(hooksDir)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read hooks directory: %w", err)
+		}
+
+		for _, typeEntry := range typeEntries {
+			if !typeEntry.IsDir() {
+				continue // e.g. hooks/README.md
+			}
+
+			typeDir := filepath.Join(hooksDir, typeEntry.Name())
+			entries, err := os.ReadDir(typeDir)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read hooks directory: %w", err)
+			}
+
+			for _, entry := range entries {
+				if !entry.Type().IsRegular() || isIgnoredHookFile(entry.Name()) {
+					continue
+				}
+
+				hook := &HookFile{
+					Type:   typeEntry.Name(),
+					Name:   entry.Name(),
+					Layer:  l.Name,
+					Source: filepath.Join(typeDir, entry.Name()),
+				}
+				found[hook.RelPath()] = true
+				if l.Manifest != nil && !l.Manifest.contributesHook(hook.RelPath()) {
+					continue
+				}
+				byPath[hook.RelPath()] = hook
+			}
+		}
+
+		if l.Manifest != nil {
+			for _, rel := range l.Manifest.Hooks {
+				if !found[rel] {
+					return nil, fmt.Errorf("profile '%s' lists hook '%s', which is not in %s", l.Name, rel, m.relPath(hooksDir))
+				}
+			}
+		}
+	}
+
+	hookFiles := make([]*HookFile, 0, len(byPath))
+	for _, hook := range byPath {
+		hookFiles = append(hookFiles, hook)
+	}
+	sort.Slice(hookFiles, func(i, j int) bool {
+		return hookFiles[i].RelPath() < hookFiles[j].RelPath()
+	})
+
+	return hookFiles, nil
+}
+
+// isIgnoredHookFile reports whether a file in a hook type directory is
+// repository bookkeeping rather than a hook (.gitkeep, README.md, ...).
+func isIgnoredHookFile(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasPrefix(strings.ToUpper(name), "README")
+}
+
+// deployHooks copies the profile's hooks into <ClaudeDir>/hooks/<type>/,
+// preserving file modes, and removes hooks the previous activation deployed.
+// Hooks the user placed there by hand are left alone.
+func (m *Manager) deployHooks(tx *transaction, profileName string) error {
+	hookFiles, err := m.ListHooks(profileName)
+	if err != nil {
+		return err
+	}
+
+	hooksDir := filepath.Join(m.ClaudeDir, "hooks")
+	if err := removeManaged(tx, hooksDir); err != nil {
+		return fmt.Errorf("failed to remove previous hooks: %w", err)
+	}
+
+	if len(hookFiles) == 0 {
+		return nil
+	}
+
+	managed := make(map[string]string, len(hookFiles))
+	for _, hook := range hookFiles {
+		info, err := os.Stat(hook.Source)
+		if err != nil {
+			return fmt.Errorf("failed to read hook %s: %w", hook.RelPath(), err)
+		}
+		data, err := os.ReadFile(hook.Source)
+		if err != nil {
+			return fmt.Errorf("failed to read hook %s: %w", hook.RelPath(), err)
+		}
+		target := filepath.Join(hooksDir, hook.Type, hook.Name)
+		if err := tx.WriteFile(target, data, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to copy hook %s: %w", hook.RelPath(), err)
+		}
+		managed[hook.RelPath()] = hook.Layer
+	}
+
+	return writeManaged(tx, hooksDir, managed)
+}
+
+// ManagedHookLayers returns the hooks dotclaude deployed into hooksDir, keyed
+// by "<type>/<file>" and mapped to the layer ("base" or a profile name) that
+// provided them. Hooks not in the map were added by hand.
+func ManagedHookLayers(hooksDir string) map[string]string {
+	managed, err := readManaged(hooksDir)
+	if err != nil {
+		return map[string]string{}
+	}
+	return managed
+}
@@ -0,0 +1,158 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writeHook creates <dir>/hooks/<hookType>/<name> with the given mode
+func writeHook(t *testing.T, dir, hookType, name string, mode os.FileMode) {
+	t.Helper()
+
+	typeDir := filepath.Join(dir, "hooks", hookType)
+	if err := os.MkdirAll(typeDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(typeDir, name), []byte("#!/bin/bash\necho "+name+"\n"), mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListHooks(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+
+	baseDir := filepath.Join(tmpDir, "base")
+	profileDir := filepath.Join(tmpDir, "profiles", "hooks-test")
+	if err := os.MkdirAll(profileDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	writeHook(t, baseDir, "session-start", "20-shared.sh", 0755)
+	writeHook(t, baseDir, "session-start", ".gitkeep", 0644)
+	writeHook(t, baseDir, "post-tool-bash", "30-base.sh", 0755)
+	writeHook(t, profileDir, "session-start", "20-shared.sh", 0755)
+	writeHook(t, profileDir, "session-start", "40-project-check.sh", 0755)
+	if err := os.WriteFile(filepath.Join(baseDir, "hooks", "README.md"), []byte("# Hooks\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	hookFiles, err := mgr.ListHooks("hooks-test")
+	if err != nil {
+		t.Fatalf("ListHooks() error = %v", err)
+	}
+
+	want := map[string]string{
+		"post-tool-bash/30-base.sh":         "base",
+		"session-start/20-shared.sh":        "hooks-test",
+		"session-start/40-project-check.sh": "hooks-test",
+	}
+
+	if len(hookFiles) != len(want) {
+		t.Fatalf("ListHooks() returned %d hooks, want %d", len(hookFiles), len(want))
+	}
+	for _, h := range hookFiles {
+		if layer, ok := want[h.RelPath()]; !ok || layer != h.Layer {
+			t.Errorf("hook %s from %q, want %q", h.RelPath(), h.Layer, layer)
+		}
+	}
+}
+
+func TestListHooksManifestFilter(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+
+	profileDir := writeProfile(t, tmpDir, "picky", "")
+	writeHook(t, profileDir, "session-start", "10-wanted.sh", 0755)
+	writeHook(t, profileDir, "session-start", "20-unwanted.sh", 0755)
+	manifestPath := filepath.Join(profileDir, "profile.yaml")
+
+	if err := os.WriteFile(manifestPath, []byte("hooks: [session-start/10-wanted.sh]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hooks, err := mgr.ListHooks("picky")
+	if err != nil {
+		t.Fatalf("ListHooks() error = %v", err)
+	}
+	if len(hooks) != 1 || hooks[0].RelPath() != "session-start/10-wanted.sh" {
+		t.Errorf("ListHooks() = %v, want only 10-wanted.sh", hooks)
+	}
+
+	if err := os.WriteFile(manifestPath, []byte("hooks: [post-tool/10-missing.sh]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mgr.ListHooks("picky"); err == nil || !strings.Contains(err.Error(), "post-tool/10-missing.sh") {
+		t.Errorf("ListHooks() error = %v, want missing hook error", err)
+	}
+}
+
+func TestDeployHooks(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	claudeDir := filepath.Join(tmpDir, ".claude")
+	mgr := NewManager(tmpDir, claudeDir)
+	hooksDir := filepath.Join(claudeDir, "hooks")
+
+	for _, name := range []string{"first", "second"} {
+		profileDir := filepath.Join(tmpDir, "profiles", name)
+		if err := os.MkdirAll(profileDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(profileDir, "CLAUDE.md"), []byte("# "+name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeHook(t, filepath.Join(tmpDir, "base"), "session-start", "10-base.sh", 0755)
+	writeHook(t, filepath.Join(tmpDir, "profiles", "first"), "session-start", "20-first.sh", 0755)
+
+	// A hand-added hook must survive activations
+	writeHook(t, claudeDir, "session-start", "90-mine.sh", 0755)
+
+	t.Run("activation deploys hooks", func(t *testing.T) {
+		if err := mgr.Activate("first"); err != nil {
+			t.Fatalf("Activate() error = %v", err)
+		}
+
+		for _, name := range []string{"10-base.sh", "20-first.sh"} {
+			info, err := os.Stat(filepath.Join(hooksDir, "session-start", name))
+			if err != nil {
+				t.Fatalf("%s should be deployed: %v", name, err)
+			}
+			if runtime.GOOS != "windows" && info.Mode()&0111 == 0 {
+				t.Errorf("%s should keep its executable bit, mode = %v", name, info.Mode())
+			}
+		}
+
+		layers := ManagedHookLayers(hooksDir)
+		if layers["session-start/10-base.sh"] != "base" {
+			t.Errorf("10-base.sh layer = %q, want base", layers["session-start/10-base.sh"])
+		}
+		if layers["session-start/20-first.sh"] != "first" {
+			t.Errorf("20-first.sh layer = %q, want first", layers["session-start/20-first.sh"])
+		}
+	})
+
+	t.Run("switching removes previous profile hooks", func(t *testing.T) {
+		if err := mgr.Activate("second"); err != nil {
+			t.Fatalf("Activate() error = %v", err)
+		}
+
+		if _, err := os.Stat(filepath.Join(hooksDir, "session-start", "20-first.sh")); !os.IsNotExist(err) {
+			t.Error("20-first.sh should have been removed when switching profiles")
+		}
+		if _, err := os.Stat(filepath.Join(hooksDir, "session-start", "10-base.sh")); err != nil {
+			t.Error("base hook should still be deployed")
+		}
+		if _, err := os.Stat(filepath.Join(hooksDir, "session-start", "90-mine.sh")); err != nil {
+			t.Error("hand-added hook should not be removed")
+		}
+	})
+}
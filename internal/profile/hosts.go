@@ -0,0 +1,111 @@
+package profile
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// HostsDir is the directory, in base and in each profile, holding per-host
+// overlays: hosts/<hostname>/ is layered on top of its parent when the
+// machine's hostname matches. The name may be a glob pattern (hosts/ci-*/)
+// to cover a group of hosts.
+const HostsDir = "hosts"
+
+// CurrentHost returns the lowercased hostname overlays are matched against.
+func CurrentHost() string {
+	host, err := hostname()
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(host)
+}
+
+// matchHost reports whether an overlay directory name matches host. Both the
+// full hostname and its first label (build-box of build-box.example.com) are
+// tried.
+func matchHost(pattern, host string) (bool, error) {
+	pattern = strings.ToLower(pattern)
+	short, _, _ := strings.Cut(host, ".")
+	for _, name := range []string{host, short} {
+		ok, err := path.Match(pattern, name)
+		if err != nil {
+			return false, fmt.Errorf("invalid host pattern %q: %w", pattern, err)
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// hostLayers returns the overlays in l's hosts directory that match host:
+// glob patterns first, alphabetically, then an exact name, so the most
+// specific overlay takes precedence.
+func (m *Manager) hostLayers(l layer, host string) ([]layer, error) {
+	if host == "" {
+		return nil, nil
+	}
+	hostsDir := filepath.Join(l.Dir, HostsDir)
+	entries, err := os.ReadDir(hostsDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hosts directory: %w", err)
+	}
+
+	var globs, exact []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		ok, err := matchHost(entry.Name(), host)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.relPath(hostsDir), err)
+		}
+		if !ok {
+			continue
+		}
+		if strings.ContainsAny(entry.Name(), `*?[\`) {
+			globs = append(globs, entry.Name())
+		} else {
+			exact = append(exact, entry.Name())
+		}
+	}
+	sort.Strings(globs)
+	sort.Strings(exact)
+
+	var overlays []layer
+	for _, name := range append(globs, exact...) {
+		dir := filepath.Join(hostsDir, name)
+		overlays = append(overlays, layer{
+			Name: l.Name + "/" + HostsDir + "/" + name,
+			Dir:  dir,
+			Via:  l.Via,
+			Host: name,
+		})
+	}
+	return overlays, nil
+}
+
+// HostOverlays returns the host overlay directories (relative to the
+// repository) activating the profile applies on this machine, lowest
+// precedence first.
+func (m *Manager) HostOverlays(profileName string) ([]string, error) {
+	layers, err := m.layers(profileName)
+	if err != nil {
+		return nil, err
+	}
+
+	var overlays []string
+	for _, l := range layers {
+		if l.Host != "" {
+			overlays = append(overlays, m.relPath(l.Dir))
+		}
+	}
+	return overlays, nil
+}
@@ -0,0 +1,147 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatchHost(t *testing.T) {
+	tests := []struct {
+		pattern string
+		host    string
+		want    bool
+	}{
+		{"build-box", "build-box", true},
+		{"build-box", "build-box.corp.example.com", true},
+		{"Build-Box", "build-box", true},
+		{"laptop-*", "laptop-alice", true},
+		{"laptop-*", "build-box", false},
+		{"ci-?", "ci-3", true},
+		{"build-box.corp.example.com", "build-box.corp.example.com", true},
+		{"build", "build-box", false},
+	}
+
+	for _, tt := range tests {
+		got, err := matchHost(tt.pattern, tt.host)
+		if err != nil {
+			t.Errorf("matchHost(%q, %q) error = %v", tt.pattern, tt.host, err)
+		}
+		if got != tt.want {
+			t.Errorf("matchHost(%q, %q) = %v, want %v", tt.pattern, tt.host, got, tt.want)
+		}
+	}
+
+	if _, err := matchHost("ci-[", "ci-1"); err == nil {
+		t.Error("matchHost() should reject an invalid pattern")
+	}
+}
+
+func TestHostOverlays(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	claudeDir := filepath.Join(tmpDir, ".claude")
+	mgr := NewManager(tmpDir, claudeDir)
+
+	origHostname := hostname
+	defer func() { hostname = origHostname }()
+	hostname = func() (string, error) { return "Laptop-Alice.example.com", nil }
+
+	workDir := writeProfile(t, tmpDir, "work", "")
+	if err := os.WriteFile(filepath.Join(workDir, "settings.json"), []byte(`{"model": "sonnet", "team": "platform"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	writeOverlay := func(dir, file, content string) {
+		t.Helper()
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeOverlay(filepath.Join(tmpDir, "base", "hosts", "laptop-*"), "settings.json", `{"laptop": true}`)
+	writeOverlay(filepath.Join(workDir, "hosts", "laptop-*"), "settings.json", `{"model": "haiku", "dirs": ["/group"]}`)
+	writeOverlay(filepath.Join(workDir, "hosts", "laptop-alice"), "settings.json", `{"dirs": ["/home/alice"]}`)
+	writeOverlay(filepath.Join(workDir, "hosts", "laptop-alice"), "CLAUDE.md", "# Alice's laptop\n")
+	writeOverlay(filepath.Join(workDir, "hosts", "build-box"), "settings.json", `{"model": "opus"}`)
+	writeAgentDefinition(t, filepath.Join(workDir, "hosts", "laptop-alice"), "local-helper", "Only here")
+
+	t.Run("matching overlays in order", func(t *testing.T) {
+		overlays, err := mgr.HostOverlays("work")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{
+			filepath.Join("base", "hosts", "laptop-*"),
+			filepath.Join("profiles", "work", "hosts", "laptop-*"),
+			filepath.Join("profiles", "work", "hosts", "laptop-alice"),
+		}
+		if !reflect.DeepEqual(overlays, want) {
+			t.Errorf("HostOverlays() = %v, want %v", overlays, want)
+		}
+
+		chain, err := mgr.ResolveChain("work")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(chain, []string{"work"}) {
+			t.Errorf("ResolveChain() = %v, host overlays aren't part of the chain", chain)
+		}
+	})
+
+	if err := mgr.Activate("work"); err != nil {
+		t.Fatalf("Activate() error = %v", err)
+	}
+
+	t.Run("settings merged on top of the profile", func(t *testing.T) {
+		content, err := os.ReadFile(filepath.Join(claudeDir, "settings.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		settings := string(content)
+		for _, want := range []string{`"model": "haiku"`, `"laptop": true`, `"team": "platform"`, `"/home/alice"`} {
+			if !strings.Contains(settings, want) {
+				t.Errorf("settings.json missing %s:\n%s", want, settings)
+			}
+		}
+		if strings.Contains(settings, "opus") || strings.Contains(settings, "/group") {
+			t.Errorf("settings.json should only contain matching overlays, the exact host last:\n%s", settings)
+		}
+	})
+
+	t.Run("CLAUDE.md and agents from overlays", func(t *testing.T) {
+		content, err := os.ReadFile(filepath.Join(claudeDir, "CLAUDE.md"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		merged := string(content)
+		work := strings.Index(merged, "# work rules")
+		host := strings.Index(merged, "# Host overlay: work/hosts/laptop-alice\n")
+		if work < 0 || host < work || !strings.Contains(merged, "# Alice's laptop") {
+			t.Errorf("CLAUDE.md should end with the host overlay:\n%s", merged)
+		}
+		if strings.Count(merged, "Host overlay:") != 1 {
+			t.Errorf("overlays without a CLAUDE.md should add no section:\n%s", merged)
+		}
+
+		if _, err := os.Stat(filepath.Join(claudeDir, "agents", "local-helper.md")); err != nil {
+			t.Errorf("overlay agent should be deployed: %v", err)
+		}
+	})
+
+	t.Run("other hosts get no overlays", func(t *testing.T) {
+		hostname = func() (string, error) { return "desktop", nil }
+		overlays, err := mgr.HostOverlays("work")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(overlays) != 0 {
+			t.Errorf("HostOverlays() = %v, want none", overlays)
+		}
+	})
+}
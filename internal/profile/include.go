@@ -0,0 +1,131 @@
+package profile
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// includeDirective matches a CLAUDE.md line of the form
+//
+//	<!-- include: base/snippets/go-testing.md -->
+//
+// The path is relative to the repository root.
+var includeDirective = regexp.MustCompile(`^\s*<!--\s*include:\s*(\S+)\s*-->\s*$`)
+
+// maxIncludeDepth bounds nesting as a backstop to cycle detection.
+const maxIncludeDepth = 32
+
+// includeExpander resolves include directives for one CLAUDE.md merge.
+type includeExpander struct {
+	m    *Manager
+	data func() *TemplateData // template data for included .tmpl snippets
+}
+
+// expand replaces include directives in content, which was read from the
+// repository-relative path name. Directives inside fenced code blocks are
+// left alone. stack holds the files currently being expanded.
+func (e *includeExpander) expand(name string, content []byte, stack []string) ([]byte, error) {
+	stack = append(stack, name)
+	if len(stack) > maxIncludeDepth {
+		return nil, fmt.Errorf("%s: includes nested more than %d deep", name, maxIncludeDepth)
+	}
+
+	var out bytes.Buffer
+	inFence := false
+	lineNum := 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		lineNum++
+
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+
+		match := includeDirective.FindStringSubmatch(line)
+		if inFence || match == nil {
+			out.WriteString(line)
+			out.WriteByte('\n')
+			continue
+		}
+
+		included, err := e.include(name, lineNum, match[1], stack)
+		if err != nil {
+			return nil, err
+		}
+		out.Write(included)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	// Keep the original trailing newline behaviour
+	result := out.Bytes()
+	if !bytes.HasSuffix(content, []byte("\n")) {
+		result = bytes.TrimSuffix(result, []byte("\n"))
+	}
+	return result, nil
+}
+
+// include reads, renders and expands a single included file, wrapped in
+// markers so the merged output can be traced back to its source.
+func (e *includeExpander) include(from string, lineNum int, target string, stack []string) ([]byte, error) {
+	path, err := e.resolve(target)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%d: %w", from, lineNum, err)
+	}
+	rel := e.m.relPath(path)
+
+	for i, entry := range stack {
+		if entry == rel {
+			cycle := append(append([]string{}, stack[i:]...), rel)
+			return nil, fmt.Errorf("%s:%d: include cycle: %s", from, lineNum, strings.Join(cycle, " -> "))
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s:%d: included file not found: %s", from, lineNum, rel)
+		}
+		return nil, fmt.Errorf("%s:%d: failed to read %s: %w", from, lineNum, rel, err)
+	}
+
+	if strings.HasSuffix(path, TemplateSuffix) {
+		content, err = renderTemplate(rel, content, e.data())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	content, err = e.expand(rel, content, stack)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "<!-- begin include: %s -->\n", rel)
+	out.Write(content)
+	if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+		out.WriteByte('\n')
+	}
+	fmt.Fprintf(&out, "<!-- end include: %s -->\n", rel)
+	return out.Bytes(), nil
+}
+
+// resolve turns an include target into a path inside the repository.
+func (e *includeExpander) resolve(target string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(target))
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("include path must be inside the repository: %s", target)
+	}
+	return filepath.Join(e.m.RepoDir, clean), nil
+}
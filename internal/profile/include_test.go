@@ -0,0 +1,108 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMergedCLAUDEmdIncludes(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+	profileDir := writeProfile(t, tmpDir, "golang", "")
+
+	snippetsDir := filepath.Join(tmpDir, "base", "snippets")
+	if err := os.MkdirAll(snippetsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile := func(path, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeClaude := func(content string) {
+		t.Helper()
+		writeFile(filepath.Join(profileDir, "CLAUDE.md"), content)
+	}
+
+	writeFile(filepath.Join(snippetsDir, "go-testing.md"), "## Go testing\n<!-- include: base/snippets/table-tests.md -->\n")
+	writeFile(filepath.Join(snippetsDir, "table-tests.md"), "Use table-driven tests.\n")
+
+	t.Run("nested includes with markers", func(t *testing.T) {
+		writeClaude("# Go\n<!-- include: base/snippets/go-testing.md -->\nDone.\n")
+
+		merged, err := mgr.MergedCLAUDEmd("golang")
+		if err != nil {
+			t.Fatalf("MergedCLAUDEmd() error = %v", err)
+		}
+
+		want := "# Go\n" +
+			"<!-- begin include: base/snippets/go-testing.md -->\n" +
+			"## Go testing\n" +
+			"<!-- begin include: base/snippets/table-tests.md -->\n" +
+			"Use table-driven tests.\n" +
+			"<!-- end include: base/snippets/table-tests.md -->\n" +
+			"<!-- end include: base/snippets/go-testing.md -->\n" +
+			"Done.\n"
+		if !strings.HasSuffix(string(merged), want) {
+			t.Errorf("merged CLAUDE.md =\n%s\nwant suffix\n%s", merged, want)
+		}
+	})
+
+	t.Run("directive in code fence is left alone", func(t *testing.T) {
+		writeClaude("```\n<!-- include: base/snippets/missing.md -->\n```\n")
+
+		merged, err := mgr.MergedCLAUDEmd("golang")
+		if err != nil {
+			t.Fatalf("MergedCLAUDEmd() error = %v", err)
+		}
+		if !strings.Contains(string(merged), "<!-- include: base/snippets/missing.md -->") {
+			t.Error("directive inside a code fence should be kept verbatim")
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		writeClaude("# Go\n\n<!-- include: base/snippets/missing.md -->\n")
+
+		_, err := mgr.MergedCLAUDEmd("golang")
+		if err == nil || !strings.Contains(err.Error(), "profiles/golang/CLAUDE.md:3: included file not found: base/snippets/missing.md") {
+			t.Errorf("MergedCLAUDEmd() error = %v", err)
+		}
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		writeFile(filepath.Join(snippetsDir, "a.md"), "<!-- include: base/snippets/b.md -->\n")
+		writeFile(filepath.Join(snippetsDir, "b.md"), "<!-- include: base/snippets/a.md -->\n")
+		writeClaude("<!-- include: base/snippets/a.md -->\n")
+
+		_, err := mgr.MergedCLAUDEmd("golang")
+		if err == nil || !strings.Contains(err.Error(), "include cycle: base/snippets/a.md -> base/snippets/b.md -> base/snippets/a.md") {
+			t.Errorf("MergedCLAUDEmd() error = %v", err)
+		}
+	})
+
+	t.Run("path outside repository", func(t *testing.T) {
+		writeClaude("<!-- include: ../secrets.md -->\n")
+
+		if _, err := mgr.MergedCLAUDEmd("golang"); err == nil {
+			t.Error("MergedCLAUDEmd() should reject includes outside the repository")
+		}
+	})
+
+	t.Run("template snippet", func(t *testing.T) {
+		writeFile(filepath.Join(snippetsDir, "whoami.md.tmpl"), "Profile: {{.Profile}}\n")
+		writeClaude("<!-- include: base/snippets/whoami.md.tmpl -->\n")
+
+		merged, err := mgr.MergedCLAUDEmd("golang")
+		if err != nil {
+			t.Fatalf("MergedCLAUDEmd() error = %v", err)
+		}
+		if !strings.Contains(string(merged), "Profile: golang") {
+			t.Errorf("template snippet should be rendered, got:\n%s", merged)
+		}
+	})
+}
@@ -0,0 +1,156 @@
+package profile
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// layer is a directory that contributes configuration (CLAUDE.md, settings.json,
+// agents, hooks) to an activation. Layers are applied lowest precedence first.
+type layer struct {
+	Name     string // "base" or a profile name
+	Dir      string
+	Manifest *Manifest // nil for base
+	// Via is the profile being activated (a member, for a stack) whose
+	// inheritance chain brought in this layer; empty for base.
+	Via string
+	// Host is the hosts/ directory name that matched this machine, for a
+	// host overlay; empty otherwise.
+	Host string
+}
+
+// optionalCLAUDEmd reports whether the layer may leave out CLAUDE.md: host
+// overlays and inherited profiles often only adjust settings or agents. Base
+// and the profiles being activated must have one.
+func (l layer) optionalCLAUDEmd() bool {
+	return l.Host != "" || (l.Via != "" && l.Name != l.Via)
+}
+
+// ResolveChain returns the inheritance chain for a profile, root ancestor first
+// and the profile itself last, by following each manifest's "extends". For a
+// stack it returns the chains of its profiles in order, each profile listed
+// once.
+func (m *Manager) ResolveChain(profileName string) ([]string, error) {
+	layers, err := m.profileLayers(profileName)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := make([]string, len(layers))
+	for i, l := range layers {
+		chain[i] = l.Name
+	}
+	return chain, nil
+}
+
+// profileLayers resolves the inheritance chain for a profile into layers,
+// root ancestor first. A stack's chains are concatenated in stack order. A
+// profile named in the stack is merged at its own position, even when an
+// earlier member extends it, so the order the user asked for wins; a
+// profile that several members only inherit from keeps its first position.
+func (m *Manager) profileLayers(profileName string) ([]layer, error) {
+	if !IsStack(profileName) {
+		return m.chainLayers(profileName)
+	}
+	if err := ValidateStackName(profileName); err != nil {
+		return nil, err
+	}
+
+	members := SplitStack(profileName)
+	position := make(map[string]int, len(members))
+	for i, member := range members {
+		position[member] = i
+	}
+
+	var stack []layer
+	seen := make(map[string]bool)
+	for i, member := range members {
+		chain, err := m.chainLayers(member)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range chain {
+			if j, ok := position[l.Name]; ok && j > i {
+				continue // Comes with its own member, later
+			}
+			if !seen[l.Name] {
+				seen[l.Name] = true
+				stack = append(stack, l)
+			}
+		}
+	}
+	return stack, nil
+}
+
+// chainLayers resolves the inheritance chain of a single profile into layers,
+// root ancestor first.
+func (m *Manager) chainLayers(profileName string) ([]layer, error) {
+	var chain []layer
+	var names []string
+	seen := make(map[string]bool)
+
+	for name := profileName; name != ""; {
+		if err := ValidateProfileName(name); err != nil {
+			return nil, err
+		}
+		if seen[name] {
+			cycle := append(append([]string{}, names...), name)
+			return nil, fmt.Errorf("profile inheritance cycle: %s", strings.Join(cycle, " -> "))
+		}
+		if !m.ProfileExists(name) {
+			if name == profileName {
+				return nil, fmt.Errorf("profile '%s' does not exist", name)
+			}
+			return nil, fmt.Errorf("profile '%s' extends '%s', which does not exist", names[len(names)-1], name)
+		}
+		seen[name] = true
+
+		dir := filepath.Join(m.ProfilesDir, name)
+		manifest, err := LoadManifest(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+		chain = append(chain, layer{Name: name, Dir: dir, Manifest: manifest, Via: profileName})
+		name = manifest.Extends
+	}
+
+	// Reverse so the root ancestor comes first
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain, nil
+}
+
+// layers returns the configuration layers for a profile or stack: base, then
+// each profile in its inheritance chain from the root ancestor down to the
+// profile. Each is followed by its host overlays that match this machine.
+func (m *Manager) layers(profileName string) ([]layer, error) {
+	chain, err := m.profileLayers(profileName)
+	if err != nil {
+		return nil, err
+	}
+
+	host := CurrentHost()
+	var layers []layer
+	for _, l := range append([]layer{{Name: "base", Dir: filepath.Join(m.RepoDir, "base")}}, chain...) {
+		overlays, err := m.hostLayers(l, host)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(append(layers, l), overlays...)
+	}
+	return layers, nil
+}
+
+// relPath returns path relative to the repository for display, or path itself
+// if it is outside the repository.
+func (m *Manager) relPath(path string) string {
+	rel, err := filepath.Rel(m.RepoDir, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
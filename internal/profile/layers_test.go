@@ -0,0 +1,191 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeProfile creates a profile with a CLAUDE.md and, if extends is set, a manifest
+func writeProfile(t *testing.T, repoDir, name, extends string) string {
+	t.Helper()
+
+	profileDir := filepath.Join(repoDir, "profiles", name)
+	if err := os.MkdirAll(profileDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(profileDir, "CLAUDE.md"), []byte("# "+name+" rules\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if extends != "" {
+		manifest := "extends: " + extends + "\n"
+		if err := os.WriteFile(filepath.Join(profileDir, "profile.yaml"), []byte(manifest), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return profileDir
+}
+
+func TestResolveChain(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+
+	writeProfile(t, tmpDir, "work", "")
+	writeProfile(t, tmpDir, "client-x", "work")
+	writeProfile(t, tmpDir, "loop-a", "loop-b")
+	writeProfile(t, tmpDir, "loop-b", "loop-a")
+	writeProfile(t, tmpDir, "orphan", "missing-parent")
+	writeProfile(t, tmpDir, "escape", "../outside")
+
+	t.Run("standalone profile", func(t *testing.T) {
+		chain, err := mgr.ResolveChain("work")
+		if err != nil {
+			t.Fatalf("ResolveChain() error = %v", err)
+		}
+		if !reflect.DeepEqual(chain, []string{"work"}) {
+			t.Errorf("ResolveChain() = %v, want [work]", chain)
+		}
+	})
+
+	t.Run("extends chain root first", func(t *testing.T) {
+		chain, err := mgr.ResolveChain("client-x")
+		if err != nil {
+			t.Fatalf("ResolveChain() error = %v", err)
+		}
+		if !reflect.DeepEqual(chain, []string{"work", "client-x"}) {
+			t.Errorf("ResolveChain() = %v, want [work client-x]", chain)
+		}
+	})
+
+	t.Run("cycle detected", func(t *testing.T) {
+		_, err := mgr.ResolveChain("loop-a")
+		if err == nil || !strings.Contains(err.Error(), "loop-a -> loop-b -> loop-a") {
+			t.Errorf("ResolveChain() error = %v, want inheritance cycle", err)
+		}
+	})
+
+	t.Run("missing parent", func(t *testing.T) {
+		_, err := mgr.ResolveChain("orphan")
+		if err == nil || !strings.Contains(err.Error(), "missing-parent") {
+			t.Errorf("ResolveChain() error = %v, want missing parent error", err)
+		}
+	})
+
+	t.Run("invalid parent name", func(t *testing.T) {
+		if _, err := mgr.ResolveChain("escape"); err == nil {
+			t.Error("ResolveChain() should reject path traversal in extends")
+		}
+	})
+}
+
+func TestActivateWithInheritance(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	claudeDir := filepath.Join(tmpDir, ".claude")
+	mgr := NewManager(tmpDir, claudeDir)
+
+	workDir := writeProfile(t, tmpDir, "work", "")
+	clientDir := writeProfile(t, tmpDir, "client-x", "work")
+
+	if err := os.WriteFile(filepath.Join(workDir, "settings.json"), []byte(`{"model": "sonnet", "team": "platform"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(clientDir, "settings.json"), []byte(`{"model": "opus"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeAgentDefinition(t, workDir, "work-reviewer", "Team reviewer")
+	writeHook(t, workDir, "session-start", "20-work.sh", 0755)
+
+	if err := mgr.Activate("client-x"); err != nil {
+		t.Fatalf("Activate() error = %v", err)
+	}
+
+	t.Run("CLAUDE.md merged through chain", func(t *testing.T) {
+		content, err := os.ReadFile(filepath.Join(claudeDir, "CLAUDE.md"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		merged := string(content)
+
+		base := strings.Index(merged, "# Base Config")
+		work := strings.Index(merged, "# work rules")
+		client := strings.Index(merged, "# client-x rules")
+		if base < 0 || work < 0 || client < 0 || !(base < work && work < client) {
+			t.Errorf("CLAUDE.md should contain base, work, client-x in order:\n%s", merged)
+		}
+		if !strings.Contains(merged, "# Profile: client-x\n") {
+			t.Error("CLAUDE.md should end with the activated profile's header")
+		}
+	})
+
+	t.Run("settings merged through chain", func(t *testing.T) {
+		content, err := os.ReadFile(filepath.Join(claudeDir, "settings.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{`"model": "opus"`, `"team": "platform"`, `"key": "value"`} {
+			if !strings.Contains(string(content), want) {
+				t.Errorf("settings.json missing %s:\n%s", want, content)
+			}
+		}
+	})
+
+	t.Run("ancestor agents and hooks deployed", func(t *testing.T) {
+		if _, err := os.Stat(filepath.Join(claudeDir, "agents", "work-reviewer.md")); err != nil {
+			t.Errorf("ancestor agent should be deployed: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(claudeDir, "hooks", "session-start", "20-work.sh")); err != nil {
+			t.Errorf("ancestor hook should be deployed: %v", err)
+		}
+	})
+
+	t.Run("active profile name", func(t *testing.T) {
+		if active := mgr.GetActiveProfileName(); active != "client-x" {
+			t.Errorf("active profile = %q, want client-x", active)
+		}
+	})
+}
+
+func TestActivateSettingsOnlyParent(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	claudeDir := filepath.Join(tmpDir, ".claude")
+	mgr := NewManager(tmpDir, claudeDir)
+
+	// A parent that only contributes settings and an agent
+	teamDir := filepath.Join(tmpDir, "profiles", "team")
+	if err := os.MkdirAll(teamDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(teamDir, "settings.json"), []byte(`{"team": "platform"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeAgentDefinition(t, teamDir, "team-reviewer", "Team reviewer")
+	writeProfile(t, tmpDir, "client-x", "team")
+
+	if err := mgr.Activate("client-x"); err != nil {
+		t.Fatalf("Activate() error = %v", err)
+	}
+
+	merged := readString(t, filepath.Join(claudeDir, "CLAUDE.md"))
+	if !strings.Contains(merged, "# client-x rules") || strings.Contains(merged, "# Profile: team") {
+		t.Errorf("CLAUDE.md should skip the parent without one:\n%s", merged)
+	}
+	if settings := readString(t, filepath.Join(claudeDir, "settings.json")); !strings.Contains(settings, `"team": "platform"`) {
+		t.Errorf("settings.json should include the parent's settings:\n%s", settings)
+	}
+	if _, err := os.Stat(filepath.Join(claudeDir, "agents", "team-reviewer.md")); err != nil {
+		t.Errorf("parent agent should be deployed: %v", err)
+	}
+
+	// The profile being activated still needs its own CLAUDE.md
+	if err := mgr.Activate("team"); err == nil {
+		t.Error("Activate() should fail for a profile without CLAUDE.md")
+	}
+}
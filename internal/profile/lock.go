@@ -0,0 +1,224 @@
+package profile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LockFileName is the advisory lock held by mutating operations, in ClaudeDir.
+const LockFileName = ".dotclaude.lock"
+
+// DefaultLockTimeout is how long an operation waits for another dotclaude
+// process to release the lock.
+const DefaultLockTimeout = 10 * time.Second
+
+// lockPollInterval is how often a waiting process retries the lock.
+const lockPollInterval = 100 * time.Millisecond
+
+// unreadableLockAge is how old a lock file with unparsable contents must be
+// before it is treated as stale (its writer may still be writing it).
+const unreadableLockAge = time.Minute
+
+// LockInfo describes the process holding the lock.
+type LockInfo struct {
+	PID      int       `json:"pid"`
+	Command  string    `json:"command"`
+	Hostname string    `json:"hostname"`
+	Acquired time.Time `json:"acquired"`
+}
+
+// LockError is returned when the lock is still held after the timeout.
+type LockError struct {
+	Path   string
+	Holder *LockInfo // nil if the lock file could not be read
+}
+
+func (e *LockError) Error() string {
+	if e.Holder == nil {
+		return fmt.Sprintf("another dotclaude process holds the lock %s", e.Path)
+	}
+	unlock := "dotclaude unlock"
+	if host, _ := hostname(); e.Holder.Hostname != host {
+		unlock = "dotclaude unlock --force" // Can't be checked from here
+	}
+	return fmt.Sprintf("another dotclaude process holds the lock: pid %d (%s) on %s since %s; if it is no longer running, run '%s'",
+		e.Holder.PID, e.Holder.Command, e.Holder.Hostname, e.Holder.Acquired.Format("2006-01-02 15:04:05"), unlock)
+}
+
+// lockPath returns the path of the lock file.
+func (m *Manager) lockPath() string {
+	return filepath.Join(m.ClaudeDir, LockFileName)
+}
+
+// lock acquires the advisory lock on ClaudeDir, waiting up to LockTimeout for
+// another process to release it. Locks left by processes that have exited are
+// removed. The returned function releases the lock.
+func (m *Manager) lock() (func(), error) {
+	if err := os.MkdirAll(m.ClaudeDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create Claude directory: %w", err)
+	}
+
+	path := m.lockPath()
+	deadline := time.Now().Add(m.LockTimeout)
+
+	for {
+		err := createLock(path)
+		if err == nil {
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create lock %s: %w", path, err)
+		}
+
+		holder, data, stale := inspectLock(path)
+		if stale {
+			// The holder is gone; take over its lock
+			if _, err := removeLock(path, data); err != nil {
+				return nil, fmt.Errorf("failed to remove stale lock %s: %w", path, err)
+			}
+			continue
+		}
+
+		if !time.Now().Before(deadline) {
+			return nil, &LockError{Path: path, Holder: holder}
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// ReadLock returns the current lock holder, or nil if the lock is free.
+func (m *Manager) ReadLock() (*LockInfo, error) {
+	data, err := os.ReadFile(m.lockPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var info LockInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("invalid lock file %s: %w", m.lockPath(), err)
+	}
+	return &info, nil
+}
+
+// Unlock forcibly removes the lock. It refuses while the holder is still
+// running on this machine unless force is set.
+func (m *Manager) Unlock(force bool) error {
+	path := m.lockPath()
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	holder, data, stale := inspectLock(path)
+	if !stale && !force && holder != nil {
+		if host, _ := hostname(); holder.Hostname != host {
+			return fmt.Errorf("lock is held by pid %d (%s) on %s; cannot check whether it is alive, use --force to remove it", holder.PID, holder.Command, holder.Hostname)
+		}
+		return fmt.Errorf("lock is held by running process %d (%s); use --force to remove it anyway", holder.PID, holder.Command)
+	}
+
+	removed, err := removeLock(path, data)
+	if err != nil {
+		return fmt.Errorf("failed to remove lock: %w", err)
+	}
+	if !removed {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return nil // Released meanwhile
+		}
+		return fmt.Errorf("lock %s was taken by another process meanwhile; run 'dotclaude unlock' again to check it", path)
+	}
+	return nil
+}
+
+// removeLock removes the lock file if it still holds data, the lock that was
+// inspected. The file is renamed aside first and checked there, so a lock
+// another process created since the inspection is never deleted: two
+// processes taking over the same stale lock can't both succeed. It reports
+// whether the inspected lock was removed; false means it was already gone
+// or had been replaced.
+func removeLock(path string, data []byte) (bool, error) {
+	aside := fmt.Sprintf("%s.stale-%d-%d", path, os.Getpid(), time.Now().UnixNano())
+	if err := os.Rename(path, aside); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil // Another process took it over first
+		}
+		return false, err
+	}
+
+	current, err := os.ReadFile(aside)
+	if err == nil && !bytes.Equal(current, data) {
+		// A live lock replaced the stale one; put it back unless yet
+		// another lock has been created in its place
+		if err := os.Link(aside, path); err != nil && !os.IsExist(err) {
+			return false, err
+		}
+		os.Remove(aside)
+		return false, nil
+	}
+	if err := os.Remove(aside); err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	return true, nil
+}
+
+// createLock atomically creates the lock file and records this process in it.
+func createLock(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+
+	host, _ := hostname()
+	info := LockInfo{
+		PID:      os.Getpid(),
+		Command:  commandLine(),
+		Hostname: host,
+		Acquired: time.Now(),
+	}
+	data, _ := json.Marshal(info)
+
+	_, werr := f.Write(append(data, '\n'))
+	cerr := f.Close()
+	if err := errors.Join(werr, cerr); err != nil {
+		os.Remove(path)
+		return err
+	}
+	return nil
+}
+
+// inspectLock reads the lock file and reports whether its holder is gone,
+// along with the contents it judged by. Only locks taken on this host can be
+// checked; others are never stale.
+func inspectLock(path string) (*LockInfo, []byte, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		// Released between our attempt and now; retry right away
+		return nil, nil, os.IsNotExist(err)
+	}
+
+	var info LockInfo
+	if err := json.Unmarshal(data, &info); err != nil || info.PID <= 0 {
+		fi, statErr := os.Stat(path)
+		return nil, data, statErr == nil && time.Since(fi.ModTime()) > unreadableLockAge
+	}
+
+	host, _ := hostname()
+	if info.Hostname != host {
+		return &info, data, false
+	}
+	return &info, data, !processAlive(info.PID)
+}
+
+// commandLine describes the current process for lock diagnostics.
+func commandLine() string {
+	args := append([]string{filepath.Base(os.Args[0])}, os.Args[1:]...)
+	return strings.Join(args, " ")
+}
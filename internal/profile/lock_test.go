@@ -0,0 +1,220 @@
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func writeLock(t *testing.T, mgr *Manager, info LockInfo) {
+	t.Helper()
+	if err := os.MkdirAll(mgr.ClaudeDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(info)
+	if err := os.WriteFile(mgr.lockPath(), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLock(t *testing.T) {
+	mgr := NewManager(t.TempDir(), t.TempDir())
+	mgr.LockTimeout = 200 * time.Millisecond
+	host, _ := hostname()
+
+	t.Run("acquire and release", func(t *testing.T) {
+		unlock, err := mgr.lock()
+		if err != nil {
+			t.Fatalf("lock() error = %v", err)
+		}
+
+		holder, err := mgr.ReadLock()
+		if err != nil || holder == nil {
+			t.Fatalf("ReadLock() = %v, %v", holder, err)
+		}
+		if holder.PID != os.Getpid() || holder.Command == "" {
+			t.Errorf("lock holder = %+v, want this process", holder)
+		}
+
+		unlock()
+		if _, err := os.Stat(mgr.lockPath()); !os.IsNotExist(err) {
+			t.Error("unlock should remove the lock file")
+		}
+	})
+
+	t.Run("held by running process", func(t *testing.T) {
+		writeLock(t, mgr, LockInfo{PID: os.Getpid(), Command: "dotclaude activate work", Hostname: host, Acquired: time.Now()})
+		defer os.Remove(mgr.lockPath())
+
+		start := time.Now()
+		_, err := mgr.lock()
+		var lockErr *LockError
+		if !errors.As(err, &lockErr) {
+			t.Fatalf("lock() error = %v, want *LockError", err)
+		}
+		if time.Since(start) < mgr.LockTimeout {
+			t.Error("lock() should wait for the timeout before failing")
+		}
+		msg := err.Error()
+		if !strings.Contains(msg, "pid "+strconv.Itoa(os.Getpid())) || !strings.Contains(msg, "dotclaude activate work") {
+			t.Errorf("error should name holder pid and command: %v", err)
+		}
+	})
+
+	t.Run("stale lock from exited process", func(t *testing.T) {
+		writeLock(t, mgr, LockInfo{PID: 1 << 30, Command: "dotclaude activate old", Hostname: host, Acquired: time.Now()})
+
+		unlock, err := mgr.lock()
+		if err != nil {
+			t.Fatalf("lock() should take over a stale lock: %v", err)
+		}
+		unlock()
+	})
+
+	t.Run("lock from another host is not stale", func(t *testing.T) {
+		writeLock(t, mgr, LockInfo{PID: 1 << 30, Command: "dotclaude activate", Hostname: host + "-elsewhere", Acquired: time.Now()})
+		defer os.Remove(mgr.lockPath())
+
+		if _, err := mgr.lock(); err == nil {
+			t.Error("lock() should not break a lock held on another host")
+		}
+	})
+}
+
+func TestLockStaleTakeover(t *testing.T) {
+	claudeDir := t.TempDir()
+	host, _ := hostname()
+
+	for round := 0; round < 3; round++ {
+		writeLock(t, NewManager(t.TempDir(), claudeDir), LockInfo{PID: 1 << 30, Command: "dotclaude activate old", Hostname: host, Acquired: time.Now()})
+
+		// Every waiter finds the same stale lock; only one may hold it at a time
+		var holders, maxHolders atomic.Int32
+		var wg sync.WaitGroup
+		errs := make(chan error, 8)
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				mgr := NewManager(t.TempDir(), claudeDir)
+				mgr.LockTimeout = 5 * time.Second
+				unlock, err := mgr.lock()
+				if err != nil {
+					errs <- err
+					return
+				}
+				n := holders.Add(1)
+				for {
+					max := maxHolders.Load()
+					if n <= max || maxHolders.CompareAndSwap(max, n) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				holders.Add(-1)
+				unlock()
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Fatalf("lock() error = %v", err)
+		}
+		if max := maxHolders.Load(); max != 1 {
+			t.Fatalf("round %d: %d processes held the lock at once", round, max)
+		}
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(claudeDir, LockFileName+".stale-*"))
+	if len(matches) != 0 {
+		t.Errorf("takeover left files behind: %v", matches)
+	}
+
+	t.Run("lock replaced after inspection", func(t *testing.T) {
+		mgr := NewManager(t.TempDir(), claudeDir)
+		writeLock(t, mgr, LockInfo{PID: 1 << 30, Command: "dotclaude activate old", Hostname: host, Acquired: time.Now()})
+		_, stale, ok := inspectLock(mgr.lockPath())
+		if !ok {
+			t.Fatal("inspectLock() should find the lock stale")
+		}
+
+		// Another waiter takes over first
+		if err := os.Remove(mgr.lockPath()); err != nil {
+			t.Fatal(err)
+		}
+		if err := createLock(mgr.lockPath()); err != nil {
+			t.Fatal(err)
+		}
+		fresh, err := os.ReadFile(mgr.lockPath())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		removed, err := removeLock(mgr.lockPath(), stale)
+		if err != nil || removed {
+			t.Errorf("removeLock() = %v, %v, want the fresh lock left alone", removed, err)
+		}
+		if got, err := os.ReadFile(mgr.lockPath()); err != nil || string(got) != string(fresh) {
+			t.Errorf("fresh lock = %q, %v, want it in place", got, err)
+		}
+		os.Remove(mgr.lockPath())
+	})
+}
+
+func TestUnlock(t *testing.T) {
+	mgr := NewManager(t.TempDir(), t.TempDir())
+	host, _ := hostname()
+
+	writeLock(t, mgr, LockInfo{PID: os.Getpid(), Command: "dotclaude activate", Hostname: host})
+	if err := mgr.Unlock(false); err == nil {
+		t.Error("Unlock() should refuse while the holder is running")
+	}
+	if err := mgr.Unlock(true); err != nil {
+		t.Errorf("Unlock(force) error = %v", err)
+	}
+	if holder, _ := mgr.ReadLock(); holder != nil {
+		t.Error("lock should be gone after Unlock(force)")
+	}
+
+	// Whether a holder on another host is alive can't be checked
+	writeLock(t, mgr, LockInfo{PID: 1 << 30, Command: "dotclaude activate", Hostname: host + "-other"})
+	err := mgr.Unlock(false)
+	if err == nil || !strings.Contains(err.Error(), "on "+host+"-other; cannot check whether it is alive, use --force") {
+		t.Errorf("Unlock() error = %v, want a lock held on another host", err)
+	}
+	if err := mgr.Unlock(true); err != nil {
+		t.Errorf("Unlock(force) error = %v", err)
+	}
+	if holder, _ := mgr.ReadLock(); holder != nil {
+		t.Error("lock should be gone after Unlock(force)")
+	}
+}
+
+func TestActivateWaitsForLock(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+	mgr.LockTimeout = 50 * time.Millisecond
+	writeProfile(t, tmpDir, "work", "")
+
+	unlock, err := mgr.lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.Activate("work"); err == nil {
+		t.Error("Activate() should fail while another process holds the lock")
+	}
+	unlock()
+
+	if err := mgr.Activate("work"); err != nil {
+		t.Errorf("Activate() error = %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package profile
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// CLAUDEmdMode is how activation writes CLAUDE.md.
+type CLAUDEmdMode string
+
+const (
+	// CLAUDEmdReplace writes the merged CLAUDE.md over the whole file.
+	CLAUDEmdReplace CLAUDEmdMode = "replace"
+	// CLAUDEmdManaged writes the merged CLAUDE.md into a block between
+	// markers and keeps everything outside the block.
+	CLAUDEmdManaged CLAUDEmdMode = "managed"
+)
+
+// ParseCLAUDEmdMode validates a CLAUDE.md mode name.
+func ParseCLAUDEmdMode(s string) (CLAUDEmdMode, error) {
+	switch mode := CLAUDEmdMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case CLAUDEmdReplace, CLAUDEmdManaged:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid CLAUDE.md mode %q (want managed or replace)", s)
+}
+
+// Markers around the managed block. The begin marker records the profile and
+// the hash of the block's content, so edits inside it can be recognized even
+// without the state document (see Drift).
+const (
+	managedBegin = "<!-- BEGIN dotclaude managed block:"
+	managedEnd   = "<!-- END dotclaude managed block -->"
+)
+
+// managedBeginLine matches the begin marker, capturing the hash.
+var managedBeginLine = regexp.MustCompile(`^<!-- BEGIN dotclaude managed block: profile=\S+ hash=(\S+)`)
+
+// managedBlock is a CLAUDE.md split around its managed block.
+type managedBlock struct {
+	Before string // Content above the begin marker
+	Body   string // Content between the markers
+	After  string // Content below the end marker
+	Hash   string // Hash recorded by the begin marker
+}
+
+// findManagedBlock splits content around its managed block. It reports false
+// if the content has no complete block.
+func findManagedBlock(content []byte) (*managedBlock, bool) {
+	text := string(content)
+	start := strings.Index(text, managedBegin)
+	if start < 0 || (start > 0 && text[start-1] != '\n') {
+		return nil, false
+	}
+	lineEnd := strings.IndexByte(text[start:], '\n')
+	if lineEnd < 0 {
+		return nil, false
+	}
+	bodyStart := start + lineEnd + 1
+
+	end := strings.Index(text[bodyStart:], managedEnd)
+	if end < 0 {
+		return nil, false
+	}
+	end += bodyStart
+	if end > bodyStart && text[end-1] != '\n' {
+		return nil, false
+	}
+	after := end + len(managedEnd)
+	if after < len(text) && text[after] == '\n' {
+		after++
+	}
+
+	block := &managedBlock{
+		Before: text[:start],
+		Body:   text[bodyStart:end],
+		After:  text[after:],
+	}
+	if m := managedBeginLine.FindStringSubmatch(text[start : bodyStart-1]); m != nil {
+		block.Hash = m[1]
+	}
+	return block, true
+}
+
+// renderManagedBlock returns body wrapped in the managed block markers.
+func renderManagedBlock(profileName string, body []byte) string {
+	content := string(body)
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return fmt.Sprintf("%s profile=%s hash=%s (edits inside this block are overwritten; add your own notes outside it) -->\n%s%s\n",
+		managedBegin, profileName, hashContent([]byte(content)), content, managedEnd)
+}
+
+// managedBlockHash returns the hash of the content of the managed block in
+// content, or "" if there is no block.
+func managedBlockHash(content []byte) string {
+	block, ok := findManagedBlock(content)
+	if !ok {
+		return ""
+	}
+	return hashContent([]byte(block.Body))
+}
+
+// withoutManagedBlock returns content with its managed block removed, and the
+// blank line that separated it from the rest.
+func withoutManagedBlock(content []byte) string {
+	block, ok := findManagedBlock(content)
+	if !ok {
+		return string(content)
+	}
+	if block.Before == "" {
+		return strings.TrimPrefix(block.After, "\n")
+	}
+	return block.Before + block.After
+}
+
+// managedCLAUDEmd returns the CLAUDE.md to write in managed mode: the merged
+// content in a managed block, with the user's content around it kept. A file
+// dotclaude wrote whole is replaced, bringing back whatever was there before
+// dotclaude first overwrote it. prev is the state being replaced.
+func (m *Manager) managedCLAUDEmd(path, profileName string, merged []byte, prev *State) ([]byte, error) {
+	block := renderManagedBlock(profileName, merged)
+
+	existing, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return []byte(block), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CLAUDE.md: %w", err)
+	}
+
+	if current, ok := findManagedBlock(existing); ok {
+		return []byte(current.Before + block + current.After), nil
+	}
+
+	// The whole file is dotclaude's when it was deployed in replace mode
+	user := string(existing)
+	if prev != nil && prev.CLAUDEmdMode() == CLAUDEmdReplace {
+		if _, deployed := prev.Files["CLAUDE.md"]; deployed || len(prev.Files) == 0 {
+			user = ""
+			if containsString(prev.Originals, "CLAUDE.md") {
+				original, err := os.ReadFile(m.originalPath("CLAUDE.md"))
+				if err != nil {
+					return nil, fmt.Errorf("failed to read original CLAUDE.md: %w", err)
+				}
+				user = string(original)
+			}
+		}
+	}
+
+	if strings.TrimSpace(user) == "" {
+		return []byte(block), nil
+	}
+	return []byte(block + "\n" + user), nil
+}
@@ -0,0 +1,251 @@
+package profile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseCLAUDEmdMode(t *testing.T) {
+	for _, s := range []string{"managed", "replace", " Managed "} {
+		if _, err := ParseCLAUDEmdMode(s); err != nil {
+			t.Errorf("ParseCLAUDEmdMode(%q) error = %v", s, err)
+		}
+	}
+	if _, err := ParseCLAUDEmdMode("block"); err == nil {
+		t.Error("ParseCLAUDEmdMode(block) should fail")
+	}
+}
+
+func TestFindManagedBlock(t *testing.T) {
+	block := renderManagedBlock("work", []byte("# Rules"))
+	content := "# Mine\n\n" + block + "\n# Notes\n"
+
+	found, ok := findManagedBlock([]byte(content))
+	if !ok {
+		t.Fatalf("findManagedBlock() found no block in:\n%s", content)
+	}
+	if found.Before != "# Mine\n\n" || found.Body != "# Rules\n" || found.After != "\n# Notes\n" {
+		t.Errorf("findManagedBlock() = %+v", found)
+	}
+	if found.Hash != hashContent([]byte("# Rules\n")) {
+		t.Errorf("marker hash = %q", found.Hash)
+	}
+
+	if got := withoutManagedBlock([]byte(content)); got != "# Mine\n\n\n# Notes\n" {
+		t.Errorf("withoutManagedBlock() = %q", got)
+	}
+	if got := withoutManagedBlock([]byte(block + "\n# Notes\n")); got != "# Notes\n" {
+		t.Errorf("withoutManagedBlock() = %q", got)
+	}
+
+	for _, broken := range []string{
+		"# No block\n",
+		strings.TrimSuffix(block, managedEnd+"\n"),
+		"text " + block,
+	} {
+		if _, ok := findManagedBlock([]byte(broken)); ok {
+			t.Errorf("findManagedBlock() should not find a block in:\n%s", broken)
+		}
+	}
+}
+
+func TestActivateManagedBlock(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	claudeDir := filepath.Join(tmpDir, ".claude")
+	claudeMD := filepath.Join(claudeDir, "CLAUDE.md")
+	mgr := NewManager(tmpDir, claudeDir)
+	writeProfile(t, tmpDir, "work", "")
+	writeProfile(t, tmpDir, "personal", "")
+
+	read := func() string {
+		t.Helper()
+		data, err := os.ReadFile(claudeMD)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(claudeMD, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("# My own rules\n")
+	if err := mgr.ActivateWithOptions("work", ActivateOptions{CLAUDEmd: CLAUDEmdManaged}); err != nil {
+		t.Fatalf("ActivateWithOptions() error = %v", err)
+	}
+
+	t.Run("existing content kept below the block", func(t *testing.T) {
+		content := read()
+		if !strings.HasPrefix(content, managedBegin+" profile=work hash=sha256:") {
+			t.Errorf("CLAUDE.md should start with the managed block:\n%s", content)
+		}
+		if !strings.HasSuffix(content, managedEnd+"\n\n# My own rules\n") {
+			t.Errorf("CLAUDE.md should keep the user's content below the block:\n%s", content)
+		}
+		if !strings.Contains(content, "# work rules") {
+			t.Errorf("CLAUDE.md should contain the profile:\n%s", content)
+		}
+
+		state, err := mgr.LoadState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.CLAUDEmdMode() != CLAUDEmdManaged {
+			t.Errorf("state mode = %q, want managed", state.CLAUDEmd)
+		}
+		if containsString(state.Originals, "CLAUDE.md") {
+			t.Error("a managed CLAUDE.md should not be saved as an original")
+		}
+	})
+
+	t.Run("notes outside the block survive a switch", func(t *testing.T) {
+		write(read() + "- remember the milk\n")
+
+		drift, err := mgr.modifiedFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(drift) != 0 {
+			t.Errorf("edits outside the block should not be drift: %v", drift)
+		}
+
+		if err := mgr.Activate("personal"); err != nil {
+			t.Fatalf("Activate() error = %v", err)
+		}
+		content := read()
+		if !strings.Contains(content, "profile=personal") || !strings.Contains(content, "# personal rules") || strings.Contains(content, "# work rules") {
+			t.Errorf("managed block should hold the new profile:\n%s", content)
+		}
+		if !strings.HasSuffix(content, "# My own rules\n- remember the milk\n") {
+			t.Errorf("notes should be kept:\n%s", content)
+		}
+	})
+
+	t.Run("edits inside the block are drift", func(t *testing.T) {
+		saved := read()
+		write(strings.Replace(saved, "# personal rules", "# personal rules\n- hand edit", 1))
+
+		drift, err := mgr.modifiedFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(drift) != 1 || drift[0].Path != "CLAUDE.md" {
+			t.Errorf("modifiedFiles() = %v, want CLAUDE.md", drift)
+		}
+		var driftErr *DriftError
+		if err := mgr.Activate("work"); !errors.As(err, &driftErr) {
+			t.Errorf("Activate() error = %v, want *DriftError", err)
+		}
+
+		// Removing the markers counts too
+		write("# My own rules\n")
+		if drift, _ := mgr.modifiedFiles(); len(drift) != 1 {
+			t.Errorf("modifiedFiles() = %v, want CLAUDE.md without its block", drift)
+		}
+		write(saved)
+	})
+
+	t.Run("marker hash detects edits without state", func(t *testing.T) {
+		saved := read()
+		if err := mgr.Deactivate(DeactivateOptions{KeepFiles: true}); err != nil {
+			t.Fatal(err)
+		}
+		if drift, err := mgr.modifiedFiles(); err != nil || len(drift) != 0 {
+			t.Errorf("modifiedFiles() = %v, %v, want an intact block", drift, err)
+		}
+
+		write(strings.Replace(saved, "# personal rules", "# personal rules\n- hand edit", 1))
+		drift, err := mgr.modifiedFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(drift) != 1 || drift[0].Path != "CLAUDE.md" {
+			t.Errorf("modifiedFiles() = %v, want CLAUDE.md from its marker hash", drift)
+		}
+		var driftErr *DriftError
+		if err := mgr.Activate("personal"); !errors.As(err, &driftErr) {
+			t.Errorf("Activate() error = %v, want *DriftError", err)
+		}
+
+		// The mode went with the state
+		write(saved)
+		if err := mgr.ActivateWithOptions("personal", ActivateOptions{CLAUDEmd: CLAUDEmdManaged}); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("deactivate removes only the block", func(t *testing.T) {
+		if err := mgr.Deactivate(DeactivateOptions{}); err != nil {
+			t.Fatalf("Deactivate() error = %v", err)
+		}
+		if got := read(); got != "# My own rules\n- remember the milk\n" {
+			t.Errorf("CLAUDE.md after deactivate = %q", got)
+		}
+	})
+}
+
+func TestSwitchCLAUDEmdMode(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	claudeDir := filepath.Join(tmpDir, ".claude")
+	claudeMD := filepath.Join(claudeDir, "CLAUDE.md")
+	mgr := NewManager(tmpDir, claudeDir)
+	writeProfile(t, tmpDir, "work", "")
+
+	if err := os.WriteFile(claudeMD, []byte("# Before dotclaude\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.Activate("work"); err != nil {
+		t.Fatalf("Activate() error = %v", err)
+	}
+
+	// Replace mode saved the original; managed mode puts it back outside the block
+	if err := mgr.ActivateWithOptions("work", ActivateOptions{CLAUDEmd: CLAUDEmdManaged}); err != nil {
+		t.Fatalf("ActivateWithOptions(managed) error = %v", err)
+	}
+	data, err := os.ReadFile(claudeMD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(data), managedEnd+"\n\n# Before dotclaude\n") {
+		t.Errorf("CLAUDE.md should have the original below the block:\n%s", data)
+	}
+	if _, err := os.Stat(mgr.originalPath("CLAUDE.md")); !os.IsNotExist(err) {
+		t.Errorf("saved original should be dropped in managed mode: %v", err)
+	}
+
+	// Back to replace mode: the content outside the block becomes the original
+	if err := mgr.ActivateWithOptions("work", ActivateOptions{CLAUDEmd: CLAUDEmdReplace}); err != nil {
+		t.Fatalf("ActivateWithOptions(replace) error = %v", err)
+	}
+	data, err = os.ReadFile(claudeMD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), managedBegin) || strings.Contains(string(data), "# Before dotclaude") {
+		t.Errorf("replace mode should write the whole file:\n%s", data)
+	}
+	if err := mgr.Deactivate(DeactivateOptions{}); err != nil {
+		t.Fatalf("Deactivate() error = %v", err)
+	}
+	data, err = os.ReadFile(claudeMD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "# Before dotclaude\n" {
+		t.Errorf("CLAUDE.md after deactivate = %q, want the original", data)
+	}
+
+	if err := mgr.ActivateWithOptions("work", ActivateOptions{CLAUDEmd: "block"}); err == nil {
+		t.Error("ActivateWithOptions() should reject an unknown mode")
+	}
+}
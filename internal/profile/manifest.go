@@ -0,0 +1,153 @@
+package profile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFiles are the supported names for a profile's metadata file, in
+// lookup order. A profile may have at most one of them.
+var ManifestFiles = []string{"profile.yaml", "profile.yml", "profile.json"}
+
+// Manifest holds a profile's metadata.
+type Manifest struct {
+	// Name is the profile name; informational, the directory name is authoritative.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	// Description is a one-line summary shown by list and show.
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	// Owner is the person or team responsible for the profile.
+	Owner string `yaml:"owner,omitempty" json:"owner,omitempty"`
+	// Tags are free-form labels for grouping profiles.
+	Tags []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+	// Extends names the parent profile whose configuration this profile builds on.
+	Extends string `yaml:"extends,omitempty" json:"extends,omitempty"`
+	// MinVersion is the oldest dotclaude version that can activate the profile.
+	MinVersion string `yaml:"min_version,omitempty" json:"min_version,omitempty"`
+	// Vars are values exposed to CLAUDE.md templates as .Vars.
+	Vars map[string]string `yaml:"vars,omitempty" json:"vars,omitempty"`
+	// Env lists environment variables exposed to CLAUDE.md templates as .Env.
+	Env []string `yaml:"env,omitempty" json:"env,omitempty"`
+	// Agents lists the agents (by name) this profile contributes from its
+	// agents/ directory. When empty, every agent in the directory is deployed.
+	Agents []string `yaml:"agents,omitempty" json:"agents,omitempty"`
+	// Hooks lists the hooks ("<type>/<file>") this profile contributes from its
+	// hooks/ directory. When empty, every hook in the directory is deployed.
+	Hooks []string `yaml:"hooks,omitempty" json:"hooks,omitempty"`
+
+	// path is the file the manifest was read from, empty if there is none.
+	path string
+}
+
+// Path returns the file the manifest was loaded from, or "" if the profile has none.
+func (mf *Manifest) Path() string {
+	return mf.path
+}
+
+// LoadManifest reads the manifest from a profile directory.
+// A profile without a manifest gets an empty one.
+func LoadManifest(profileDir string) (*Manifest, error) {
+	var found []string
+	for _, name := range ManifestFiles {
+		if _, err := os.Stat(filepath.Join(profileDir, name)); err == nil {
+			found = append(found, name)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return &Manifest{}, nil
+	case 1:
+	default:
+		return nil, fmt.Errorf("multiple manifests in %s: %s (keep only one)", profileDir, strings.Join(found, ", "))
+	}
+
+	path := filepath.Join(profileDir, found[0])
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var manifest Manifest
+	if filepath.Ext(path) == ".json" {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err = dec.Decode(&manifest); err == nil && dec.More() {
+			err = errors.New("unexpected data after the manifest object")
+		}
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err = dec.Decode(&manifest); errors.Is(err, io.EOF) {
+			err = nil // empty file
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+
+	if manifest.Extends != "" {
+		if err := ValidateProfileName(manifest.Extends); err != nil {
+			return nil, fmt.Errorf("invalid extends in %s: %w", path, err)
+		}
+	}
+
+	manifest.path = path
+	return &manifest, nil
+}
+
+// SaveManifest writes the manifest into a profile directory, replacing the
+// file it was loaded from or creating profile.yaml.
+func SaveManifest(profileDir string, manifest *Manifest) error {
+	path := manifest.path
+	if path == "" {
+		path = filepath.Join(profileDir, ManifestFiles[0])
+	}
+
+	var data []byte
+	var err error
+	if filepath.Ext(path) == ".json" {
+		data, err = json.MarshalIndent(manifest, "", "  ")
+		data = append(data, '\n')
+	} else {
+		data, err = yaml.Marshal(manifest)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	manifest.path = path
+	return nil
+}
+
+// contributesAgent reports whether the manifest allows deploying the named agent.
+func (mf *Manifest) contributesAgent(name string) bool {
+	return len(mf.Agents) == 0 || containsString(mf.Agents, name)
+}
+
+// contributesHook reports whether the manifest allows deploying the hook at
+// relPath ("<type>/<file>").
+func (mf *Manifest) contributesHook(relPath string) bool {
+	return len(mf.Hooks) == 0 || containsString(mf.Hooks, relPath)
+}
+
+// containsString reports whether list contains s.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadManifest(t *testing.T) {
+	write := func(t *testing.T, dir, name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("missing manifest", func(t *testing.T) {
+		manifest, err := LoadManifest(t.TempDir())
+		if err != nil {
+			t.Fatalf("LoadManifest() error = %v", err)
+		}
+		if manifest.Extends != "" || manifest.Path() != "" {
+			t.Errorf("LoadManifest() = %+v, want empty manifest", manifest)
+		}
+	})
+
+	t.Run("yaml manifest", func(t *testing.T) {
+		dir := t.TempDir()
+		write(t, dir, "profile.yaml", `description: Go services at work
+owner: platform-team
+tags: [go, work]
+extends: work
+min_version: 1.2.0
+agents: [reviewer]
+hooks: [session-start/10-check.sh]
+`)
+		manifest, err := LoadManifest(dir)
+		if err != nil {
+			t.Fatalf("LoadManifest() error = %v", err)
+		}
+
+		want := &Manifest{
+			Description: "Go services at work",
+			Owner:       "platform-team",
+			Tags:        []string{"go", "work"},
+			Extends:     "work",
+			MinVersion:  "1.2.0",
+			Agents:      []string{"reviewer"},
+			Hooks:       []string{"session-start/10-check.sh"},
+			path:        filepath.Join(dir, "profile.yaml"),
+		}
+		if !reflect.DeepEqual(manifest, want) {
+			t.Errorf("LoadManifest() = %+v, want %+v", manifest, want)
+		}
+	})
+
+	t.Run("json manifest", func(t *testing.T) {
+		dir := t.TempDir()
+		write(t, dir, "profile.json", `{"extends": "work", "tags": ["go"]}`)
+		manifest, err := LoadManifest(dir)
+		if err != nil {
+			t.Fatalf("LoadManifest() error = %v", err)
+		}
+		if manifest.Extends != "work" || len(manifest.Tags) != 1 {
+			t.Errorf("LoadManifest() = %+v", manifest)
+		}
+	})
+
+	t.Run("empty yaml manifest", func(t *testing.T) {
+		dir := t.TempDir()
+		write(t, dir, "profile.yml", "")
+		if _, err := LoadManifest(dir); err != nil {
+			t.Errorf("LoadManifest() error = %v", err)
+		}
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		dir := t.TempDir()
+		write(t, dir, "profile.yaml", "extend: work\n")
+		if _, err := LoadManifest(dir); err == nil {
+			t.Error("LoadManifest() should reject unknown fields")
+		}
+
+		dir = t.TempDir()
+		write(t, dir, "profile.json", `{"extend": "work"}`)
+		if _, err := LoadManifest(dir); err == nil {
+			t.Error("LoadManifest() should reject unknown fields in JSON")
+		}
+	})
+
+	t.Run("invalid manifest", func(t *testing.T) {
+		dir := t.TempDir()
+		write(t, dir, "profile.json", `{extends`)
+		if _, err := LoadManifest(dir); err == nil {
+			t.Error("LoadManifest() should error on invalid JSON")
+		}
+	})
+
+	t.Run("invalid extends", func(t *testing.T) {
+		dir := t.TempDir()
+		write(t, dir, "profile.yaml", "extends: ../work\n")
+		if _, err := LoadManifest(dir); err == nil {
+			t.Error("LoadManifest() should reject an invalid parent name")
+		}
+	})
+
+	t.Run("multiple manifests", func(t *testing.T) {
+		dir := t.TempDir()
+		write(t, dir, "profile.yaml", "extends: work\n")
+		write(t, dir, "profile.json", `{"extends": "work"}`)
+		_, err := LoadManifest(dir)
+		if err == nil || !strings.Contains(err.Error(), "multiple manifests") {
+			t.Errorf("LoadManifest() error = %v, want multiple manifests error", err)
+		}
+	})
+}
+
+func TestSaveManifest(t *testing.T) {
+	t.Run("creates profile.yaml", func(t *testing.T) {
+		dir := t.TempDir()
+		manifest := &Manifest{Description: "Test profile", Tags: []string{"a", "b"}}
+		if err := SaveManifest(dir, manifest); err != nil {
+			t.Fatalf("SaveManifest() error = %v", err)
+		}
+		if manifest.Path() != filepath.Join(dir, "profile.yaml") {
+			t.Errorf("Path() = %q, want profile.yaml", manifest.Path())
+		}
+
+		loaded, err := LoadManifest(dir)
+		if err != nil {
+			t.Fatalf("LoadManifest() error = %v", err)
+		}
+		if !reflect.DeepEqual(loaded, manifest) {
+			t.Errorf("round trip = %+v, want %+v", loaded, manifest)
+		}
+	})
+
+	t.Run("keeps existing json file", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "profile.json"), []byte(`{}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		manifest, err := LoadManifest(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		manifest.Owner = "me"
+		if err := SaveManifest(dir, manifest); err != nil {
+			t.Fatalf("SaveManifest() error = %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "profile.yaml")); !os.IsNotExist(err) {
+			t.Error("SaveManifest() should not create profile.yaml next to profile.json")
+		}
+
+		loaded, err := LoadManifest(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loaded.Owner != "me" {
+			t.Errorf("Owner = %q, want %q", loaded.Owner, "me")
+		}
+	})
+}
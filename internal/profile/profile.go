@@ -6,7 +6,6 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
-	"strings"
 	"time"
 )
 
@@ -16,6 +15,7 @@ type Profile struct {
 	Path         string
 	IsActive     bool
 	LastModified time.Time
+	Manifest     *Manifest
 }
 
 // Manager handles profile operations.
@@ -24,6 +24,18 @@ type Manager struct {
 	ProfilesDir string
 	ClaudeDir   string
 	StateFile   string
+	// ProjectDir is the project whose .claude directory ClaudeDir is, for
+	// project-scoped activation; empty for the global Claude directory.
+	ProjectDir string
+	// Version is the running dotclaude version, checked against each
+	// profile's min_version on activation. Empty skips the check.
+	Version string
+	// LockTimeout is how long mutating operations wait for another dotclaude
+	// process to release the lock on ClaudeDir.
+	LockTimeout time.Duration
+	// SecretsFile is the local file ${secret:NAME} references in settings
+	// are read from.
+	SecretsFile string
 }
 
 // NewManager creates a new profile manager.
@@ -32,7 +44,9 @@ func NewManager(repoDir, claudeDir string) *Manager {
 		RepoDir:     repoDir,
 		ProfilesDir: filepath.Join(repoDir, "profiles"),
 		ClaudeDir:   claudeDir,
-		StateFile:   filepath.Join(claudeDir, ".current-profile"),
+		StateFile:   filepath.Join(claudeDir, StateFileName),
+		LockTimeout: DefaultLockTimeout,
+		SecretsFile: filepath.Join(claudeDir, SecretsFileName),
 	}
 }
 
@@ -53,8 +67,8 @@ func (m *Manager) ListProfiles() ([]*Profile, error) {
 		return nil, fmt.Errorf("failed to read profiles directory: %w", err)
 	}
 
-	// Get active profile name
-	activeProfile := m.GetActiveProfileName()
+	// Get active profile name; every profile of an active stack is active
+	active := SplitStack(m.GetActiveProfileName())
 
 	profiles := make([]*Profile, 0, len(entries))
 	for _, entry := range entries {
@@ -70,11 +84,18 @@ func (m *Manager) ListProfiles() ([]*Profile, error) {
 			continue
 		}
 
+		// A broken manifest shouldn't hide the profile from listings
+		manifest, err := LoadManifest(profilePath)
+		if err != nil {
+			manifest = &Manifest{}
+		}
+
 		profiles = append(profiles, &Profile{
 			Name:         entry.Name(),
 			Path:         profilePath,
-			IsActive:     entry.Name() == activeProfile,
+			IsActive:     containsString(active, entry.Name()),
 			LastModified: info.ModTime(),
+			Manifest:     manifest,
 		})
 	}
 
@@ -86,22 +107,30 @@ func (m *Manager) ListProfiles() ([]*Profile, error) {
 	return profiles, nil
 }
 
-// GetActiveProfileName returns the name of the currently active profile.
+// GetActiveProfileName returns the name of the currently active profile, or
+// "" if there is none or the state can't be read.
 func (m *Manager) GetActiveProfileName() string {
-	data, err := os.ReadFile(m.StateFile)
-	if err != nil {
+	state, err := m.LoadState()
+	if err != nil || state == nil {
 		return ""
 	}
-	return strings.TrimSpace(string(data))
+	return state.Profile
 }
 
-// GetActiveProfile returns the currently active profile, or nil if none.
+// GetActiveProfile returns the currently active profile, or nil if none. For
+// an active stack it returns the last profile of the stack, which takes
+// precedence over the others.
 func (m *Manager) GetActiveProfile() (*Profile, error) {
 	name := m.GetActiveProfileName()
 	if name == "" {
 		return nil, nil
 	}
+	stack := SplitStack(name)
+	return m.GetProfile(stack[len(stack)-1])
+}
 
+// GetProfile returns the named profile, or nil if it doesn't exist.
+func (m *Manager) GetProfile(name string) (*Profile, error) {
 	profilePath := filepath.Join(m.ProfilesDir, name)
 	info, err := os.Stat(profilePath)
 	if err != nil {
@@ -111,11 +140,17 @@ func (m *Manager) GetActiveProfile() (*Profile, error) {
 		return nil, err
 	}
 
+	manifest, err := LoadManifest(profilePath)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Profile{
 		Name:         name,
 		Path:         profilePath,
-		IsActive:     true,
+		IsActive:     m.InActiveStack(name),
 		LastModified: info.ModTime(),
+		Manifest:     manifest,
 	}, nil
 }
 
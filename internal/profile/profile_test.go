@@ -65,7 +65,7 @@ func TestNewManager(t *testing.T) {
 		t.Errorf("ProfilesDir = %q, want %q", mgr.ProfilesDir, expectedProfilesDir)
 	}
 
-	expectedStateFile := filepath.Join(claudeDir, ".current-profile")
+	expectedStateFile := filepath.Join(claudeDir, StateFileName)
 	if mgr.StateFile != expectedStateFile {
 		t.Errorf("StateFile = %q, want %q", mgr.StateFile, expectedStateFile)
 	}
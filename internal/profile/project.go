@@ -0,0 +1,256 @@
+package profile
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ProjectClaudeDir is the directory, inside a project, that project-scoped
+// activation deploys into.
+const ProjectClaudeDir = ".claude"
+
+// Markers around the patterns dotclaude maintains in .git/info/exclude.
+const (
+	excludeBegin = "# BEGIN dotclaude project activation (managed, do not edit)"
+	excludeEnd   = "# END dotclaude project activation"
+)
+
+// NewProjectManager creates a profile manager that activates profiles into
+// projectDir/.claude instead of the global Claude directory. Project scope
+// deploys CLAUDE.md, settings.json and agents; hooks stay global. Callers
+// point SecretsFile at the global one.
+func NewProjectManager(repoDir, projectDir string) *Manager {
+	m := NewManager(repoDir, filepath.Join(projectDir, ProjectClaudeDir))
+	m.ProjectDir = projectDir
+	return m
+}
+
+// IsProject reports whether the manager targets a project's .claude directory.
+func (m *Manager) IsProject() bool {
+	return m.ProjectDir != ""
+}
+
+// ProjectRoot returns the root of the project containing dir: the top level
+// of its git work tree, or dir itself outside of git.
+func ProjectRoot(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", abs)
+	}
+
+	if top, _ := gitPaths(abs); top != "" {
+		return top, nil
+	}
+	return abs, nil
+}
+
+// gitPaths returns the top level of the git work tree containing dir and the
+// path of its info/exclude file, or empty strings outside of git.
+// Overridable for tests.
+var gitPaths = func(dir string) (top, exclude string) {
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel", "--git-path", "info/exclude")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", ""
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != 2 {
+		return "", ""
+	}
+	top, exclude = filepath.FromSlash(lines[0]), filepath.FromSlash(lines[1])
+	if !filepath.IsAbs(exclude) {
+		exclude = filepath.Join(dir, exclude)
+	}
+	return top, exclude
+}
+
+// TrackedFilesError is returned when project-scoped activation would
+// overwrite files git tracks: .git/info/exclude doesn't hide changes to them,
+// and the profile's content, resolved secrets included, would show up in git
+// diff.
+type TrackedFilesError struct {
+	Files []string // Relative to the work tree, slash-separated
+}
+
+func (e *TrackedFilesError) Error() string {
+	return fmt.Sprintf("refusing to overwrite files tracked by git: %s (changes to them would show up in git diff, resolved secrets included; use --force to overwrite)", strings.Join(e.Files, ", "))
+}
+
+// checkTracked fails with a *TrackedFilesError if the transaction would
+// change files git tracks in the project. Nothing is checked outside of git.
+func (m *Manager) checkTracked(tx *transaction) error {
+	top, _ := gitPaths(m.ProjectDir)
+	if top == "" {
+		return nil
+	}
+
+	var paths []string
+	for path := range tx.hashes {
+		if rel, err := filepath.Rel(m.ClaudeDir, path); err == nil && !isBookkeeping(rel) {
+			paths = append(paths, path)
+		}
+	}
+	tracked, err := gitTracked(top, paths)
+	if err != nil {
+		return err
+	}
+
+	var changed []string
+	for _, path := range tracked {
+		// Content that already matches changes nothing
+		if data, err := os.ReadFile(path); err == nil && hashContent(data) == tx.hashes[path] {
+			continue
+		}
+		rel, err := filepath.Rel(top, path)
+		if err != nil {
+			return err
+		}
+		changed = append(changed, filepath.ToSlash(rel))
+	}
+	if len(changed) > 0 {
+		sort.Strings(changed)
+		return &TrackedFilesError{Files: changed}
+	}
+	return nil
+}
+
+// gitTracked returns the paths git tracks in the work tree at top.
+func gitTracked(top string, paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	byRel := make(map[string]string, len(paths))
+	args := []string{"ls-files", "-z", "--full-name", "--"}
+	for _, path := range paths {
+		rel, err := filepath.Rel(top, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue // Outside the work tree
+		}
+		byRel[filepath.ToSlash(rel)] = path
+		args = append(args, ":(literal)"+filepath.ToSlash(rel))
+	}
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = top
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check files tracked by git: %w", err)
+	}
+	var tracked []string
+	for _, rel := range strings.Split(string(out), "\x00") {
+		if path, ok := byRel[rel]; ok {
+			tracked = append(tracked, path)
+		}
+	}
+	return tracked, nil
+}
+
+// excludeDeployed lists the deployed files and dotclaude's bookkeeping in the
+// project's .git/info/exclude, so they don't show up as untracked files.
+// Nothing happens outside of git.
+func (m *Manager) excludeDeployed() error {
+	state, err := m.LoadState()
+	if err != nil {
+		return err
+	}
+	var files []string
+	if state != nil {
+		for rel := range state.Files {
+			files = append(files, rel)
+		}
+	}
+	return m.updateGitExclude(files)
+}
+
+// updateGitExclude replaces dotclaude's block in .git/info/exclude with
+// patterns for files (relative to ClaudeDir). With no files the block is
+// removed.
+func (m *Manager) updateGitExclude(files []string) error {
+	top, excludePath := gitPaths(m.ProjectDir)
+	if top == "" {
+		return nil
+	}
+	dir, err := filepath.Rel(top, m.ClaudeDir)
+	if err != nil || strings.HasPrefix(dir, "..") {
+		return nil // ClaudeDir is outside the work tree
+	}
+	prefix := "/" + escapeExcludePattern(filepath.ToSlash(dir)) + "/"
+
+	data, err := os.ReadFile(excludePath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", excludePath, err)
+	}
+	content := removeExcludeBlock(string(data))
+
+	if len(files) > 0 {
+		sort.Strings(files)
+		var block strings.Builder
+		block.WriteString(excludeBegin + "\n")
+		for _, rel := range files {
+			block.WriteString(prefix + escapeExcludePattern(rel) + "\n")
+		}
+		// State, snapshots (.dotclaude-backups), history, lock and staging
+		block.WriteString(prefix + "**/.dotclaude*\n")
+		block.WriteString(excludeEnd + "\n")
+
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		content += block.String()
+	}
+
+	if content == string(data) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(excludePath), 0755); err != nil {
+		return fmt.Errorf("failed to update %s: %w", excludePath, err)
+	}
+	if err := os.WriteFile(excludePath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to update %s: %w", excludePath, err)
+	}
+	return nil
+}
+
+// removeExcludeBlock returns content without dotclaude's block.
+func removeExcludeBlock(content string) string {
+	start := strings.Index(content, excludeBegin)
+	if start < 0 {
+		return content
+	}
+	end := strings.Index(content[start:], excludeEnd)
+	if end < 0 {
+		return content[:start]
+	}
+	end += start + len(excludeEnd)
+	if end < len(content) && content[end] == '\n' {
+		end++
+	}
+	return content[:start] + content[end:]
+}
+
+// escapeExcludePattern escapes characters gitignore patterns treat specially.
+func escapeExcludePattern(path string) string {
+	var b strings.Builder
+	for i, r := range path {
+		switch {
+		case strings.ContainsRune(`*?[\`, r):
+			b.WriteByte('\\')
+		case i == 0 && (r == '#' || r == '!'):
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
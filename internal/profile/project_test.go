@@ -0,0 +1,191 @@
+package profile
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newGitProject creates a git repository for project-scope tests.
+func newGitProject(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	cmd := exec.Command("git", "init", "-q")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	// Resolve symlinks (e.g. /tmp on macOS) to match git's toplevel
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resolved
+}
+
+func TestProjectActivation(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+	writeProfile(t, tmpDir, "client", "")
+	writeHook(t, filepath.Join(tmpDir, "base"), "session-start", "10-env.sh", 0755)
+	writeAgentDefinition(t, filepath.Join(tmpDir, "profiles", "client"), "reviewer", "Reviews code")
+
+	project := newGitProject(t)
+	excludePath := filepath.Join(project, ".git", "info", "exclude")
+	if err := os.WriteFile(excludePath, []byte("*.log\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sub := filepath.Join(project, "src")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	root, err := ProjectRoot(sub)
+	if err != nil || root != project {
+		t.Fatalf("ProjectRoot() = %q, %v, want %q", root, err, project)
+	}
+
+	mgr := NewProjectManager(tmpDir, root)
+	if err := mgr.Activate("client"); err != nil {
+		t.Fatalf("Activate() error = %v", err)
+	}
+
+	claudeDir := filepath.Join(project, ".claude")
+	for _, rel := range []string{"CLAUDE.md", "settings.json", "agents/reviewer.md", StateFileName} {
+		if _, err := os.Stat(filepath.Join(claudeDir, rel)); err != nil {
+			t.Errorf("%s should be deployed to the project: %v", rel, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(claudeDir, "hooks")); !os.IsNotExist(err) {
+		t.Error("hooks should not be deployed in project scope")
+	}
+	if got := mgr.GetActiveProfileName(); got != "client" {
+		t.Errorf("project active profile = %q, want %q", got, "client")
+	}
+
+	exclude := readString(t, excludePath)
+	for _, want := range []string{"*.log\n", "/.claude/CLAUDE.md\n", "/.claude/agents/reviewer.md\n", "/.claude/**/.dotclaude*\n"} {
+		if !strings.Contains(exclude, want) {
+			t.Errorf("exclude should contain %q, got:\n%s", want, exclude)
+		}
+	}
+	if strings.Contains(exclude, ".backup.") {
+		t.Errorf("exclude should not list loose backup files, got:\n%s", exclude)
+	}
+
+	// Nothing dotclaude wrote shows up as untracked
+	cmd := exec.Command("git", "status", "--porcelain", "--untracked-files=all")
+	cmd.Dir = project
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(strings.TrimSpace(string(out))) != 0 {
+		t.Errorf("git status should be clean, got:\n%s", out)
+	}
+
+	// Reactivating rewrites the block instead of appending another
+	if err := mgr.Activate("client"); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(readString(t, excludePath), excludeBegin); n != 1 {
+		t.Errorf("exclude has %d dotclaude blocks, want 1", n)
+	}
+
+	if err := mgr.Deactivate(DeactivateOptions{}); err != nil {
+		t.Fatalf("Deactivate() error = %v", err)
+	}
+	if got := readString(t, excludePath); got != "*.log\n" {
+		t.Errorf("exclude after deactivate = %q, want the user's patterns only", got)
+	}
+}
+
+func TestProjectActivationTrackedFiles(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+	writeProfile(t, tmpDir, "client", "")
+
+	// The team's settings.json is committed
+	project := newGitProject(t)
+	settingsPath := filepath.Join(project, ".claude", "settings.json")
+	if err := os.MkdirAll(filepath.Dir(settingsPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	committed := `{"model": "team"}` + "\n"
+	if err := os.WriteFile(settingsPath, []byte(committed), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"add", ".claude/settings.json"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "settings"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = project
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v: %s", args[0], err, out)
+		}
+	}
+
+	mgr := NewProjectManager(tmpDir, project)
+	err := mgr.Activate("client")
+	var trackedErr *TrackedFilesError
+	if !errors.As(err, &trackedErr) {
+		t.Fatalf("Activate() error = %v, want *TrackedFilesError", err)
+	}
+	if len(trackedErr.Files) != 1 || trackedErr.Files[0] != ".claude/settings.json" {
+		t.Errorf("tracked files = %v, want [.claude/settings.json]", trackedErr.Files)
+	}
+	if got := readString(t, settingsPath); got != committed {
+		t.Errorf("tracked settings.json was changed to %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".claude", "CLAUDE.md")); !os.IsNotExist(err) {
+		t.Error("a refused activation should not write anything")
+	}
+	if got := mgr.GetActiveProfileName(); got != "" {
+		t.Errorf("active profile = %q after a refused activation", got)
+	}
+
+	if err := mgr.ActivateWithOptions("client", ActivateOptions{Force: true}); err != nil {
+		t.Fatalf("ActivateWithOptions(Force) error = %v", err)
+	}
+	if got := readString(t, settingsPath); got == committed {
+		t.Error("--force should overwrite the tracked settings.json")
+	}
+}
+
+func TestProjectRootOutsideGit(t *testing.T) {
+	dir := t.TempDir()
+	root, err := ProjectRoot(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	abs, _ := filepath.Abs(dir)
+	if root != abs {
+		t.Errorf("ProjectRoot() = %q, want %q", root, abs)
+	}
+
+	if _, err := ProjectRoot(filepath.Join(dir, "missing")); err == nil {
+		t.Error("ProjectRoot() should fail for a missing directory")
+	}
+}
+
+func TestEscapeExcludePattern(t *testing.T) {
+	tests := map[string]string{
+		"agents/reviewer.md": "agents/reviewer.md",
+		"agents/a*b?.md":     `agents/a\*b\?.md`,
+		"#notes.md":          `\#notes.md`,
+		"!important.md":      `\!important.md`,
+	}
+	for in, want := range tests {
+		if got := escapeExcludePattern(in); got != want {
+			t.Errorf("escapeExcludePattern(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -1,173 +1,212 @@
 package profile
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"sort"
-	"strings"
 	"time"
 )
 
-// Backup represents a backup file.
-type Backup struct {
-	Path      string
-	Filename  string
-	Timestamp string
-	Size      int64
-	Type      string // "CLAUDE.md" or "settings.json"
-}
-
-// ListBackups returns all available backup files sorted by modification time (newest first).
+// ListBackups returns all available snapshots, newest first. Legacy backup
+// files are imported as snapshots first.
 func (m *Manager) ListBackups() ([]*Backup, error) {
-	// Find CLAUDE.md backups
-	claudePattern := filepath.Join(m.ClaudeDir, "CLAUDE.md.backup.*")
-	claudeMatches, err := filepath.Glob(claudePattern)
+	legacy, err := m.findLegacyBackups()
 	if err != nil {
-		return nil, fmt.Errorf("failed to find CLAUDE.md backups: %w", err)
-	}
-
-	// Find settings.json backups
-	settingsPattern := filepath.Join(m.ClaudeDir, "settings.json.backup.*")
-	settingsMatches, err := filepath.Glob(settingsPattern)
-	if err != nil {
-		return nil, fmt.Errorf("failed to find settings.json backups: %w", err)
+		return nil, err
 	}
-
-	var backups []*Backup
-
-	// Process CLAUDE.md backups
-	for _, path := range claudeMatches {
-		backup, err := m.parseBackup(path, "CLAUDE.md")
+	if len(legacy) > 0 {
+		// Serialize with other dotclaude processes
+		unlock, err := m.lock()
 		if err != nil {
-			continue // Skip invalid backups
+			return nil, err
 		}
-		backups = append(backups, backup)
-	}
-
-	// Process settings.json backups
-	for _, path := range settingsMatches {
-		backup, err := m.parseBackup(path, "settings.json")
+		err = m.importLegacyBackups()
+		unlock()
 		if err != nil {
-			continue // Skip invalid backups
+			return nil, err
 		}
-		backups = append(backups, backup)
 	}
 
-	// Sort by modification time (newest first)
-	sort.Slice(backups, func(i, j int) bool {
-		infoI, errI := os.Stat(backups[i].Path)
-		infoJ, errJ := os.Stat(backups[j].Path)
-		if errI != nil || errJ != nil {
-			return false
-		}
-		return infoI.ModTime().After(infoJ.ModTime())
-	})
+	return m.loadBackups()
+}
 
-	return backups, nil
+// BackupFilter selects snapshots. Zero fields match every snapshot.
+type BackupFilter struct {
+	// ID matches one snapshot.
+	ID string
+	// Profile matches snapshots taken while this profile was active.
+	Profile string
+	// Before matches snapshots taken before this time.
+	Before time.Time
 }
 
-// parseBackup extracts backup information from a backup file path.
-func (m *Manager) parseBackup(path, backupType string) (*Backup, error) {
-	info, err := os.Stat(path)
+// FindBackup returns the newest snapshot matching a filter.
+func (m *Manager) FindBackup(filter BackupFilter) (*Backup, error) {
+	backups, err := m.ListBackups()
 	if err != nil {
 		return nil, err
 	}
-
-	filename := filepath.Base(path)
-
-	// Extract timestamp from filename
-	// Format: CLAUDE.md.backup.20251210-155544
-	var timestamp string
-	if backupType == "CLAUDE.md" {
-		timestamp = strings.TrimPrefix(filename, "CLAUDE.md.backup.")
-	} else {
-		timestamp = strings.TrimPrefix(filename, "settings.json.backup.")
+	if filter.ID != "" {
+		// Validates the ID, and reports a snapshot it can't read
+		backup, err := m.loadBackup(filter.ID)
+		if err != nil {
+			return nil, err
+		}
+		backups = []*Backup{backup}
 	}
 
-	return &Backup{
-		Path:      path,
-		Filename:  filename,
-		Timestamp: timestamp,
-		Size:      info.Size(),
-		Type:      backupType,
-	}, nil
-}
+	for _, backup := range backups {
+		if filter.Profile != "" && backup.Profile != filter.Profile {
+			continue
+		}
+		if !filter.Before.IsZero() && !backup.Time.Before(filter.Before) {
+			continue
+		}
+		return backup, nil
+	}
 
-// Restore restores a backup file to its original location.
-// It creates a backup of the current file before restoring.
-func (m *Manager) Restore(backupPath string) error {
-	// Validate backup exists
-	if _, err := os.Stat(backupPath); os.IsNotExist(err) {
-		return fmt.Errorf("backup file not found: %s", backupPath)
+	if len(backups) == 0 {
+		return nil, fmt.Errorf("no backups found")
 	}
+	desc := "no backup"
+	if filter.ID != "" {
+		desc += " " + filter.ID
+	}
+	if filter.Profile != "" {
+		desc += " of profile " + filter.Profile
+	}
+	if !filter.Before.IsZero() {
+		desc += " taken before " + filter.Before.Local().Format("2006-01-02 15:04:05")
+	}
+	return nil, fmt.Errorf("%s", desc)
+}
 
-	// Determine target file based on backup filename
-	filename := filepath.Base(backupPath)
-	var targetPath string
+// RestoreChange is a file restoring a snapshot would change.
+type RestoreChange struct {
+	// Path is relative to ClaudeDir and slash-separated.
+	Path string
+	// Current is the file's content now; nil if it doesn't exist.
+	Current []byte
+	// Backup is the snapshot's content; nil if restoring removes the file.
+	Backup []byte
+}
 
-	if strings.HasPrefix(filename, "CLAUDE.md.backup.") {
-		targetPath = filepath.Join(m.ClaudeDir, "CLAUDE.md")
-	} else if strings.HasPrefix(filename, "settings.json.backup.") {
-		targetPath = filepath.Join(m.ClaudeDir, "settings.json")
-	} else {
-		return fmt.Errorf("invalid backup filename: %s", filename)
+// RestoreChanges returns the files restoring a snapshot would write or
+// remove, sorted by path. Files that already match are left out.
+func (m *Manager) RestoreChanges(backup *Backup) ([]RestoreChange, error) {
+	current, err := m.managedFiles()
+	if err != nil {
+		return nil, err
 	}
 
-	// Create backup of current file before restoring
-	if _, err := os.Stat(targetPath); err == nil {
-		timestamp := time.Now().Format("20060102-150405")
-		currentBackup := targetPath + ".backup." + timestamp
-
-		data, err := os.ReadFile(targetPath)
+	var changes []RestoreChange
+	for _, f := range backup.Files {
+		saved, err := m.ReadBackupFile(backup, f.Path)
 		if err != nil {
-			return fmt.Errorf("failed to read current file: %w", err)
+			return nil, err
 		}
-
-		if err := os.WriteFile(currentBackup, data, 0644); err != nil {
-			return fmt.Errorf("failed to backup current file: %w", err)
+		change := RestoreChange{Path: f.Path, Backup: saved}
+		data, err := os.ReadFile(filepath.Join(m.ClaudeDir, filepath.FromSlash(f.Path)))
+		if err == nil {
+			if bytes.Equal(data, saved) {
+				continue
+			}
+			change.Current = data
+		} else if !os.IsNotExist(err) {
+			return nil, err
 		}
+		changes = append(changes, change)
 	}
+	if !backup.Partial {
+		for _, rel := range current {
+			if backup.File(rel) != nil {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(m.ClaudeDir, filepath.FromSlash(rel)))
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, RestoreChange{Path: rel, Current: data})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
 
-	// Restore the backup
-	data, err := os.ReadFile(backupPath)
+// Restore puts back every file saved in a snapshot, and removes managed
+// files the snapshot doesn't have, so the configuration and the activation
+// record are exactly as they were. A partial snapshot only restores its own
+// files and keeps the active profile. The current configuration is
+// snapshotted first.
+func (m *Manager) Restore(id string) error {
+	// Serialize with other dotclaude processes
+	unlock, err := m.lock()
 	if err != nil {
-		return fmt.Errorf("failed to read backup: %w", err)
+		return err
 	}
+	defer unlock()
 
-	if err := os.WriteFile(targetPath, data, 0644); err != nil {
-		return fmt.Errorf("failed to restore backup: %w", err)
+	if err := m.importLegacyBackups(); err != nil {
+		return err
+	}
+	backup, err := m.loadBackup(id)
+	if err != nil {
+		return err
 	}
 
-	// If restoring CLAUDE.md, try to update .current-profile marker
-	if strings.HasPrefix(filename, "CLAUDE.md.backup.") {
-		if err := m.updateProfileFromCLAUDE(targetPath); err != nil {
-			// Log but don't fail on this error
-			fmt.Fprintf(os.Stderr, "warning: could not update active profile: %v\n", err)
+	// Read everything before the new snapshot can prune this one
+	contents := make(map[string][]byte, len(backup.Files))
+	for _, f := range backup.Files {
+		data, err := m.ReadBackupFile(backup, f.Path)
+		if err != nil {
+			return err
 		}
+		contents[f.Path] = data
+	}
+	current, err := m.managedFiles()
+	if err != nil {
+		return err
 	}
 
-	return nil
-}
+	active := m.GetActiveProfileName()
+	if _, err := m.snapshot("before restoring " + backup.ID); err != nil {
+		return fmt.Errorf("failed to back up current configuration: %w", err)
+	}
 
-// updateProfileFromCLAUDE attempts to extract the profile name from CLAUDE.md content
-// and update the .current-profile marker.
-func (m *Manager) updateProfileFromCLAUDE(claudePath string) error {
-	data, err := os.ReadFile(claudePath)
+	tx, err := m.begin()
 	if err != nil {
 		return err
 	}
+	for _, f := range backup.Files {
+		// Keep each file's permissions (settings with secrets are 0600)
+		if err := tx.WriteFile(filepath.Join(m.ClaudeDir, filepath.FromSlash(f.Path)), contents[f.Path], f.Mode); err != nil {
+			tx.Abort()
+			return err
+		}
+	}
+	if !backup.Partial {
+		for _, rel := range current {
+			if backup.File(rel) == nil {
+				tx.Remove(filepath.Join(m.ClaudeDir, filepath.FromSlash(rel)))
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to restore backup (configuration left unchanged): %w", err)
+	}
 
-	// Look for "# Profile: <name>" pattern
-	re := regexp.MustCompile(`(?m)^# Profile: ([^\s]+)`)
-	matches := re.FindStringSubmatch(string(data))
-
-	if len(matches) > 1 {
-		profileName := matches[1]
-		stateFile := filepath.Join(m.ClaudeDir, ".current-profile")
-		return os.WriteFile(stateFile, []byte(profileName), 0644)
+	// A restored file is dotclaude's doing, not drift
+	if backup.Partial {
+		for _, f := range backup.Files {
+			if err := m.updateDeployedHash(filepath.Join(m.ClaudeDir, f.Path), contents[f.Path]); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: could not update deployed file record: %v\n", err)
+			}
+		}
 	}
 
+	m.logHistory(HistoryEntry{Action: HistoryRestore, From: active, To: m.GetActiveProfileName(), Target: backup.ID})
 	return nil
 }
@@ -32,304 +32,269 @@ func TestListBackups(t *testing.T) {
 		}
 	})
 
-	t.Run("with CLAUDE.md backups", func(t *testing.T) {
-		// Create some backup files
-		timestamps := []string{"20251201-120000", "20251202-130000", "20251203-140000"}
-		for _, ts := range timestamps {
-			backupPath := filepath.Join(claudeDir, "CLAUDE.md.backup."+ts)
-			if err := os.WriteFile(backupPath, []byte("backup content"), 0644); err != nil {
+	t.Run("legacy backup files imported", func(t *testing.T) {
+		legacy := map[string]string{
+			"CLAUDE.md.backup.20251201-120000":     "# first",
+			"settings.json.backup.20251201-120000": `{"first": true}`,
+			"CLAUDE.md.backup.20251203-140000":     "# second",
+		}
+		for name, content := range legacy {
+			if err := os.WriteFile(filepath.Join(claudeDir, name), []byte(content), 0644); err != nil {
 				t.Fatal(err)
 			}
-			// Sleep to ensure different mod times
-			time.Sleep(10 * time.Millisecond)
 		}
 
 		backups, err := mgr.ListBackups()
 		if err != nil {
 			t.Fatalf("ListBackups() error = %v", err)
 		}
-
-		if len(backups) != 3 {
-			t.Errorf("ListBackups() returned %d backups, want 3", len(backups))
+		if len(backups) != 2 {
+			t.Fatalf("ListBackups() returned %d backups, want 2 (one per timestamp)", len(backups))
 		}
 
-		// All should be CLAUDE.md type
-		for _, b := range backups {
-			if b.Type != "CLAUDE.md" {
-				t.Errorf("Backup type = %q, want %q", b.Type, "CLAUDE.md")
-			}
+		newest, oldest := backups[0], backups[1]
+		if newest.ID != "20251203-140000" || oldest.ID != "20251201-120000" {
+			t.Errorf("IDs = %s, %s, want newest first", newest.ID, oldest.ID)
 		}
-	})
-
-	t.Run("with settings.json backups", func(t *testing.T) {
-		// Create settings backup
-		backupPath := filepath.Join(claudeDir, "settings.json.backup.20251201-100000")
-		if err := os.WriteFile(backupPath, []byte(`{}`), 0644); err != nil {
-			t.Fatal(err)
-		}
-
-		backups, err := mgr.ListBackups()
-		if err != nil {
-			t.Fatalf("ListBackups() error = %v", err)
+		if !oldest.Partial || len(oldest.Files) != 2 {
+			t.Errorf("backup from one switch should pair CLAUDE.md and settings.json: %+v", oldest)
 		}
-
-		// Should now have 4 backups total
-		if len(backups) != 4 {
-			t.Errorf("ListBackups() returned %d backups, want 4", len(backups))
+		data, err := mgr.ReadBackupFile(oldest, "settings.json")
+		if err != nil || string(data) != `{"first": true}` {
+			t.Errorf("ReadBackupFile() = %q, %v", data, err)
 		}
 
-		// Check we have both types
-		hasSettings := false
-		hasClaude := false
-		for _, b := range backups {
-			if b.Type == "settings.json" {
-				hasSettings = true
-			}
-			if b.Type == "CLAUDE.md" {
-				hasClaude = true
+		for name := range legacy {
+			if _, err := os.Stat(filepath.Join(claudeDir, name)); !os.IsNotExist(err) {
+				t.Errorf("%s should be removed once imported", name)
 			}
 		}
-
-		if !hasSettings {
-			t.Error("Should have settings.json backup")
-		}
-		if !hasClaude {
-			t.Error("Should have CLAUDE.md backup")
-		}
 	})
 }
 
 func TestRestore(t *testing.T) {
-	tmpDir, err := os.MkdirTemp("", "dotclaude-test-*")
-	if err != nil {
-		t.Fatal(err)
-	}
-	defer os.RemoveAll(tmpDir)
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
 
 	claudeDir := filepath.Join(tmpDir, ".claude")
-	if err := os.MkdirAll(claudeDir, 0755); err != nil {
+	mgr := NewManager(tmpDir, claudeDir)
+
+	workDir := writeProfile(t, tmpDir, "work", "")
+	writeAgentDefinition(t, workDir, "reviewer", "Work reviewer")
+	if err := os.WriteFile(filepath.Join(workDir, "settings.json"), []byte(`{"team": "platform"}`), 0644); err != nil {
 		t.Fatal(err)
 	}
+	personalDir := writeProfile(t, tmpDir, "personal", "")
+	writeAgentDefinition(t, personalDir, "writer", "Personal writer")
 
-	mgr := NewManager(tmpDir, claudeDir)
-
-	t.Run("restore CLAUDE.md backup", func(t *testing.T) {
-		// Create current CLAUDE.md
-		currentPath := filepath.Join(claudeDir, "CLAUDE.md")
-		if err := os.WriteFile(currentPath, []byte("current content"), 0644); err != nil {
-			t.Fatal(err)
-		}
+	if err := mgr.Activate("work"); err != nil {
+		t.Fatal(err)
+	}
+	workCLAUDEmd := readString(t, filepath.Join(claudeDir, "CLAUDE.md"))
+	workSettings := readString(t, filepath.Join(claudeDir, "settings.json"))
+	if err := mgr.Activate("personal"); err != nil {
+		t.Fatal(err)
+	}
 
-		// Create backup
-		backupPath := filepath.Join(claudeDir, "CLAUDE.md.backup.20251201-120000")
-		backupContent := "# Profile: old-profile\n\nbackup content"
-		if err := os.WriteFile(backupPath, []byte(backupContent), 0644); err != nil {
-			t.Fatal(err)
+	backups, err := mgr.ListBackups()
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("ListBackups() = %v, %v, want the backup taken by the switch", backups, err)
+	}
+	backup := backups[0]
+	if backup.Profile != "work" || backup.Reason != "before activating personal" || backup.Partial {
+		t.Errorf("backup = %+v", backup)
+	}
+	for _, rel := range []string{"CLAUDE.md", "settings.json", "agents/reviewer.md", "agents/" + managedManifestFile, StateFileName} {
+		if backup.File(rel) == nil {
+			t.Errorf("backup should hold %s", rel)
 		}
+	}
 
-		// Wait a moment
-		time.Sleep(10 * time.Millisecond)
-
-		// Restore
-		err := mgr.Restore(backupPath)
-		if err != nil {
+	t.Run("restores the whole snapshot", func(t *testing.T) {
+		if err := mgr.Restore(backup.ID); err != nil {
 			t.Fatalf("Restore() error = %v", err)
 		}
 
-		// Verify restored content
-		restored, err := os.ReadFile(currentPath)
-		if err != nil {
-			t.Fatalf("Failed to read restored file: %v", err)
+		if got := readString(t, filepath.Join(claudeDir, "CLAUDE.md")); got != workCLAUDEmd {
+			t.Errorf("CLAUDE.md = %q, want work's", got)
 		}
-
-		if string(restored) != backupContent {
-			t.Errorf("Restored content = %q, want %q", string(restored), backupContent)
+		if got := readString(t, filepath.Join(claudeDir, "settings.json")); got != workSettings {
+			t.Errorf("settings.json = %q, want work's", got)
 		}
-
-		// Verify a new backup was created of the "current content"
-		backups, _ := filepath.Glob(filepath.Join(claudeDir, "CLAUDE.md.backup.*"))
-		if len(backups) < 2 {
-			t.Error("Should have created backup of current file before restoring")
+		if _, err := os.Stat(filepath.Join(claudeDir, "agents", "reviewer.md")); err != nil {
+			t.Errorf("work's agent should be back: %v", err)
 		}
-	})
-
-	t.Run("restore settings.json backup", func(t *testing.T) {
-		// Create current settings.json
-		currentPath := filepath.Join(claudeDir, "settings.json")
-		if err := os.WriteFile(currentPath, []byte(`{"current": true}`), 0644); err != nil {
-			t.Fatal(err)
+		if _, err := os.Stat(filepath.Join(claudeDir, "agents", "writer.md")); !os.IsNotExist(err) {
+			t.Error("personal's agent should be removed")
 		}
 
-		// Create backup
-		backupPath := filepath.Join(claudeDir, "settings.json.backup.20251201-120000")
-		backupContent := `{"old": true}`
-		if err := os.WriteFile(backupPath, []byte(backupContent), 0644); err != nil {
-			t.Fatal(err)
+		if got := mgr.GetActiveProfileName(); got != "work" {
+			t.Errorf("active profile = %q, want the snapshot's", got)
 		}
-
-		// Restore
-		err := mgr.Restore(backupPath)
+		modified, err := mgr.modifiedFiles()
 		if err != nil {
-			t.Fatalf("Restore() error = %v", err)
+			t.Fatal(err)
+		}
+		if len(modified) != 0 {
+			t.Errorf("restored files should not be reported as drift, got %v", modified)
 		}
+	})
 
-		// Verify restored content
-		restored, err := os.ReadFile(currentPath)
+	t.Run("current configuration backed up first", func(t *testing.T) {
+		backups, err := mgr.ListBackups()
 		if err != nil {
-			t.Fatalf("Failed to read restored file: %v", err)
+			t.Fatal(err)
 		}
-
-		if string(restored) != backupContent {
-			t.Errorf("Restored content = %q, want %q", string(restored), backupContent)
+		if len(backups) != 2 || backups[0].Reason != "before restoring "+backup.ID || backups[0].Profile != "personal" {
+			t.Fatalf("ListBackups() = %+v, want a backup of personal first", backups)
 		}
-	})
-
-	t.Run("restore non-existent backup", func(t *testing.T) {
-		err := mgr.Restore("/non/existent/backup")
-		if err == nil {
-			t.Error("Restore() should error for non-existent backup")
+		if backups[0].File("agents/writer.md") == nil {
+			t.Error("backup before restoring should hold personal's agent")
 		}
 	})
 
-	t.Run("restore invalid backup filename", func(t *testing.T) {
-		// Create file with invalid backup name
-		invalidPath := filepath.Join(claudeDir, "invalid.backup.20251201")
-		if err := os.WriteFile(invalidPath, []byte("data"), 0644); err != nil {
-			t.Fatal(err)
-		}
-
-		err := mgr.Restore(invalidPath)
-		if err == nil {
-			t.Error("Restore() should error for invalid backup filename")
+	t.Run("unknown backup", func(t *testing.T) {
+		for _, id := range []string{"20200101-000000", "../state", ""} {
+			if err := mgr.Restore(id); err == nil {
+				t.Errorf("Restore(%q) should fail", id)
+			}
 		}
 	})
 }
 
-func TestUpdateProfileFromCLAUDE(t *testing.T) {
-	tmpDir, err := os.MkdirTemp("", "dotclaude-test-*")
-	if err != nil {
+func TestRestoreKeepsState(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+	writeProfile(t, tmpDir, "work", "")
+	if err := mgr.Activate("work"); err != nil {
 		t.Fatal(err)
 	}
-	defer os.RemoveAll(tmpDir)
 
-	claudeDir := filepath.Join(tmpDir, ".claude")
-	if err := os.MkdirAll(claudeDir, 0755); err != nil {
+	// A partial backup whose content names another profile must not change
+	// the active profile
+	backupPath := filepath.Join(mgr.ClaudeDir, "CLAUDE.md.backup.20250101-120000")
+	content := "# Base content\n\n# Profile: other\n"
+	if err := os.WriteFile(backupPath, []byte(content), 0644); err != nil {
 		t.Fatal(err)
 	}
+	if err := mgr.Restore("20250101-120000"); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
 
-	mgr := NewManager(tmpDir, claudeDir)
-
-	t.Run("update profile from CLAUDE.md", func(t *testing.T) {
-		// Create CLAUDE.md with profile header
-		claudePath := filepath.Join(claudeDir, "CLAUDE.md")
-		content := "# Base content\n\n# Profile: extracted-profile\n\nProfile content"
-		if err := os.WriteFile(claudePath, []byte(content), 0644); err != nil {
-			t.Fatal(err)
-		}
-
-		err := mgr.updateProfileFromCLAUDE(claudePath)
-		if err != nil {
-			t.Fatalf("updateProfileFromCLAUDE() error = %v", err)
-		}
-
-		// Verify state file was updated
-		active := mgr.GetActiveProfileName()
-		if active != "extracted-profile" {
-			t.Errorf("Active profile = %q, want %q", active, "extracted-profile")
-		}
-	})
-
-	t.Run("no profile header", func(t *testing.T) {
-		// Clear state file
-		os.Remove(filepath.Join(claudeDir, ".current-profile"))
-
-		// Create CLAUDE.md without profile header
-		claudePath := filepath.Join(claudeDir, "CLAUDE-no-profile.md")
-		content := "# Base content only\n\nNo profile marker"
-		if err := os.WriteFile(claudePath, []byte(content), 0644); err != nil {
-			t.Fatal(err)
-		}
-
-		err := mgr.updateProfileFromCLAUDE(claudePath)
-		if err != nil {
-			t.Fatalf("updateProfileFromCLAUDE() should not error: %v", err)
-		}
-
-		// State file should not have been created
-		active := mgr.GetActiveProfileName()
-		if active != "" {
-			t.Errorf("Active profile = %q, want empty", active)
-		}
-	})
-}
-
-func TestParseBackup(t *testing.T) {
-	tmpDir, err := os.MkdirTemp("", "dotclaude-test-*")
+	if got := readString(t, filepath.Join(mgr.ClaudeDir, "CLAUDE.md")); !strings.Contains(got, "Profile: other") {
+		t.Errorf("CLAUDE.md = %q, want the backup's", got)
+	}
+	if _, err := os.Stat(filepath.Join(mgr.ClaudeDir, "settings.json")); err != nil {
+		t.Errorf("a partial restore should leave other files alone: %v", err)
+	}
+	if got := mgr.GetActiveProfileName(); got != "work" {
+		t.Errorf("active profile = %q, want %q", got, "work")
+	}
+	modified, err := mgr.modifiedFiles()
 	if err != nil {
 		t.Fatal(err)
 	}
-	defer os.RemoveAll(tmpDir)
-
-	claudeDir := filepath.Join(tmpDir, ".claude")
-	if err := os.MkdirAll(claudeDir, 0755); err != nil {
-		t.Fatal(err)
+	if len(modified) != 0 {
+		t.Errorf("restored file should not be reported as drift, got %v", modified)
 	}
+}
 
-	mgr := NewManager(tmpDir, claudeDir)
+func TestFindBackup(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
 
-	t.Run("parse CLAUDE.md backup", func(t *testing.T) {
-		// Create backup file
-		backupPath := filepath.Join(claudeDir, "CLAUDE.md.backup.20251201-153045")
-		if err := os.WriteFile(backupPath, []byte("test"), 0644); err != nil {
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+	for _, name := range []string{"work", "personal", "oss"} {
+		writeProfile(t, tmpDir, name, "")
+		if err := mgr.Activate(name); err != nil {
 			t.Fatal(err)
 		}
+	}
+	backups, err := mgr.ListBackups()
+	if err != nil || len(backups) != 2 {
+		t.Fatalf("ListBackups() = %v, %v, want backups of personal and work", backups, err)
+	}
+	personal, work := backups[0], backups[1]
+
+	tests := []struct {
+		name    string
+		filter  BackupFilter
+		want    *Backup
+		wantErr string
+	}{
+		{"latest", BackupFilter{}, personal, ""},
+		{"by id", BackupFilter{ID: work.ID}, work, ""},
+		{"by profile", BackupFilter{Profile: "work"}, work, ""},
+		{"before", BackupFilter{Before: personal.Time.Add(time.Second)}, personal, ""},
+		{"profile before", BackupFilter{Profile: "personal", Before: personal.Time}, nil, "no backup of profile personal taken before"},
+		{"unknown profile", BackupFilter{Profile: "nope"}, nil, "no backup of profile nope"},
+		{"unknown id", BackupFilter{ID: "20200101-000000"}, nil, "not found"},
+		{"invalid id", BackupFilter{ID: "../state"}, nil, "invalid backup ID"},
+	}
 
-		backup, err := mgr.parseBackup(backupPath, "CLAUDE.md")
-		if err != nil {
-			t.Fatalf("parseBackup() error = %v", err)
-		}
-
-		if backup.Path != backupPath {
-			t.Errorf("Path = %q, want %q", backup.Path, backupPath)
-		}
-
-		if backup.Type != "CLAUDE.md" {
-			t.Errorf("Type = %q, want %q", backup.Type, "CLAUDE.md")
-		}
-
-		if backup.Timestamp != "20251201-153045" {
-			t.Errorf("Timestamp = %q, want %q", backup.Timestamp, "20251201-153045")
-		}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mgr.FindBackup(tt.filter)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("FindBackup() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got.ID != tt.want.ID {
+				t.Errorf("FindBackup() = %v, %v, want %s", got, err, tt.want.ID)
+			}
+		})
+	}
+}
 
-		if !strings.Contains(backup.Filename, "CLAUDE.md.backup.") {
-			t.Errorf("Filename = %q, should contain backup pattern", backup.Filename)
-		}
-	})
+func TestRestoreChanges(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
 
-	t.Run("parse settings.json backup", func(t *testing.T) {
-		backupPath := filepath.Join(claudeDir, "settings.json.backup.20251202-100000")
-		if err := os.WriteFile(backupPath, []byte("{}"), 0644); err != nil {
+	claudeDir := filepath.Join(tmpDir, ".claude")
+	mgr := NewManager(tmpDir, claudeDir)
+	workDir := writeProfile(t, tmpDir, "work", "")
+	writeAgentDefinition(t, workDir, "reviewer", "Work reviewer")
+	personalDir := writeProfile(t, tmpDir, "personal", "")
+	writeAgentDefinition(t, personalDir, "writer", "Personal writer")
+	for _, name := range []string{"work", "personal"} {
+		if err := mgr.Activate(name); err != nil {
 			t.Fatal(err)
 		}
+	}
+	backup, err := mgr.FindBackup(BackupFilter{Profile: "work"})
+	if err != nil {
+		t.Fatal(err)
+	}
 
-		backup, err := mgr.parseBackup(backupPath, "settings.json")
-		if err != nil {
-			t.Fatalf("parseBackup() error = %v", err)
-		}
-
-		if backup.Type != "settings.json" {
-			t.Errorf("Type = %q, want %q", backup.Type, "settings.json")
-		}
-
-		if backup.Timestamp != "20251202-100000" {
-			t.Errorf("Timestamp = %q, want %q", backup.Timestamp, "20251202-100000")
-		}
-	})
+	changes, err := mgr.RestoreChanges(backup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]RestoreChange)
+	for _, c := range changes {
+		got[c.Path] = c
+	}
+	if _, ok := got["settings.json"]; ok {
+		t.Error("settings.json is the same in both profiles and shouldn't be listed")
+	}
+	if c := got["CLAUDE.md"]; c.Current == nil || c.Backup == nil || string(c.Current) == string(c.Backup) {
+		t.Errorf("CLAUDE.md change = %+v, want both versions", c)
+	}
+	if c, ok := got["agents/reviewer.md"]; !ok || c.Current != nil {
+		t.Errorf("agents/reviewer.md change = %+v, want created", c)
+	}
+	if c, ok := got["agents/writer.md"]; !ok || c.Backup != nil {
+		t.Errorf("agents/writer.md change = %+v, want removed", c)
+	}
 
-	t.Run("parse non-existent file", func(t *testing.T) {
-		_, err := mgr.parseBackup("/non/existent", "CLAUDE.md")
-		if err == nil {
-			t.Error("parseBackup() should error for non-existent file")
-		}
-	})
+	if err := mgr.Restore(backup.ID); err != nil {
+		t.Fatal(err)
+	}
+	if changes, err := mgr.RestoreChanges(backup); err != nil || len(changes) != 0 {
+		t.Errorf("RestoreChanges() after restoring = %v, %v, want none", changes, err)
+	}
 }
@@ -0,0 +1,330 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// backupRetentionFile holds, in BackupsDir, the retention policy.
+const backupRetentionFile = "retention.json"
+
+// DefaultBackupRetention is the retention policy when none is set.
+var DefaultBackupRetention = BackupRetention{Keep: 20}
+
+// BackupRetention decides which snapshots are kept. A zero limit is no
+// limit. The newest snapshot is always kept.
+type BackupRetention struct {
+	// Keep is how many of the most recent snapshots are kept.
+	Keep int
+	// MaxAge removes snapshots taken longer ago than this.
+	MaxAge time.Duration
+	// MaxSize limits the storage the snapshots use, counting content shared
+	// between them once. The oldest snapshots that don't fit are removed.
+	MaxSize int64
+}
+
+// String describes the policy, e.g. "keep 20, max age 30d, max size 100M".
+func (r BackupRetention) String() string {
+	var parts []string
+	if r.Keep > 0 {
+		parts = append(parts, fmt.Sprintf("keep %d", r.Keep))
+	}
+	if r.MaxAge > 0 {
+		parts = append(parts, "max age "+FormatBackupAge(r.MaxAge))
+	}
+	if r.MaxSize > 0 {
+		parts = append(parts, "max size "+FormatByteSize(r.MaxSize))
+	}
+	if len(parts) == 0 {
+		return "keep everything"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// retentionFile is the stored form of a BackupRetention. A missing keep
+// means the default; ages and sizes are kept readable.
+type retentionFile struct {
+	Keep    *int   `json:"keep,omitempty"`
+	MaxAge  string `json:"max_age,omitempty"`
+	MaxSize string `json:"max_size,omitempty"`
+}
+
+// retentionPath returns the path of the stored retention policy.
+func (m *Manager) retentionPath() string {
+	return filepath.Join(m.backupsPath(), backupRetentionFile)
+}
+
+// BackupRetention returns the stored retention policy, or
+// DefaultBackupRetention if none is stored.
+func (m *Manager) BackupRetention() (BackupRetention, error) {
+	data, err := os.ReadFile(m.retentionPath())
+	if os.IsNotExist(err) {
+		return DefaultBackupRetention, nil
+	}
+	if err != nil {
+		return DefaultBackupRetention, fmt.Errorf("failed to read retention policy: %w", err)
+	}
+
+	var stored retentionFile
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return DefaultBackupRetention, fmt.Errorf("invalid retention policy %s: %w", m.retentionPath(), err)
+	}
+	retention := DefaultBackupRetention
+	if stored.Keep != nil {
+		retention.Keep = *stored.Keep
+	}
+	if stored.MaxAge != "" {
+		if retention.MaxAge, err = ParseBackupAge(stored.MaxAge); err != nil {
+			return DefaultBackupRetention, fmt.Errorf("invalid retention policy %s: %w", m.retentionPath(), err)
+		}
+	}
+	if stored.MaxSize != "" {
+		if retention.MaxSize, err = ParseByteSize(stored.MaxSize); err != nil {
+			return DefaultBackupRetention, fmt.Errorf("invalid retention policy %s: %w", m.retentionPath(), err)
+		}
+	}
+	return retention, nil
+}
+
+// SetBackupRetention stores the retention policy. It applies from the next
+// snapshot or prune.
+func (m *Manager) SetBackupRetention(retention BackupRetention) error {
+	if retention.Keep < 0 || retention.MaxAge < 0 || retention.MaxSize < 0 {
+		return fmt.Errorf("retention limits cannot be negative")
+	}
+
+	// Serialize with other dotclaude processes
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	stored := retentionFile{Keep: &retention.Keep}
+	if retention.MaxAge > 0 {
+		stored.MaxAge = FormatBackupAge(retention.MaxAge)
+	}
+	if retention.MaxSize > 0 {
+		stored.MaxSize = FormatByteSize(retention.MaxSize)
+	}
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(m.backupsPath(), 0700); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	tx, err := m.begin()
+	if err != nil {
+		return err
+	}
+	if err := tx.WriteFile(m.retentionPath(), append(data, '\n'), 0600); err != nil {
+		tx.Abort()
+		return fmt.Errorf("failed to write retention policy: %w", err)
+	}
+	return tx.Commit()
+}
+
+// PruneResult reports what pruning removed, or would remove.
+type PruneResult struct {
+	// Removed lists the snapshots removed, newest first.
+	Removed []*Backup
+	// Kept is how many snapshots remain.
+	Kept int
+	// Objects is how many stored contents no remaining snapshot uses.
+	Objects int
+	// Reclaimed is the disk space freed, in bytes.
+	Reclaimed int64
+}
+
+// PruneBackups removes the snapshots a retention policy doesn't keep, and
+// the stored contents no remaining snapshot uses. With dryRun, it only
+// reports what it would remove.
+func (m *Manager) PruneBackups(retention BackupRetention, dryRun bool) (*PruneResult, error) {
+	// Serialize with other dotclaude processes
+	unlock, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if !dryRun {
+		if err := m.importLegacyBackups(); err != nil {
+			return nil, err
+		}
+	}
+	return m.prune(retention, dryRun)
+}
+
+// prune implements PruneBackups. The caller holds the lock.
+func (m *Manager) prune(retention BackupRetention, dryRun bool) (*PruneResult, error) {
+	backups, err := m.loadBackups()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PruneResult{}
+	used := make(map[string]bool)
+	var size int64
+	full := false
+	now := time.Now()
+	for i, backup := range backups {
+		// Storage the snapshot adds to the newer ones kept
+		var added int64
+		for _, f := range backup.Files {
+			if !used[f.Hash] {
+				added += f.Size
+			}
+		}
+
+		keep := i == 0
+		if !keep {
+			full = full || (retention.MaxSize > 0 && size+added > retention.MaxSize)
+			keep = !full &&
+				(retention.Keep == 0 || i < retention.Keep) &&
+				(retention.MaxAge == 0 || now.Sub(backup.Time) <= retention.MaxAge)
+		}
+		if !keep {
+			result.Removed = append(result.Removed, backup)
+			result.Reclaimed += dirSize(backup.dir)
+			continue
+		}
+
+		result.Kept++
+		size += added
+		for _, f := range backup.Files {
+			used[f.Hash] = true
+		}
+	}
+
+	if !dryRun {
+		for _, backup := range result.Removed {
+			if err := os.RemoveAll(backup.dir); err != nil {
+				return nil, fmt.Errorf("failed to remove backup %s: %w", backup.ID, err)
+			}
+		}
+	}
+
+	// Objects go after the manifests that use them, so an interrupted prune
+	// only leaves unused objects for the next one
+	objects := filepath.Join(m.backupsPath(), backupObjectsDir)
+	err = filepath.WalkDir(objects, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == objects {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		shard := filepath.Base(filepath.Dir(path))
+		if used["sha256:"+shard+d.Name()] {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		result.Objects++
+		result.Reclaimed += info.Size()
+		if dryRun {
+			return nil
+		}
+		return os.Remove(path)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to prune backup contents: %w", err)
+	}
+	return result, nil
+}
+
+// dirSize returns the total size of the files under dir.
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
+
+// ParseBackupAge parses a retention age: a number of days or weeks (30d,
+// 2w) or a Go duration (36h).
+func ParseBackupAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			if count, err := strconv.Atoi(n); err == nil && count >= 0 {
+				return time.Duration(count) * unit, nil
+			}
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return d, nil
+	}
+	return 0, fmt.Errorf("invalid age %q (e.g. 30d, 2w, 36h)", s)
+}
+
+// FormatBackupAge formats an age the way ParseBackupAge reads it, in days
+// when it is a whole number of them.
+func FormatBackupAge(d time.Duration) string {
+	day := 24 * time.Hour
+	if d > 0 && d%day == 0 {
+		return strconv.FormatInt(int64(d/day), 10) + "d"
+	}
+	return d.String()
+}
+
+// byteUnits are the size suffixes ParseByteSize accepts, largest first.
+var byteUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+	{"B", 1},
+}
+
+// ParseByteSize parses a size in bytes with an optional K, M or G suffix
+// (powers of 1024; KB, MiB and the like are accepted too), e.g. 500K or
+// 1.5G.
+func ParseByteSize(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	value = strings.TrimSuffix(strings.TrimSuffix(value, "IB"), "B")
+	unit := int64(1)
+	for _, u := range byteUnits {
+		if n, ok := strings.CutSuffix(value, u.suffix); ok {
+			value, unit = strings.TrimSpace(n), u.size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n < 0 || math.IsInf(n, 0) || n*float64(unit) > math.MaxInt64 {
+		return 0, fmt.Errorf("invalid size %q (e.g. 500K, 100M, 1.5G)", s)
+	}
+	return int64(n * float64(unit)), nil
+}
+
+// FormatByteSize formats a size the way ParseByteSize reads it, e.g. 1.5M.
+func FormatByteSize(size int64) string {
+	for _, u := range byteUnits[:len(byteUnits)-1] {
+		if size >= u.size {
+			value := strconv.FormatFloat(float64(size)/float64(u.size), 'f', 1, 64)
+			return strings.TrimSuffix(value, ".0") + u.suffix
+		}
+	}
+	return strconv.FormatInt(size, 10) + "B"
+}
@@ -0,0 +1,213 @@
+package profile
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setupBackups returns a manager with n snapshots, newest first, each taken
+// a day before the next (the newest a minute ago) and holding a distinct 1K
+// settings.json.
+func setupBackups(t *testing.T, n int) (*Manager, []*Backup) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	claudeDir := filepath.Join(tmpDir, ".claude")
+	if err := os.MkdirAll(claudeDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	mgr := NewManager(tmpDir, claudeDir)
+	if err := mgr.SetBackupRetention(BackupRetention{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		content := strings.Repeat(string(rune('a'+i)), 1024)
+		if err := os.WriteFile(filepath.Join(claudeDir, "settings.json"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		backup, err := mgr.snapshot("test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		backup.Time = time.Now().UTC().Add(-time.Duration(i)*24*time.Hour - time.Minute)
+		data, err := json.Marshal(backup)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(backup.dir, backupManifestFile), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backups, err := mgr.ListBackups()
+	if err != nil || len(backups) != n {
+		t.Fatalf("ListBackups() = %d backups, %v, want %d", len(backups), err, n)
+	}
+	return mgr, backups
+}
+
+func TestPruneBackups(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention BackupRetention
+		want      int // Snapshots kept
+	}{
+		{"keep everything", BackupRetention{}, 5},
+		{"count", BackupRetention{Keep: 3}, 3},
+		{"age", BackupRetention{MaxAge: 36 * time.Hour}, 2},
+		{"size", BackupRetention{MaxSize: 2500}, 2},
+		{"strictest limit wins", BackupRetention{Keep: 4, MaxAge: 72 * time.Hour, MaxSize: 1 << 20}, 3},
+		{"newest always kept", BackupRetention{Keep: 1, MaxAge: time.Nanosecond, MaxSize: 1}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr, backups := setupBackups(t, 5)
+
+			preview, err := mgr.PruneBackups(tt.retention, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if preview.Kept != tt.want || len(preview.Removed) != 5-tt.want {
+				t.Fatalf("dry run kept %d, removed %d, want %d kept", preview.Kept, len(preview.Removed), tt.want)
+			}
+			if after, _ := mgr.ListBackups(); len(after) != 5 {
+				t.Fatalf("dry run removed snapshots: %d left", len(after))
+			}
+
+			result, err := mgr.PruneBackups(tt.retention, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Reclaimed != preview.Reclaimed || result.Objects != preview.Objects {
+				t.Errorf("prune reclaimed %d bytes in %d objects, dry run reported %d in %d",
+					result.Reclaimed, result.Objects, preview.Reclaimed, preview.Objects)
+			}
+			if removed := 5 - tt.want; result.Objects != removed || result.Reclaimed < int64(removed)*1024 {
+				t.Errorf("prune removed %d objects (%d bytes), want %d", result.Objects, result.Reclaimed, removed)
+			}
+
+			after, err := mgr.ListBackups()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(after) != tt.want {
+				t.Fatalf("%d snapshots left, want %d", len(after), tt.want)
+			}
+			for i, backup := range after {
+				if backup.ID != backups[i].ID {
+					t.Errorf("kept %s, want the newest ones", backup.ID)
+				}
+				if _, err := mgr.ReadBackupFile(backup, "settings.json"); err != nil {
+					t.Errorf("kept snapshot lost its content: %v", err)
+				}
+			}
+		})
+	}
+
+	t.Run("shared content kept", func(t *testing.T) {
+		mgr, backups := setupBackups(t, 2)
+		if err := os.WriteFile(filepath.Join(mgr.ClaudeDir, "CLAUDE.md"), []byte("# shared"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// Same settings.json as the oldest snapshot
+		data, err := mgr.ReadBackupFile(backups[1], "settings.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(mgr.ClaudeDir, "settings.json"), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		newest, err := mgr.snapshot("test")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := mgr.PruneBackups(BackupRetention{Keep: 1}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Removed) != 2 || result.Objects != 1 {
+			t.Errorf("removed %d snapshots and %d objects, want 2 and only the unshared one", len(result.Removed), result.Objects)
+		}
+		if got, err := mgr.ReadBackupFile(newest, "settings.json"); err != nil || string(got) != string(data) {
+			t.Errorf("ReadBackupFile() = %v, want content shared with a pruned snapshot kept", err)
+		}
+	})
+}
+
+func TestBackupRetention(t *testing.T) {
+	mgr := NewManager(t.TempDir(), filepath.Join(t.TempDir(), ".claude"))
+
+	retention, err := mgr.BackupRetention()
+	if err != nil || retention != DefaultBackupRetention {
+		t.Errorf("BackupRetention() = %v, %v, want the default", retention, err)
+	}
+
+	want := BackupRetention{MaxAge: 30 * 24 * time.Hour, MaxSize: 100 << 20}
+	if err := mgr.SetBackupRetention(want); err != nil {
+		t.Fatal(err)
+	}
+	if retention, err := mgr.BackupRetention(); err != nil || retention != want {
+		t.Errorf("BackupRetention() = %v, %v, want %v", retention, err, want)
+	}
+	if got := want.String(); got != "max age 30d, max size 100M" {
+		t.Errorf("String() = %q", got)
+	}
+
+	if err := mgr.SetBackupRetention(BackupRetention{Keep: -1}); err == nil {
+		t.Error("SetBackupRetention() should reject negative limits")
+	}
+}
+
+func TestParseBackupAge(t *testing.T) {
+	tests := map[string]time.Duration{
+		"30d": 30 * 24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+		"36h": 36 * time.Hour,
+		"0":   0,
+	}
+	for value, want := range tests {
+		if got, err := ParseBackupAge(value); err != nil || got != want {
+			t.Errorf("ParseBackupAge(%q) = %v, %v, want %v", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"", "-1d", "soon"} {
+		if _, err := ParseBackupAge(value); err == nil {
+			t.Errorf("ParseBackupAge(%q) should fail", value)
+		}
+	}
+	if got := FormatBackupAge(30 * 24 * time.Hour); got != "30d" {
+		t.Errorf("FormatBackupAge() = %q", got)
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]int64{
+		"512":   512,
+		"500K":  500 << 10,
+		"100MB": 100 << 20,
+		"1.5G":  3 << 29,
+		"2 MiB": 2 << 20,
+		"0":     0,
+	}
+	for value, want := range tests {
+		if got, err := ParseByteSize(value); err != nil || got != want {
+			t.Errorf("ParseByteSize(%q) = %d, %v, want %d", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"", "-1M", "lots", "1T"} {
+		if _, err := ParseByteSize(value); err == nil {
+			t.Errorf("ParseByteSize(%q) should fail", value)
+		}
+	}
+	for size, want := range map[int64]string{512: "512B", 1536: "1.5K", 100 << 20: "100M"} {
+		if got := FormatByteSize(size); got != want {
+			t.Errorf("FormatByteSize(%d) = %q, want %q", size, got, want)
+		}
+	}
+}
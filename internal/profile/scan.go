@@ -0,0 +1,432 @@
+package profile
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ScanAllowlistFile is the file, at the top of the repository, listing scan
+// findings to ignore. Each line is one of:
+//
+//	profiles/examples/       a path or glob, relative to the repository
+//	rule:high-entropy-string a whole rule
+//	fingerprint:3f2a9c1e0b7d4a61  one secret, wherever it appears
+//
+// Blank lines and lines starting with # are ignored.
+const ScanAllowlistFile = ".dotclaude-allowlist"
+
+// scanMaxFileSize is the largest file the scanner reads; anything bigger
+// isn't configuration.
+const scanMaxFileSize = 1 << 20
+
+// ScanRule is a kind of secret the scanner detects.
+type ScanRule struct {
+	ID          string
+	Description string
+}
+
+// scanDetector finds one kind of secret in a line. The secret is the first
+// capture group, or the whole match when there is none.
+type scanDetector struct {
+	ScanRule
+	re *regexp.Regexp
+}
+
+// Entropy detection: long tokens of base64-ish characters that mix cases
+// and digits and look random.
+const (
+	entropyRuleID    = "high-entropy-string"
+	entropyMinLength = 24
+	entropyMinBits   = 4.0
+)
+
+var entropyToken = regexp.MustCompile(fmt.Sprintf(`[A-Za-z0-9+/=_-]{%d,}`, entropyMinLength))
+
+var scanDetectors = []scanDetector{
+	{ScanRule{"aws-access-key-id", "AWS access key ID"}, regexp.MustCompile(`\b((?:AKIA|ASIA)[0-9A-Z]{16})\b`)},
+	{ScanRule{"aws-secret-access-key", "AWS secret access key"}, regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})`)},
+	{ScanRule{"github-token", "GitHub token"}, regexp.MustCompile(`\b((?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36}|github_pat_[A-Za-z0-9_]{82})\b`)},
+	{ScanRule{"anthropic-api-key", "Anthropic API key"}, regexp.MustCompile(`\b(sk-ant-[A-Za-z0-9_-]{32,})`)},
+	{ScanRule{"private-key", "Private key block"}, regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`)},
+}
+
+// ScanRules returns every rule the scanner applies.
+func ScanRules() []ScanRule {
+	rules := make([]ScanRule, 0, len(scanDetectors)+1)
+	for _, d := range scanDetectors {
+		rules = append(rules, d.ScanRule)
+	}
+	return append(rules, ScanRule{entropyRuleID, "High-entropy string that looks like a credential"})
+}
+
+// Finding is a possible secret found by Scan.
+type Finding struct {
+	Rule string
+	// File is relative to the repository for base/ and profiles/, and
+	// absolute for files in ClaudeDir.
+	File   string
+	Line   int
+	Column int
+	// Secret is the matched text. It is never printed; see Redacted.
+	Secret string
+	// Fingerprint identifies the secret for the allowlist, independent of
+	// where it appears.
+	Fingerprint string
+
+	repoPath string // Slash-separated path in the repository; empty for ClaudeDir
+}
+
+// Redacted returns the start of the secret, enough to recognize it.
+func (f Finding) Redacted() string {
+	if len(f.Secret) <= 8 {
+		return SecretMask
+	}
+	return f.Secret[:4] + SecretMask
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s %s (fingerprint:%s)", f.File, f.Line, f.Column, f.Rule, f.Redacted(), f.Fingerprint)
+}
+
+// ScanOptions selects what Scan reads.
+type ScanOptions struct {
+	// Repo scans base/ and profiles/.
+	Repo bool
+	// ClaudeDir scans what dotclaude deploys to ClaudeDir: CLAUDE.md,
+	// settings.json, their backups and saved originals, agents and hooks.
+	ClaudeDir bool
+}
+
+// ScanResult is the outcome of a scan.
+type ScanResult struct {
+	Findings []Finding
+	Files    int // Files read
+	Allowed  int // Findings ignored by the allowlist
+}
+
+// ScanError is returned by activation with ActivateOptions.Scan when the
+// profile contains possible secrets.
+type ScanError struct {
+	Findings []Finding
+}
+
+func (e *ScanError) Error() string {
+	return fmt.Sprintf("refusing to activate: %d possible secret(s) found (run 'dotclaude scan' for details, or add them to %s)", len(e.Findings), ScanAllowlistFile)
+}
+
+// Scan looks for secrets in the repository and the deployed configuration.
+func (m *Manager) Scan(opts ScanOptions) (*ScanResult, error) {
+	var dirs []string
+	if opts.Repo {
+		dirs = append(dirs, filepath.Join(m.RepoDir, "base"), m.ProfilesDir)
+	}
+	if opts.ClaudeDir {
+		for _, name := range []string{"CLAUDE.md", "settings.json", "agents", "hooks", OriginalsDir, BackupsDir} {
+			dirs = append(dirs, filepath.Join(m.ClaudeDir, name))
+		}
+		for _, filename := range []string{"CLAUDE.md", "settings.json"} {
+			backups, err := filepath.Glob(filepath.Join(m.ClaudeDir, filename+".backup.*"))
+			if err != nil {
+				return nil, fmt.Errorf("failed to list backups: %w", err)
+			}
+			dirs = append(dirs, backups...) // Not yet imported into BackupsDir
+		}
+	}
+	return m.scanPaths(dirs)
+}
+
+// ScanProfile scans the directories activating a profile reads: base and
+// every profile in its chain or stack, host overlays included.
+func (m *Manager) ScanProfile(profileName string) (*ScanResult, error) {
+	layers, err := m.layers(profileName)
+	if err != nil {
+		return nil, err
+	}
+
+	var dirs []string
+	for _, l := range layers {
+		// Overlays are inside their parent's directory
+		if l.Host == "" {
+			dirs = append(dirs, l.Dir)
+		}
+	}
+	return m.scanPaths(dirs)
+}
+
+// scanPaths scans files and directory trees, skipping missing ones.
+func (m *Manager) scanPaths(paths []string) (*ScanResult, error) {
+	allow, err := m.loadScanAllowlist()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ScanResult{}
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) && p == root {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() {
+				if d.Name() == ".git" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			findings, scanned, err := m.scanFile(p)
+			if err != nil {
+				return err
+			}
+			if scanned {
+				result.Files++
+			}
+			for _, f := range findings {
+				if allow.allows(f) {
+					result.Allowed++
+				} else {
+					result.Findings = append(result.Findings, f)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+		}
+	}
+	return result, nil
+}
+
+// scanFile returns the findings in one file. Large and binary files are
+// skipped.
+func (m *Manager) scanFile(p string) ([]Finding, bool, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, false, err
+	}
+	if info.Size() > scanMaxFileSize {
+		return nil, false, nil
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, false, err
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return nil, false, nil
+	}
+
+	file, repoPath := p, ""
+	if rel, err := filepath.Rel(m.RepoDir, p); err == nil && !strings.HasPrefix(rel, "..") && !isWithin(m.ClaudeDir, p) {
+		file, repoPath = rel, filepath.ToSlash(rel)
+	}
+
+	var findings []Finding
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), scanMaxFileSize)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		for _, f := range scanLine(scanner.Text()) {
+			f.File, f.Line, f.repoPath = file, lineNo, repoPath
+			findings = append(findings, f)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, false, err
+	}
+	return findings, true, nil
+}
+
+// isWithin reports whether p is dir or inside it.
+func isWithin(dir, p string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// scanLine returns the findings in one line, with Rule, Column, Secret and
+// Fingerprint set. Secret references (${env:...} and friends) are what
+// settings should contain instead of secrets, so they are never reported.
+func scanLine(line string) []Finding {
+	// Blank out references, keeping columns
+	line = secretRef.ReplaceAllStringFunc(line, func(ref string) string {
+		return strings.Repeat(" ", len(ref))
+	})
+
+	var findings []Finding
+	var spans [][2]int
+	add := func(rule string, start, end int) {
+		for _, s := range spans {
+			if start < s[1] && s[0] < end {
+				return // Already reported by a more specific rule
+			}
+		}
+		spans = append(spans, [2]int{start, end})
+		secret := line[start:end]
+		findings = append(findings, Finding{
+			Rule:        rule,
+			Column:      start + 1,
+			Secret:      secret,
+			Fingerprint: scanFingerprint(rule, secret),
+		})
+	}
+
+	for _, d := range scanDetectors {
+		for _, loc := range d.re.FindAllStringSubmatchIndex(line, -1) {
+			if len(loc) >= 4 && loc[2] >= 0 {
+				add(d.ID, loc[2], loc[3])
+			} else {
+				add(d.ID, loc[0], loc[1])
+			}
+		}
+	}
+	for _, loc := range entropyToken.FindAllStringIndex(line, -1) {
+		if looksRandom(line[loc[0]:loc[1]]) {
+			add(entropyRuleID, loc[0], loc[1])
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Column < findings[j].Column })
+	return findings
+}
+
+// looksRandom reports whether a token is long, mixes upper case, lower case
+// and digits, and has high Shannon entropy. Hex digests are all one case
+// and never match.
+func looksRandom(token string) bool {
+	if len(token) < entropyMinLength {
+		return false
+	}
+	var upper, lower, digit bool
+	counts := make(map[rune]int)
+	for _, r := range token {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= '0' && r <= '9':
+			digit = true
+		}
+		counts[r]++
+	}
+	if !upper || !lower || !digit {
+		return false
+	}
+	return shannonEntropy(counts, len(token)) >= entropyMinBits
+}
+
+// shannonEntropy returns the entropy, in bits per character, of a string
+// with the given character counts.
+func shannonEntropy(counts map[rune]int, length int) float64 {
+	var bits float64
+	for _, n := range counts {
+		p := float64(n) / float64(length)
+		bits -= p * math.Log2(p)
+	}
+	return bits
+}
+
+// RedactSecrets returns data with everything the scanner would report
+// replaced by its redacted form, for showing configuration that may hold
+// resolved secrets.
+func RedactSecrets(data []byte) []byte {
+	lines := strings.SplitAfter(string(data), "\n")
+	for i, line := range lines {
+		findings := scanLine(line)
+		// Right to left, so earlier columns stay valid
+		for j := len(findings) - 1; j >= 0; j-- {
+			f := findings[j]
+			start := f.Column - 1
+			line = line[:start] + f.Redacted() + line[start+len(f.Secret):]
+		}
+		lines[i] = line
+	}
+	return []byte(strings.Join(lines, ""))
+}
+
+// scanFingerprint identifies a secret found by a rule.
+func scanFingerprint(rule, secret string) string {
+	sum := sha256.Sum256([]byte(rule + ":" + secret))
+	return hex.EncodeToString(sum[:8])
+}
+
+// scanAllowlist is the parsed ScanAllowlistFile.
+type scanAllowlist struct {
+	paths        []string
+	rules        map[string]bool
+	fingerprints map[string]bool
+}
+
+// loadScanAllowlist reads the allowlist; a missing file allows nothing.
+func (m *Manager) loadScanAllowlist() (*scanAllowlist, error) {
+	allow := &scanAllowlist{rules: make(map[string]bool), fingerprints: make(map[string]bool)}
+
+	p := filepath.Join(m.RepoDir, ScanAllowlistFile)
+	data, err := os.ReadFile(p)
+	if os.IsNotExist(err) {
+		return allow, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scan allowlist: %w", err)
+	}
+
+	known := make(map[string]bool)
+	for _, rule := range ScanRules() {
+		known[rule.ID] = true
+	}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "rule:"):
+			rule := strings.TrimPrefix(line, "rule:")
+			if !known[rule] {
+				return nil, fmt.Errorf("%s:%d: unknown rule %q", ScanAllowlistFile, i+1, rule)
+			}
+			allow.rules[rule] = true
+		case strings.HasPrefix(line, "fingerprint:"):
+			allow.fingerprints[strings.ToLower(strings.TrimPrefix(line, "fingerprint:"))] = true
+		default:
+			pattern := strings.TrimSuffix(filepath.ToSlash(line), "/")
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid pattern %q: %w", ScanAllowlistFile, i+1, line, err)
+			}
+			allow.paths = append(allow.paths, pattern)
+		}
+	}
+	return allow, nil
+}
+
+// allows reports whether a finding is allowlisted. A path pattern matches
+// a file or any directory above it.
+func (a *scanAllowlist) allows(f Finding) bool {
+	if a.rules[f.Rule] || a.fingerprints[f.Fingerprint] {
+		return true
+	}
+	if f.repoPath == "" {
+		return false
+	}
+	for p := f.repoPath; p != "." && p != "/"; p = path.Dir(p) {
+		for _, pattern := range a.paths {
+			if ok, _ := path.Match(pattern, p); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
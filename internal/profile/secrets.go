@@ -0,0 +1,302 @@
+package profile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SecretsFileName is the default local secrets file, in the global ClaudeDir.
+// It is a JSON object of names to values and never lives in the repository.
+const SecretsFileName = ".dotclaude-secrets.json"
+
+// SecretsFileEnv overrides the path of the secrets file.
+const SecretsFileEnv = "DOTCLAUDE_SECRETS_FILE"
+
+// SecretMask replaces settings values holding secrets in anything shown to
+// the user.
+const SecretMask = "********"
+
+// secretCommandTimeout bounds how long a ${cmd:...} reference may run.
+const secretCommandTimeout = 30 * time.Second
+
+// secretRef matches a reference in a settings string: ${env:NAME},
+// ${secret:NAME} or ${cmd:command}. $${ escapes a literal ${.
+var secretRef = regexp.MustCompile(`\$?\$\{(env|secret|cmd):([^}]*)\}`)
+
+// SecretRef is a reference to a secret in a settings.json value.
+type SecretRef struct {
+	Path string // JSON path of the value, e.g. env.ANTHROPIC_API_KEY
+	Kind string // env, secret or cmd
+	Name string // Variable, secrets file key or command
+}
+
+func (r SecretRef) String() string {
+	return "${" + r.Kind + ":" + r.Name + "}"
+}
+
+// SecretRefs returns the secret references in the settings activating the
+// profile would deploy, without resolving them.
+func (m *Manager) SecretRefs(profileName string) ([]SecretRef, error) {
+	data, err := m.MergedSettings(profileName)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := decodeSettings(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []SecretRef
+	walkSettingsStrings(settings, nil, func(path []string, s string) {
+		for _, match := range secretRef.FindAllStringSubmatch(s, -1) {
+			if !strings.HasPrefix(match[0], "$$") {
+				refs = append(refs, SecretRef{Path: strings.Join(path, "."), Kind: match[1], Name: match[2]})
+			}
+		}
+	})
+	sort.SliceStable(refs, func(i, j int) bool { return refs[i].Path < refs[j].Path })
+	return refs, nil
+}
+
+// walkSettingsStrings calls fn for every string in a settings document.
+func walkSettingsStrings(v interface{}, path []string, fn func(path []string, s string)) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			walkSettingsStrings(child, append(path[:len(path):len(path)], k), fn)
+		}
+	case []interface{}:
+		for i, child := range v {
+			walkSettingsStrings(child, append(path[:len(path):len(path)], fmt.Sprint(i)), fn)
+		}
+	case string:
+		fn(path, v)
+	}
+}
+
+// hasSecretRef reports whether a settings string holds a reference that
+// isn't escaped.
+func hasSecretRef(s string) bool {
+	for _, match := range secretRef.FindAllString(s, -1) {
+		if !strings.HasPrefix(match, "$$") {
+			return true
+		}
+	}
+	return false
+}
+
+// secretResolver resolves the secret references of one activation, running
+// each command and reading the secrets file at most once.
+type secretResolver struct {
+	m     *Manager
+	file  map[string]string // nil until the secrets file is read
+	cache map[string]string // Resolved value by reference
+	paths [][]string        // Settings strings that held references
+
+	// mask replaces strings holding references with SecretMask instead of
+	// resolving them, so planning an activation runs no ${cmd:...}
+	mask bool
+}
+
+func newSecretResolver(m *Manager) *secretResolver {
+	return &secretResolver{m: m, cache: make(map[string]string)}
+}
+
+// resolveSettings returns settings with every reference replaced by its value.
+func (r *secretResolver) resolveSettings(v interface{}, path []string) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			resolved, err := r.resolveSettings(child, append(path[:len(path):len(path)], k))
+			if err != nil {
+				return nil, err
+			}
+			v[k] = resolved
+		}
+		return v, nil
+	case []interface{}:
+		for i, child := range v {
+			resolved, err := r.resolveSettings(child, append(path[:len(path):len(path)], fmt.Sprint(i)))
+			if err != nil {
+				return nil, err
+			}
+			v[i] = resolved
+		}
+		return v, nil
+	case string:
+		if hasSecretRef(v) {
+			r.paths = append(r.paths, path)
+			if r.mask {
+				return SecretMask, nil
+			}
+		}
+		return r.resolveString(v, strings.Join(path, "."))
+	}
+	return v, nil
+}
+
+// resolveString replaces the references in one settings string.
+func (r *secretResolver) resolveString(s, path string) (string, error) {
+	var firstErr error
+	resolved := secretRef.ReplaceAllStringFunc(s, func(match string) string {
+		if strings.HasPrefix(match, "$$") {
+			return match[1:] // Escaped
+		}
+		if firstErr != nil {
+			return ""
+		}
+		parts := secretRef.FindStringSubmatch(match)
+		value, ok := r.cache[match]
+		if !ok {
+			var err error
+			value, err = r.lookup(parts[1], parts[2])
+			if err != nil {
+				firstErr = fmt.Errorf("settings.json %s: %w", path, err)
+				return ""
+			}
+			r.cache[match] = value
+		}
+		return value
+	})
+	return resolved, firstErr
+}
+
+// lookup resolves a single reference.
+func (r *secretResolver) lookup(kind, name string) (string, error) {
+	switch kind {
+	case "env":
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return value, nil
+	case "secret":
+		if r.file == nil {
+			file, err := r.m.loadSecretsFile()
+			if err != nil {
+				return "", err
+			}
+			r.file = file
+		}
+		value, ok := r.file[name]
+		if !ok {
+			return "", fmt.Errorf("secret %q is not in %s", name, r.m.secretsPath())
+		}
+		return value, nil
+	case "cmd":
+		return r.m.runSecretCommand(name)
+	}
+	return "", fmt.Errorf("unknown secret reference kind %q", kind)
+}
+
+// secretsPath returns the secrets file: $DOTCLAUDE_SECRETS_FILE, else
+// SecretsFile.
+func (m *Manager) secretsPath() string {
+	if path := os.Getenv(SecretsFileEnv); path != "" {
+		return path
+	}
+	return m.SecretsFile
+}
+
+// loadSecretsFile reads the secrets file, warning if other users can read it.
+func (m *Manager) loadSecretsFile() (map[string]string, error) {
+	path := m.secretsPath()
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets file: %w", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		fmt.Fprintf(os.Stderr, "warning: %s is readable by other users (run: chmod 600 %s)\n", path, path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets file: %w", err)
+	}
+	secrets := make(map[string]string)
+	if err := json.Unmarshal(data, &secrets); err != nil {
+		return nil, fmt.Errorf("invalid secrets file %s (want a JSON object of strings): %w", path, err)
+	}
+	return secrets, nil
+}
+
+// runSecretCommand runs a ${cmd:...} reference from the repository and
+// returns its output without the trailing newline.
+func (m *Manager) runSecretCommand(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), secretCommandTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/c", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Dir = m.RepoDir
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("secret command %q timed out after %s", command, secretCommandTimeout)
+	}
+	if err != nil {
+		return "", fmt.Errorf("secret command %q failed: %w", command, err)
+	}
+
+	value := strings.TrimRight(string(out), "\r\n")
+	if value == "" {
+		return "", fmt.Errorf("secret command %q printed nothing", command)
+	}
+	return value, nil
+}
+
+// maskSettings replaces the string values at paths in a settings document
+// with SecretMask. Only the values that held references are touched, so
+// short values elsewhere ("1", "true", a port) stay readable. A document
+// that isn't valid JSON is redacted with the secret scanner instead.
+func maskSettings(data []byte, paths [][]string) []byte {
+	if len(paths) == 0 {
+		return data
+	}
+	settings, err := decodeSettings(data)
+	if err != nil {
+		return RedactSecrets(data)
+	}
+	for _, path := range paths {
+		settings = maskSettingsValue(settings, path)
+	}
+	masked, err := encodeSettings(settings)
+	if err != nil {
+		return RedactSecrets(data)
+	}
+	return masked
+}
+
+// maskSettingsValue replaces the string at path in v with SecretMask.
+func maskSettingsValue(v interface{}, path []string) interface{} {
+	if len(path) == 0 {
+		if _, ok := v.(string); ok {
+			return SecretMask
+		}
+		return v
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if child, ok := v[path[0]]; ok {
+			v[path[0]] = maskSettingsValue(child, path[1:])
+		}
+	case []interface{}:
+		if i, err := strconv.Atoi(path[0]); err == nil && i >= 0 && i < len(v) {
+			v[i] = maskSettingsValue(v[i], path[1:])
+		}
+	}
+	return v
+}
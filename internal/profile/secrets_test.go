@@ -0,0 +1,201 @@
+package profile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestResolveSecretString(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+	mgr.SecretsFile = filepath.Join(tmpDir, "secrets.json")
+	if err := os.WriteFile(mgr.SecretsFile, []byte(`{"github_token": "ghp_123"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DOTCLAUDE_TEST_API_KEY", "sk-test")
+
+	tests := []struct {
+		in      string
+		want    string
+		wantErr string
+	}{
+		{"${env:DOTCLAUDE_TEST_API_KEY}", "sk-test", ""},
+		{"Bearer ${secret:github_token}", "Bearer ghp_123", ""},
+		{"$${env:DOTCLAUDE_TEST_API_KEY}", "${env:DOTCLAUDE_TEST_API_KEY}", ""},
+		{"no references", "no references", ""},
+		{"${env:DOTCLAUDE_TEST_UNSET}", "", "DOTCLAUDE_TEST_UNSET is not set"},
+		{"${secret:missing}", "", `secret "missing" is not in`},
+	}
+	if runtime.GOOS != "windows" {
+		tests = append(tests,
+			struct{ in, want, wantErr string }{"${cmd:echo from-command}", "from-command", ""},
+			struct{ in, want, wantErr string }{"${cmd:exit 3}", "", "failed"},
+			struct{ in, want, wantErr string }{"${cmd:true}", "", "printed nothing"},
+		)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := newSecretResolver(mgr).resolveString(tt.in, "env.KEY")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("resolveString(%q) error = %v, want %q", tt.in, err, tt.wantErr)
+				}
+				if err != nil && !strings.HasPrefix(err.Error(), "settings.json env.KEY: ") {
+					t.Errorf("error should name the settings path: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveString(%q) error = %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("resolveString(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("env overrides the secrets file", func(t *testing.T) {
+		other := filepath.Join(tmpDir, "other.json")
+		if err := os.WriteFile(other, []byte(`{"github_token": "ghp_other"}`), 0600); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv(SecretsFileEnv, other)
+		got, err := newSecretResolver(mgr).resolveString("${secret:github_token}", "env.KEY")
+		if err != nil || got != "ghp_other" {
+			t.Errorf("resolveString() = %q, %v, want the value from %s", got, err, SecretsFileEnv)
+		}
+	})
+}
+
+func TestPlanRunsNoCommands(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+
+	marker := filepath.Join(tmpDir, "ran")
+	workDir := writeProfile(t, tmpDir, "work", "")
+	settings := fmt.Sprintf(`{"env": {"TOKEN": "${cmd:touch %s && echo token}", "UNSET": "${env:DOTCLAUDE_TEST_UNSET}"}}`, filepath.ToSlash(marker))
+	if err := os.WriteFile(filepath.Join(workDir, "settings.json"), []byte(settings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := mgr.Plan("work")
+	if err != nil {
+		t.Fatalf("Plan() error = %v, want unresolvable references masked", err)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Error("Plan() ran a ${cmd:} reference")
+	}
+	if planned := string(plan.Files["settings.json"]); strings.Count(planned, SecretMask) != 2 {
+		t.Errorf("planned settings should mask both references:\n%s", planned)
+	}
+}
+
+func TestMaskSettings(t *testing.T) {
+	// PORT resolved to "1"; the same short value elsewhere stays readable
+	data := []byte(`{"env": {"KEY": "sk-test", "PORT": "1", "DEBUG": "1"}, "flags": ["true", "1"], "retries": 1}`)
+	got := string(maskSettings(data, [][]string{{"env", "KEY"}, {"env", "PORT"}, {"flags", "0"}, {"missing", "key"}}))
+	for _, want := range []string{`"KEY": "********"`, `"PORT": "********"`, `"DEBUG": "1"`, `"********",` + "\n    \"1\"", `"retries": 1`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("maskSettings() should contain %s:\n%s", want, got)
+		}
+	}
+
+	if got := string(maskSettings(data, nil)); got != string(data) {
+		t.Errorf("maskSettings() without paths changed the document: %s", got)
+	}
+	invalid := []byte(`{"env": {"KEY": "ghp_` + strings.Repeat("a", 36) + `"`)
+	if got := string(maskSettings(invalid, [][]string{{"env", "KEY"}})); strings.Contains(got, "ghp_aaaa") {
+		t.Errorf("maskSettings() should redact invalid JSON with the scanner: %s", got)
+	}
+}
+
+func TestActivateResolvesSecrets(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	claudeDir := filepath.Join(tmpDir, ".claude")
+	mgr := NewManager(tmpDir, claudeDir)
+	t.Setenv("DOTCLAUDE_TEST_API_KEY", "sk-live-123")
+
+	workDir := writeProfile(t, tmpDir, "work", "")
+	settings := `{"env": {"ANTHROPIC_API_KEY": "${env:DOTCLAUDE_TEST_API_KEY}", "LITERAL": "$${HOME}"}}`
+	if err := os.WriteFile(filepath.Join(workDir, "settings.json"), []byte(settings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("references listed without values", func(t *testing.T) {
+		refs, err := mgr.SecretRefs("work")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(refs) != 1 || refs[0].Path != "env.ANTHROPIC_API_KEY" || refs[0].String() != "${env:DOTCLAUDE_TEST_API_KEY}" {
+			t.Errorf("SecretRefs() = %+v", refs)
+		}
+
+		merged, err := mgr.MergedSettings("work")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(merged), "sk-live-123") {
+			t.Errorf("MergedSettings() should leave references unresolved:\n%s", merged)
+		}
+	})
+
+	t.Run("plan masks references without resolving them", func(t *testing.T) {
+		plan, err := mgr.Plan("work")
+		if err != nil {
+			t.Fatal(err)
+		}
+		planned := string(plan.Files["settings.json"])
+		if strings.Contains(planned, "sk-live") || !strings.Contains(planned, `"ANTHROPIC_API_KEY": "`+SecretMask+`"`) {
+			t.Errorf("planned settings should hold the mask, not the value:\n%s", planned)
+		}
+		current := []byte(`{"env": {"ANTHROPIC_API_KEY": "sk-live-123"}}`)
+		if masked := string(plan.Mask("settings.json", current)); strings.Contains(masked, "sk-live") || !strings.Contains(masked, SecretMask) {
+			t.Errorf("Mask() should hide the deployed value:\n%s", masked)
+		}
+	})
+
+	if err := mgr.Activate("work"); err != nil {
+		t.Fatalf("Activate() error = %v", err)
+	}
+
+	t.Run("deployed with the value and private", func(t *testing.T) {
+		path := filepath.Join(claudeDir, "settings.json")
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(content), `"ANTHROPIC_API_KEY": "sk-live-123"`) {
+			t.Errorf("settings.json should contain the resolved secret:\n%s", content)
+		}
+		if !strings.Contains(string(content), `"LITERAL": "$${HOME}"`) {
+			t.Errorf("settings.json should keep text that isn't a reference:\n%s", content)
+		}
+		if runtime.GOOS != "windows" {
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if perm := info.Mode().Perm(); perm != 0600 {
+				t.Errorf("settings.json mode = %o, want 600", perm)
+			}
+		}
+	})
+
+	t.Run("unresolvable reference fails activation", func(t *testing.T) {
+		os.Unsetenv("DOTCLAUDE_TEST_API_KEY")
+		err := mgr.Activate("work")
+		if err == nil || !strings.Contains(err.Error(), "env.ANTHROPIC_API_KEY: environment variable DOTCLAUDE_TEST_API_KEY is not set") {
+			t.Errorf("Activate() error = %v", err)
+		}
+	})
+}
@@ -0,0 +1,276 @@
+package profile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// arrayStrategy controls how an array in an overlay settings file is combined
+// with the array at the same path in the layer below it.
+type arrayStrategy int
+
+const (
+	// arrayReplace replaces the lower array with the overlay array (default).
+	arrayReplace arrayStrategy = iota
+	// arrayUnion appends overlay elements that are not already present.
+	arrayUnion
+	// arrayConcatByMatcher merges hook entries that share a "matcher" by
+	// concatenating their "hooks" lists; entries with new matchers are appended.
+	arrayConcatByMatcher
+)
+
+// settingsArrayStrategies maps dotted settings paths to their array strategy.
+// A "*" segment matches any single key.
+var settingsArrayStrategies = map[string]arrayStrategy{
+	"permissions.allow":                 arrayUnion,
+	"permissions.deny":                  arrayUnion,
+	"permissions.ask":                   arrayUnion,
+	"permissions.additionalDirectories": arrayUnion,
+	"hooks.*":                           arrayConcatByMatcher,
+}
+
+// SettingsSources returns the settings.json files (relative to the repository)
+// that contribute to a profile's deployed settings, lowest layer first.
+func (m *Manager) SettingsSources(profileName string) ([]string, error) {
+	files, err := m.settingsFiles(profileName)
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []string
+	for _, path := range files {
+		sources = append(sources, m.relPath(path))
+	}
+	return sources, nil
+}
+
+// settingsFiles returns the existing settings.json paths for each layer of a profile.
+func (m *Manager) settingsFiles(profileName string) ([]string, error) {
+	layers, err := m.layers(profileName)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, l := range layers {
+		path := filepath.Join(l.Dir, "settings.json")
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+	return files, nil
+}
+
+// MergedSettings returns the settings.json content that activating the profile
+// would deploy: base/settings.json with each profile's settings.json in the
+// inheritance chain deep-merged on top, in order.
+func (m *Manager) MergedSettings(profileName string) ([]byte, error) {
+	files, err := m.settingsFiles(profileName)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("failed to read settings: no settings.json in base or profile '%s'", profileName)
+	}
+
+	var merged interface{}
+	for i, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read settings: %w", err)
+		}
+
+		layer, err := decodeSettings(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON in %s: %w", m.relPath(path), err)
+		}
+
+		if i == 0 {
+			merged = layer
+			continue
+		}
+		merged = mergeSettingsValue(merged, layer, nil)
+	}
+
+	return encodeSettings(merged)
+}
+
+// decodeSettings parses a settings document, keeping numbers verbatim.
+func decodeSettings(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// encodeSettings renders a settings document as indented JSON.
+func encodeSettings(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("failed to encode settings: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// mergeSettingsValue merges overlay on top of base at the given path.
+// Objects merge recursively, a null overlay value deletes the key, arrays are
+// combined according to settingsArrayStrategies, and anything else is replaced.
+func mergeSettingsValue(base, overlay interface{}, path []string) interface{} {
+	switch ov := overlay.(type) {
+	case map[string]interface{}:
+		bv, ok := base.(map[string]interface{})
+		if !ok {
+			return stripNulls(ov)
+		}
+
+		result := make(map[string]interface{}, len(bv)+len(ov))
+		for k, v := range bv {
+			result[k] = v
+		}
+		for k, v := range ov {
+			if v == nil {
+				delete(result, k)
+				continue
+			}
+			childPath := append(append([]string{}, path...), k)
+			if existing, ok := result[k]; ok {
+				result[k] = mergeSettingsValue(existing, v, childPath)
+			} else {
+				result[k] = stripNulls(v)
+			}
+		}
+		return result
+
+	case []interface{}:
+		bv, ok := base.([]interface{})
+		if !ok {
+			return ov
+		}
+
+		switch strategyForPath(path) {
+		case arrayUnion:
+			return unionArrays(bv, ov)
+		case arrayConcatByMatcher:
+			return concatByMatcher(bv, ov)
+		default:
+			return ov
+		}
+
+	default:
+		return overlay
+	}
+}
+
+// stripNulls removes null-valued keys from an object that has nothing to merge into.
+func stripNulls(v interface{}) interface{} {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+
+	result := make(map[string]interface{}, len(obj))
+	for k, child := range obj {
+		if child == nil {
+			continue
+		}
+		result[k] = stripNulls(child)
+	}
+	return result
+}
+
+// strategyForPath returns the array strategy configured for a settings path.
+func strategyForPath(path []string) arrayStrategy {
+	for pattern, strategy := range settingsArrayStrategies {
+		parts := strings.Split(pattern, ".")
+		if len(parts) != len(path) {
+			continue
+		}
+
+		matched := true
+		for i, part := range parts {
+			if part != "*" && part != path[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return strategy
+		}
+	}
+	return arrayReplace
+}
+
+// unionArrays appends overlay elements not already present in base.
+func unionArrays(base, overlay []interface{}) []interface{} {
+	result := append([]interface{}{}, base...)
+	for _, item := range overlay {
+		if !containsValue(result, item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// concatByMatcher merges Claude Code hook entries ({"matcher": ..., "hooks": [...]})
+// so that entries with the same matcher share a single, de-duplicated hooks list.
+func concatByMatcher(base, overlay []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(base)+len(overlay))
+	index := make(map[string]int)
+
+	add := func(item interface{}) {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			if !containsValue(result, item) {
+				result = append(result, item)
+			}
+			return
+		}
+
+		matcher, _ := entry["matcher"].(string)
+		i, seen := index[matcher]
+		if !seen {
+			copied := make(map[string]interface{}, len(entry))
+			for k, v := range entry {
+				copied[k] = v
+			}
+			index[matcher] = len(result)
+			result = append(result, copied)
+			return
+		}
+
+		existing := result[i].(map[string]interface{})
+		existingHooks, _ := existing["hooks"].([]interface{})
+		newHooks, _ := entry["hooks"].([]interface{})
+		existing["hooks"] = unionArrays(existingHooks, newHooks)
+	}
+
+	for _, item := range base {
+		add(item)
+	}
+	for _, item := range overlay {
+		add(item)
+	}
+	return result
+}
+
+// containsValue reports whether list contains a value deeply equal to v.
+func containsValue(list []interface{}, v interface{}) bool {
+	for _, item := range list {
+		if reflect.DeepEqual(item, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+package profile
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMergedSettings(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	baseSettings := `{
+  "alwaysThinkingEnabled": true,
+  "permissions": {"allow": ["Read", "Grep"], "deny": ["Bash(rm:*)"]},
+  "hooks": {
+    "SessionStart": [
+      {"matcher": "*", "hooks": [{"type": "command", "command": "dotclaude hook run session-start"}]}
+    ]
+  }
+}`
+	if err := os.WriteFile(filepath.Join(tmpDir, "base", "settings.json"), []byte(baseSettings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	profileDir := filepath.Join(tmpDir, "profiles", "layered")
+	if err := os.MkdirAll(profileDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	profileSettings := `{
+  "model": "opus",
+  "alwaysThinkingEnabled": null,
+  "permissions": {"allow": ["Grep", "Bash(go test:*)"]},
+  "hooks": {
+    "SessionStart": [
+      {"matcher": "*", "hooks": [{"type": "command", "command": "echo profile"}]}
+    ],
+    "PostToolUse": [
+      {"matcher": "Bash", "hooks": [{"type": "command", "command": "echo bash"}]}
+    ]
+  }
+}`
+	if err := os.WriteFile(filepath.Join(profileDir, "settings.json"), []byte(profileSettings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+
+	data, err := mgr.MergedSettings("layered")
+	if err != nil {
+		t.Fatalf("MergedSettings() error = %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("merged settings are not valid JSON: %v", err)
+	}
+
+	t.Run("profile scalar overrides", func(t *testing.T) {
+		if got["model"] != "opus" {
+			t.Errorf("model = %v, want opus", got["model"])
+		}
+	})
+
+	t.Run("null deletes key", func(t *testing.T) {
+		if _, ok := got["alwaysThinkingEnabled"]; ok {
+			t.Error("alwaysThinkingEnabled should have been deleted by null")
+		}
+	})
+
+	t.Run("permissions are unioned", func(t *testing.T) {
+		perms := got["permissions"].(map[string]interface{})
+		allow := perms["allow"].([]interface{})
+		want := []interface{}{"Read", "Grep", "Bash(go test:*)"}
+		if !reflect.DeepEqual(allow, want) {
+			t.Errorf("permissions.allow = %v, want %v", allow, want)
+		}
+		if _, ok := perms["deny"]; !ok {
+			t.Error("permissions.deny from base should be preserved")
+		}
+	})
+
+	t.Run("hooks concatenated by matcher", func(t *testing.T) {
+		hooksObj := got["hooks"].(map[string]interface{})
+		sessionStart := hooksObj["SessionStart"].([]interface{})
+		if len(sessionStart) != 1 {
+			t.Fatalf("SessionStart entries = %d, want 1", len(sessionStart))
+		}
+		commands := sessionStart[0].(map[string]interface{})["hooks"].([]interface{})
+		if len(commands) != 2 {
+			t.Errorf("SessionStart hooks = %d, want 2 (base + profile)", len(commands))
+		}
+		if _, ok := hooksObj["PostToolUse"]; !ok {
+			t.Error("PostToolUse from profile should be added")
+		}
+	})
+}
+
+func TestMergedSettings_ErrorPaths(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+
+	profileDir := filepath.Join(tmpDir, "profiles", "broken")
+	if err := os.MkdirAll(profileDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(profileDir, "settings.json"), []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := mgr.MergedSettings("broken"); err == nil {
+		t.Error("MergedSettings() should error on invalid JSON")
+	}
+}
+
+func TestMergeSettingsValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    string
+		overlay string
+		want    string
+	}{
+		{"nested objects merge", `{"a": {"b": 1, "c": 2}}`, `{"a": {"c": 3}}`, `{"a": {"b": 1, "c": 3}}`},
+		{"unknown arrays replace", `{"list": [1, 2]}`, `{"list": [3]}`, `{"list": [3]}`},
+		{"null deletes nested key", `{"a": {"b": 1, "c": 2}}`, `{"a": {"b": null}}`, `{"a": {"c": 2}}`},
+		{"null in new object is dropped", `{}`, `{"a": {"b": null, "c": 1}}`, `{"a": {"c": 1}}`},
+		{"duplicate hook is not repeated", `{"hooks": {"Stop": [{"matcher": "", "hooks": [{"command": "x"}]}]}}`, `{"hooks": {"Stop": [{"matcher": "", "hooks": [{"command": "x"}]}]}}`, `{"hooks": {"Stop": [{"matcher": "", "hooks": [{"command": "x"}]}]}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, err := decodeSettings([]byte(tt.base))
+			if err != nil {
+				t.Fatal(err)
+			}
+			overlay, err := decodeSettings([]byte(tt.overlay))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := decodeSettings([]byte(tt.want))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := mergeSettingsValue(base, overlay, nil)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("mergeSettingsValue() = %v, want %v", got, want)
+			}
+		})
+	}
+}
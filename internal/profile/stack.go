@@ -0,0 +1,69 @@
+package profile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StackSeparator joins the profiles of a stack, e.g. "work+golang+security".
+// A stack is activated like a single profile: base first, then each
+// profile's inheritance chain in order, later profiles taking precedence.
+const StackSeparator = "+"
+
+// SplitStack returns the profiles named by a profile or stack name.
+func SplitStack(name string) []string {
+	return strings.Split(name, StackSeparator)
+}
+
+// JoinStack returns the stack name for profiles.
+func JoinStack(profiles []string) string {
+	return strings.Join(profiles, StackSeparator)
+}
+
+// IsStack reports whether name names more than one profile.
+func IsStack(name string) bool {
+	return strings.Contains(name, StackSeparator)
+}
+
+// ValidateStackName checks a profile name or a stack of profile names.
+func ValidateStackName(name string) error {
+	if name == "" {
+		return fmt.Errorf("profile name cannot be empty")
+	}
+
+	seen := make(map[string]bool)
+	for _, member := range SplitStack(name) {
+		if member == "" {
+			return fmt.Errorf("invalid profile stack: %s (empty profile name)", name)
+		}
+		if err := ValidateProfileName(member); err != nil {
+			return err
+		}
+		if seen[member] {
+			return fmt.Errorf("invalid profile stack: %s ('%s' appears twice)", name, member)
+		}
+		seen[member] = true
+	}
+	return nil
+}
+
+// StackExists returns an error naming the first profile of a stack that
+// doesn't exist.
+func (m *Manager) StackExists(name string) error {
+	for _, member := range SplitStack(name) {
+		if !m.ProfileExists(member) {
+			return fmt.Errorf("profile '%s' does not exist", member)
+		}
+	}
+	return nil
+}
+
+// InActiveStack reports whether a profile is active, on its own or as part
+// of the active stack.
+func (m *Manager) InActiveStack(profileName string) bool {
+	active := m.GetActiveProfileName()
+	if active == "" {
+		return false
+	}
+	return containsString(SplitStack(active), profileName)
+}
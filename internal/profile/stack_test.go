@@ -0,0 +1,194 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateStackName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"work", false},
+		{"work+golang", false},
+		{"work+golang+security", false},
+		{"", true},
+		{"work+", true},
+		{"+work", true},
+		{"work++golang", true},
+		{"work+golang+work", true},
+		{"work+../etc", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateStackName(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateStackName(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStackOrderWins(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+
+	writeProfile(t, tmpDir, "company", "")
+	writeProfile(t, tmpDir, "work", "company")
+	writeProfile(t, tmpDir, "golang", "work")
+	writeProfile(t, tmpDir, "rust", "company")
+
+	tests := map[string][]string{
+		// work is asked for on top of golang, which extends it
+		"golang+work": {"company", "golang", "work"},
+		"work+golang": {"company", "work", "golang"},
+		// An inherited-only parent stays below both children
+		"golang+rust": {"company", "work", "golang", "rust"},
+	}
+	for stack, want := range tests {
+		chain, err := mgr.ResolveChain(stack)
+		if err != nil {
+			t.Fatalf("ResolveChain(%s) error = %v", stack, err)
+		}
+		if !reflect.DeepEqual(chain, want) {
+			t.Errorf("ResolveChain(%s) = %v, want %v", stack, chain, want)
+		}
+	}
+
+	// A member keeps its own CLAUDE.md requirement and header
+	layers, err := mgr.profileLayers("golang+work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := layers[len(layers)-1]; last.Name != "work" || last.Via != "work" {
+		t.Errorf("top layer = %+v, want work as a member", last)
+	}
+}
+
+func TestActivateStack(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	claudeDir := filepath.Join(tmpDir, ".claude")
+	mgr := NewManager(tmpDir, claudeDir)
+
+	workDir := writeProfile(t, tmpDir, "work", "")
+	golangDir := writeProfile(t, tmpDir, "golang", "")
+	securityDir := writeProfile(t, tmpDir, "security", "golang")
+
+	if err := os.WriteFile(filepath.Join(workDir, "settings.json"), []byte(`{"model": "sonnet", "team": "platform"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(securityDir, "settings.json"), []byte(`{"model": "opus"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeAgentDefinition(t, workDir, "work-reviewer", "Team reviewer")
+	writeAgentDefinition(t, golangDir, "go-expert", "Go expert")
+
+	if err := mgr.Activate("work+nope"); err == nil || !strings.Contains(err.Error(), "'nope' does not exist") {
+		t.Errorf("Activate() with a missing member error = %v", err)
+	}
+
+	if err := mgr.Activate("work+golang+security"); err != nil {
+		t.Fatalf("Activate() error = %v", err)
+	}
+
+	t.Run("CLAUDE.md sections in stack order", func(t *testing.T) {
+		content, err := os.ReadFile(filepath.Join(claudeDir, "CLAUDE.md"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		merged := string(content)
+
+		var last int
+		for _, want := range []string{"# Base Config", "# Profile: work\n", "# work rules", "# Profile: golang\n", "# golang rules", "# Profile: security\n", "# security rules"} {
+			i := strings.Index(merged, want)
+			if i < last {
+				t.Fatalf("CLAUDE.md should contain %q after the previous section:\n%s", want, merged)
+			}
+			last = i
+		}
+		if strings.Count(merged, "# golang rules") != 1 {
+			t.Errorf("a profile inherited by another member should be merged once:\n%s", merged)
+		}
+	})
+
+	t.Run("settings merged in order", func(t *testing.T) {
+		content, err := os.ReadFile(filepath.Join(claudeDir, "settings.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{`"model": "opus"`, `"team": "platform"`, `"key": "value"`} {
+			if !strings.Contains(string(content), want) {
+				t.Errorf("settings.json missing %s:\n%s", want, content)
+			}
+		}
+	})
+
+	t.Run("agents from every profile", func(t *testing.T) {
+		for _, agent := range []string{"work-reviewer.md", "go-expert.md"} {
+			if _, err := os.Stat(filepath.Join(claudeDir, "agents", agent)); err != nil {
+				t.Errorf("agent %s should be deployed: %v", agent, err)
+			}
+		}
+	})
+
+	t.Run("stack recorded in state", func(t *testing.T) {
+		state, err := mgr.LoadState()
+		if err != nil || state == nil {
+			t.Fatalf("LoadState() = %v, %v", state, err)
+		}
+		if state.Profile != "work+golang+security" {
+			t.Errorf("state profile = %q", state.Profile)
+		}
+		if want := []string{"work", "golang", "security"}; !reflect.DeepEqual(state.Stack, want) {
+			t.Errorf("state stack = %v, want %v", state.Stack, want)
+		}
+		if want := []string{"work", "golang", "security"}; !reflect.DeepEqual(state.Chain, want) {
+			t.Errorf("state chain = %v, want %v", state.Chain, want)
+		}
+	})
+
+	t.Run("members listed as active", func(t *testing.T) {
+		profiles, err := mgr.ListProfiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range profiles {
+			if !p.IsActive {
+				t.Errorf("profile %s should be active", p.Name)
+			}
+		}
+
+		active, err := mgr.GetActiveProfile()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if active.Name != "security" {
+			t.Errorf("GetActiveProfile() = %s, want the top of the stack", active.Name)
+		}
+	})
+
+	t.Run("members cannot be deleted", func(t *testing.T) {
+		err := mgr.Delete("golang")
+		if err == nil || !strings.Contains(err.Error(), "active stack") {
+			t.Errorf("Delete() error = %v, want active stack error", err)
+		}
+	})
+
+	t.Run("history matches members", func(t *testing.T) {
+		entries, err := mgr.History(HistoryFilter{Profile: "golang"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 || entries[0].To != "work+golang+security" {
+			t.Errorf("History() = %+v, want the stack activation", entries)
+		}
+	})
+}
@@ -0,0 +1,283 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// StateFileName is the activation state document kept in ClaudeDir.
+const StateFileName = ".dotclaude-state.json"
+
+// StateVersion is the state document format this build writes. Documents
+// with a newer version are rejected rather than misread.
+const StateVersion = 1
+
+// OriginalsDir holds, under ClaudeDir, the files that were in place before
+// dotclaude first overwrote them, so deactivation can put them back.
+const OriginalsDir = ".dotclaude-originals"
+
+// legacyStateFile held the active profile's name before the state document.
+// It is read when no state document exists and removed by the next write.
+const legacyStateFile = ".current-profile"
+
+// State records the last activation.
+type State struct {
+	// Version is the format version of the document.
+	Version int `json:"version"`
+	// Profile is the active profile, or the active stack ("work+golang").
+	Profile string `json:"profile"`
+	// Stack lists the profiles of an active stack in merge order; empty for
+	// a single profile.
+	Stack []string `json:"stack,omitempty"`
+	// Chain is the resolved inheritance chain, root ancestor first, ending
+	// with Profile (for a stack, every member's chain in order). Empty for
+	// state migrated from .current-profile.
+	Chain []string `json:"chain,omitempty"`
+	// ActivatedAt is when the profile was activated.
+	ActivatedAt time.Time `json:"activated_at"`
+	// Commit is the repo's HEAD commit at activation, if it is a git checkout.
+	Commit string `json:"commit,omitempty"`
+	// DotclaudeVersion is the dotclaude version that activated the profile.
+	DotclaudeVersion string `json:"dotclaude_version,omitempty"`
+	// Files maps each deployed file, relative to ClaudeDir and
+	// slash-separated, to its content hash.
+	Files map[string]string `json:"files"`
+	// Originals lists the files, relative to ClaudeDir, that existed before
+	// dotclaude first wrote them. Their content is saved in OriginalsDir.
+	Originals []string `json:"originals,omitempty"`
+	// CLAUDEmd is how CLAUDE.md was written; empty means CLAUDEmdReplace.
+	// In managed mode Files records the hash of the managed block only.
+	CLAUDEmd CLAUDEmdMode `json:"claude_md,omitempty"`
+}
+
+// CLAUDEmdMode returns how the activation wrote CLAUDE.md. A nil state, before
+// any activation, reports CLAUDEmdReplace.
+func (s *State) CLAUDEmdMode() CLAUDEmdMode {
+	if s == nil || s.CLAUDEmd == "" {
+		return CLAUDEmdReplace
+	}
+	return s.CLAUDEmd
+}
+
+// gitHead returns the commit checked out in dir, or "" if it isn't a git
+// checkout. Overridable for tests.
+var gitHead = defaultGitHead
+
+func defaultGitHead(dir string) string {
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// LoadState reads the activation state, migrating it from the legacy
+// .current-profile file if the state document doesn't exist yet. It returns nil if no profile was ever activated.
+func (m *Manager) LoadState() (*State, error) {
+	data, err := os.ReadFile(m.StateFile)
+	if os.IsNotExist(err) {
+		return m.legacyState()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("invalid state file %s: %w", m.StateFile, err)
+	}
+	if state.Version > StateVersion {
+		return nil, fmt.Errorf("state file %s has format version %d, newer than this dotclaude supports (%d); upgrade dotclaude", m.StateFile, state.Version, StateVersion)
+	}
+	if state.Files == nil {
+		state.Files = map[string]string{}
+	}
+	return &state, nil
+}
+
+// legacyState builds a state from the file older versions wrote. It has no
+// file hashes, so nothing deployed before counts as drifted.
+func (m *Manager) legacyState() (*State, error) {
+	path := filepath.Join(m.ClaudeDir, legacyStateFile)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", legacyStateFile, err)
+	}
+
+	name := strings.TrimSpace(string(data))
+	if name == "" {
+		return nil, nil
+	}
+	state := &State{Version: StateVersion, Profile: name, Files: map[string]string{}}
+	if info, err := os.Stat(path); err == nil {
+		state.ActivatedAt = info.ModTime()
+	}
+
+	return state, nil
+}
+
+// stageState records the activation of name, including the hashes of
+// everything the transaction writes into ClaudeDir, and retires the legacy
+// state file. prev is the state being replaced, nil on first activation.
+func (m *Manager) stageState(tx *transaction, name string, prev *State, mode CLAUDEmdMode) error {
+	chain, err := m.ResolveChain(name)
+	if err != nil {
+		return err
+	}
+
+	originals, err := m.stageOriginals(tx, prev, mode)
+	if err != nil {
+		return fmt.Errorf("failed to save original files: %w", err)
+	}
+
+	state := &State{
+		Version:          StateVersion,
+		Profile:          name,
+		Chain:            chain,
+		ActivatedAt:      time.Now().UTC().Truncate(time.Second),
+		Commit:           gitHead(m.RepoDir),
+		DotclaudeVersion: m.Version,
+		Files:            make(map[string]string),
+		Originals:        originals,
+	}
+	if mode == CLAUDEmdManaged {
+		state.CLAUDEmd = mode
+	}
+	if IsStack(name) {
+		state.Stack = SplitStack(name)
+	}
+	for path, hash := range tx.hashes {
+		rel, err := filepath.Rel(m.ClaudeDir, path)
+		if err != nil || strings.HasPrefix(rel, "..") || isBookkeeping(rel) {
+			continue
+		}
+		state.Files[filepath.ToSlash(rel)] = hash
+	}
+
+	return m.stageStateDocument(tx, state)
+}
+
+// stageOriginals saves a copy of each file the transaction overwrites that
+// dotclaude didn't deploy itself, and returns every saved original. State
+// migrated without file hashes can't tell dotclaude's files from the user's,
+// so nothing new is saved for it.
+//
+// A managed CLAUDE.md keeps the user's content in the file itself, so it has
+// no saved original; leaving managed mode saves the content outside the block.
+func (m *Manager) stageOriginals(tx *transaction, prev *State, mode CLAUDEmdMode) ([]string, error) {
+	var originals []string
+	saved := make(map[string]bool)
+	if prev != nil {
+		for _, rel := range prev.Originals {
+			if rel == "CLAUDE.md" && mode == CLAUDEmdManaged {
+				tx.Remove(m.originalPath(rel)) // Now kept outside the block
+				continue
+			}
+			originals = append(originals, rel)
+			saved[rel] = true
+		}
+	}
+	if mode == CLAUDEmdManaged {
+		saved["CLAUDE.md"] = true
+	} else if prev.CLAUDEmdMode() == CLAUDEmdManaged {
+		path := filepath.Join(m.ClaudeDir, "CLAUDE.md")
+		if data, err := os.ReadFile(path); err == nil {
+			if user := withoutManagedBlock(data); strings.TrimSpace(user) != "" {
+				if err := tx.WriteFile(m.originalPath("CLAUDE.md"), []byte(user), 0644); err != nil {
+					return nil, err
+				}
+				originals = append(originals, "CLAUDE.md")
+			}
+		}
+		saved["CLAUDE.md"] = true
+	}
+	if prev != nil && len(prev.Files) == 0 {
+		return originals, nil
+	}
+
+	var written []string
+	for path := range tx.hashes {
+		written = append(written, path)
+	}
+	sort.Strings(written)
+
+	for _, path := range written {
+		rel, err := filepath.Rel(m.ClaudeDir, path)
+		if err != nil || strings.HasPrefix(rel, "..") || isBookkeeping(rel) {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		if saved[rel] {
+			continue
+		}
+		if prev != nil {
+			if _, deployed := prev.Files[rel]; deployed {
+				continue
+			}
+		}
+
+		info, err := os.Lstat(path)
+		if os.IsNotExist(err) || (err == nil && !info.Mode().IsRegular()) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := tx.WriteFile(m.originalPath(rel), data, info.Mode().Perm()); err != nil {
+			return nil, err
+		}
+		originals = append(originals, rel)
+		saved[rel] = true
+	}
+	return originals, nil
+}
+
+// originalPath returns where the original of a file is saved.
+func (m *Manager) originalPath(rel string) string {
+	return filepath.Join(m.ClaudeDir, OriginalsDir, filepath.FromSlash(rel))
+}
+
+// stageStateDocument stages state to be written, replacing the legacy file.
+func (m *Manager) stageStateDocument(tx *transaction, state *State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := tx.WriteFile(m.StateFile, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+
+	legacy := filepath.Join(m.ClaudeDir, legacyStateFile)
+	if _, err := os.Lstat(legacy); err == nil {
+		tx.Remove(legacy)
+	}
+	return nil
+}
+
+// saveState writes state outside of an activation, e.g. after a restore.
+func (m *Manager) saveState(state *State) error {
+	tx, err := m.begin()
+	if err != nil {
+		return err
+	}
+	if err := m.stageStateDocument(tx, state); err != nil {
+		tx.Abort()
+		return err
+	}
+	return tx.Commit()
+}
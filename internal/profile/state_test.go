@@ -0,0 +1,121 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestActivateWritesState(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	gitHead = func(string) string { return "abc123" }
+	defer func() { gitHead = defaultGitHead }()
+
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+	mgr.Version = "1.2.3"
+	writeProfile(t, tmpDir, "parent", "")
+	writeProfile(t, tmpDir, "child", "parent")
+
+	if err := mgr.Activate("child"); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := mgr.LoadState()
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	if state.Version != StateVersion {
+		t.Errorf("Version = %d, want %d", state.Version, StateVersion)
+	}
+	if state.Profile != "child" {
+		t.Errorf("Profile = %q, want %q", state.Profile, "child")
+	}
+	if want := []string{"parent", "child"}; !reflect.DeepEqual(state.Chain, want) {
+		t.Errorf("Chain = %v, want %v", state.Chain, want)
+	}
+	if state.Commit != "abc123" {
+		t.Errorf("Commit = %q, want %q", state.Commit, "abc123")
+	}
+	if state.DotclaudeVersion != "1.2.3" {
+		t.Errorf("DotclaudeVersion = %q, want %q", state.DotclaudeVersion, "1.2.3")
+	}
+	if state.ActivatedAt.IsZero() {
+		t.Error("ActivatedAt should be set")
+	}
+	if _, ok := state.Files["CLAUDE.md"]; !ok {
+		t.Errorf("Files = %v, should include CLAUDE.md", state.Files)
+	}
+	for rel := range state.Files {
+		if isBookkeeping(rel) {
+			t.Errorf("Files should not include bookkeeping file %s", rel)
+		}
+	}
+}
+
+func TestLegacyStateMigration(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+	writeProfile(t, tmpDir, "work", "")
+	writeProfile(t, tmpDir, "personal", "")
+	if err := os.MkdirAll(mgr.ClaudeDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	legacy := filepath.Join(mgr.ClaudeDir, legacyStateFile)
+	if err := os.WriteFile(legacy, []byte("work\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := mgr.GetActiveProfileName(); got != "work" {
+		t.Errorf("GetActiveProfileName() = %q, want %q", got, "work")
+	}
+	state, err := mgr.LoadState()
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	if state.Profile != "work" || len(state.Files) != 0 {
+		t.Errorf("LoadState() = %+v, want work with no hashes", state)
+	}
+
+	if err := mgr.Activate("personal"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Error(".current-profile should be removed after migration")
+	}
+	if got := mgr.GetActiveProfileName(); got != "personal" {
+		t.Errorf("GetActiveProfileName() = %q, want %q", got, "personal")
+	}
+}
+
+func TestLoadStateNewerVersion(t *testing.T) {
+	claudeDir := t.TempDir()
+	mgr := NewManager(t.TempDir(), claudeDir)
+
+	if err := os.WriteFile(mgr.StateFile, []byte(`{"version": 99, "profile": "work"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := mgr.LoadState()
+	if err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("LoadState() error = %v, want a newer-version error", err)
+	}
+	if got := mgr.GetActiveProfileName(); got != "" {
+		t.Errorf("GetActiveProfileName() = %q, want empty for unreadable state", got)
+	}
+}
+
+func TestLoadStateNone(t *testing.T) {
+	mgr := NewManager(t.TempDir(), t.TempDir())
+
+	state, err := mgr.LoadState()
+	if err != nil || state != nil {
+		t.Errorf("LoadState() = %v, %v, want nil, nil", state, err)
+	}
+}
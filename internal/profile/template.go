@@ -0,0 +1,170 @@
+package profile
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/template"
+)
+
+// TemplateSuffix marks a CLAUDE.md that is rendered as a Go text/template at
+// activation time (CLAUDE.md.tmpl). Plain CLAUDE.md files are copied verbatim.
+const TemplateSuffix = ".tmpl"
+
+// TemplateData is the data available to CLAUDE.md templates.
+type TemplateData struct {
+	Profile  string            // Profile being activated
+	Chain    []string          // Inheritance chain, root ancestor first
+	Hostname string            // Machine hostname
+	OS       string            // runtime.GOOS, e.g. "linux" or "darwin"
+	Arch     string            // runtime.GOARCH, e.g. "amd64"
+	Home     string            // User's home directory
+	Git      GitIdentity       // Global git identity
+	Env      map[string]string // Environment variables listed under "env" in the chain's manifests
+	Vars     map[string]string // "vars" from the chain's manifests, later profiles win
+}
+
+// GitIdentity is the user's configured git identity.
+type GitIdentity struct {
+	Name  string
+	Email string
+}
+
+// Overridable for tests.
+var (
+	hostname  = os.Hostname
+	gitConfig = func(dir, key string) string {
+		cmd := exec.Command("git", "config", "--get", key)
+		cmd.Dir = dir
+		out, err := cmd.Output()
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(out))
+	}
+)
+
+// templateFuncs are the helper functions available to CLAUDE.md templates.
+var templateFuncs = template.FuncMap{
+	"default": func(def, value string) string {
+		if value == "" {
+			return def
+		}
+		return value
+	},
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"trim":  strings.TrimSpace,
+}
+
+// TemplateData returns the data CLAUDE.md templates are rendered with when
+// activating the profile.
+func (m *Manager) TemplateData(profileName string) (*TemplateData, error) {
+	layers, err := m.layers(profileName)
+	if err != nil {
+		return nil, err
+	}
+	return m.templateData(profileName, layers), nil
+}
+
+// templateData builds template data from already-resolved layers.
+func (m *Manager) templateData(profileName string, layers []layer) *TemplateData {
+	host, _ := hostname()
+	home, _ := os.UserHomeDir()
+
+	data := &TemplateData{
+		Profile:  profileName,
+		Hostname: host,
+		OS:       runtime.GOOS,
+		Arch:     runtime.GOARCH,
+		Home:     home,
+		Git: GitIdentity{
+			Name:  gitConfig(m.RepoDir, "user.name"),
+			Email: gitConfig(m.RepoDir, "user.email"),
+		},
+		Env:  make(map[string]string),
+		Vars: make(map[string]string),
+	}
+
+	for _, l := range layers {
+		if l.Manifest == nil {
+			continue
+		}
+		data.Chain = append(data.Chain, l.Name)
+		for _, name := range l.Manifest.Env {
+			data.Env[name] = os.Getenv(name)
+		}
+		for k, v := range l.Manifest.Vars {
+			data.Vars[k] = v
+		}
+	}
+
+	return data
+}
+
+// renderTemplate executes a CLAUDE.md template. name is used in error
+// messages, which carry the line (and column) of the problem.
+func renderTemplate(name string, content []byte, data *TemplateData) ([]byte, error) {
+	tmpl, err := template.New(name).
+		Funcs(templateFuncs).
+		Option("missingkey=error").
+		Parse(string(content))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// CLAUDEmdPath returns the CLAUDE.md source in dir: CLAUDE.md.tmpl if it
+// exists, otherwise CLAUDE.md (which may not exist either).
+func CLAUDEmdPath(dir string) string {
+	tmplPath := filepath.Join(dir, "CLAUDE.md"+TemplateSuffix)
+	if _, err := os.Stat(tmplPath); err == nil {
+		return tmplPath
+	}
+	return filepath.Join(dir, "CLAUDE.md")
+}
+
+// claudeMDSource returns the CLAUDE.md source of a layer, refusing
+// ambiguous layers that have both a template and a plain file.
+func claudeMDSource(l layer) (string, error) {
+	path := CLAUDEmdPath(l.Dir)
+	if strings.HasSuffix(path, TemplateSuffix) {
+		if _, err := os.Stat(filepath.Join(l.Dir, "CLAUDE.md")); err == nil {
+			return "", fmt.Errorf("%s has both CLAUDE.md and CLAUDE.md%s (keep only one)", l.Dir, TemplateSuffix)
+		}
+	}
+	return path, nil
+}
+
+// CLAUDEmdSources returns the CLAUDE.md files (relative to the repository)
+// merged when activating the profile, base first.
+func (m *Manager) CLAUDEmdSources(profileName string) ([]string, error) {
+	layers, err := m.layers(profileName)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make([]string, 0, len(layers))
+	for _, l := range layers {
+		path, err := claudeMDSource(l)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) && l.optionalCLAUDEmd() {
+			continue
+		}
+		sources = append(sources, m.relPath(path))
+	}
+	return sources, nil
+}
@@ -0,0 +1,116 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMergedCLAUDEmdTemplates(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+
+	origHostname, origGitConfig := hostname, gitConfig
+	defer func() { hostname, gitConfig = origHostname, origGitConfig }()
+	hostname = func() (string, error) { return "devbox", nil }
+	gitConfig = func(dir, key string) string {
+		return map[string]string{"user.name": "Dana Dev", "user.email": "dana@example.com"}[key]
+	}
+	t.Setenv("DOTCLAUDE_TEST_PROJECT_ROOT", "/srv/projects")
+
+	workDir := writeProfile(t, tmpDir, "work", "")
+	if err := os.WriteFile(filepath.Join(workDir, "profile.yaml"), []byte("vars:\n  team: platform\n  editor: vim\nenv: [DOTCLAUDE_TEST_PROJECT_ROOT]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	clientDir := writeProfile(t, tmpDir, "client", "work")
+	if err := os.WriteFile(filepath.Join(clientDir, "profile.yaml"), []byte("extends: work\nvars:\n  editor: emacs\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(clientDir, "CLAUDE.md")); err != nil {
+		t.Fatal(err)
+	}
+
+	writeTemplate := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(clientDir, "CLAUDE.md.tmpl"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("renders template data", func(t *testing.T) {
+		writeTemplate(`# {{.Profile}} on {{.Hostname}} ({{.OS}})
+Author: {{.Git.Name}} <{{.Git.Email}}>
+Team: {{.Vars.team}}, editor: {{.Vars.editor}}
+Projects: {{.Env.DOTCLAUDE_TEST_PROJECT_ROOT}}
+Chain: {{join .Chain " > "}}
+`)
+		merged, err := mgr.MergedCLAUDEmd("client")
+		if err != nil {
+			t.Fatalf("MergedCLAUDEmd() error = %v", err)
+		}
+
+		for _, want := range []string{
+			"# client on devbox",
+			"Author: Dana Dev <dana@example.com>",
+			"Team: platform, editor: emacs",
+			"Projects: /srv/projects",
+			"Chain: work > client",
+			"# work rules", // plain CLAUDE.md is merged verbatim
+		} {
+			if !strings.Contains(string(merged), want) {
+				t.Errorf("merged CLAUDE.md missing %q:\n%s", want, merged)
+			}
+		}
+	})
+
+	t.Run("parse error reports file and line", func(t *testing.T) {
+		writeTemplate("line one\n{{.Profile\n")
+		_, err := mgr.MergedCLAUDEmd("client")
+		if err == nil || !strings.Contains(err.Error(), "profiles/client/CLAUDE.md.tmpl:2") {
+			t.Errorf("MergedCLAUDEmd() error = %v, want file:line", err)
+		}
+	})
+
+	t.Run("unknown variable reports file and line", func(t *testing.T) {
+		writeTemplate("line one\nline two\n{{.Vars.missing}}\n")
+		_, err := mgr.MergedCLAUDEmd("client")
+		if err == nil || !strings.Contains(err.Error(), "profiles/client/CLAUDE.md.tmpl:3") {
+			t.Errorf("MergedCLAUDEmd() error = %v, want file:line", err)
+		}
+	})
+
+	t.Run("template and plain file together", func(t *testing.T) {
+		writeTemplate("{{.Profile}}\n")
+		if err := os.WriteFile(filepath.Join(clientDir, "CLAUDE.md"), []byte("plain\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(filepath.Join(clientDir, "CLAUDE.md"))
+
+		if _, err := mgr.MergedCLAUDEmd("client"); err == nil {
+			t.Error("MergedCLAUDEmd() should refuse a profile with both CLAUDE.md and CLAUDE.md.tmpl")
+		}
+	})
+}
+
+func TestCLAUDEmdSources(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+	profileDir := writeProfile(t, tmpDir, "templated", "")
+	if err := os.Rename(filepath.Join(profileDir, "CLAUDE.md"), filepath.Join(profileDir, "CLAUDE.md.tmpl")); err != nil {
+		t.Fatal(err)
+	}
+
+	sources, err := mgr.CLAUDEmdSources("templated")
+	if err != nil {
+		t.Fatalf("CLAUDEmdSources() error = %v", err)
+	}
+	want := []string{"base/CLAUDE.md", "profiles/templated/CLAUDE.md.tmpl"}
+	if strings.Join(sources, ",") != strings.Join(want, ",") {
+		t.Errorf("CLAUDEmdSources() = %v, want %v", sources, want)
+	}
+}
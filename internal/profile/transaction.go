@@ -0,0 +1,289 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// stagingPrefix names the temporary directories transactions stage files in.
+// They live inside ClaudeDir so the final renames stay on one filesystem.
+const stagingPrefix = ".dotclaude-staging-"
+
+// journalFile records a transaction's planned renames while it commits, so an
+// interrupted commit can be rolled back by the next transaction.
+const journalFile = "journal.json"
+
+// transaction stages changes to files under ClaudeDir and applies them all or
+// none. Writes go to a staging directory and are fsynced; Commit then renames
+// them into place, moving the files they replace aside so any failure can put
+// everything back.
+type transaction struct {
+	stageDir string
+	ops      []txOp
+	hashes   map[string]string // Content hash of each file written, by path
+
+	// secretPaths are the settings.json values that held secret references,
+	// for masking
+	secretPaths [][]string
+
+	// planned holds written content instead of staging it, for transactions
+	// that only describe an activation (see newPlan)
+	planned map[string][]byte
+}
+
+// txOp is a single staged change.
+type txOp struct {
+	Target  string   `json:"target"`             // Destination path
+	Staged  string   `json:"staged,omitempty"`   // Staged content; empty for a removal
+	Backup  string   `json:"backup,omitempty"`   // Where the replaced file is moved during commit
+	Existed bool     `json:"existed,omitempty"`  // Target existed when the op was applied
+	NewDirs []string `json:"new_dirs,omitempty"` // Parent directories the op created
+}
+
+// begin starts a transaction on ClaudeDir, first rolling back any transaction
+// a previous process left half-committed.
+func (m *Manager) begin() (*transaction, error) {
+	if err := os.MkdirAll(m.ClaudeDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create Claude directory: %w", err)
+	}
+	if err := recoverTransactions(m.ClaudeDir); err != nil {
+		return nil, fmt.Errorf("failed to recover interrupted activation: %w", err)
+	}
+
+	stageDir, err := os.MkdirTemp(m.ClaudeDir, stagingPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	return &transaction{stageDir: stageDir, hashes: make(map[string]string)}, nil
+}
+
+// newPlan returns a transaction that records what would be written without
+// touching the filesystem. It cannot be committed.
+func newPlan() *transaction {
+	return &transaction{hashes: make(map[string]string), planned: make(map[string][]byte)}
+}
+
+// WriteFile stages data to be written to path on commit.
+func (tx *transaction) WriteFile(path string, data []byte, mode os.FileMode) error {
+	tx.hashes[path] = hashContent(data)
+	if tx.planned != nil {
+		tx.planned[path] = data
+		return nil
+	}
+
+	staged := filepath.Join(tx.stageDir, "new-"+strconv.Itoa(len(tx.ops)))
+	if err := writeFileSync(staged, data, mode); err != nil {
+		return fmt.Errorf("failed to stage %s: %w", path, err)
+	}
+	tx.ops = append(tx.ops, txOp{Target: path, Staged: staged})
+	return nil
+}
+
+// RecordHash replaces the hash recorded for a written file, for files of
+// which dotclaude owns only part.
+func (tx *transaction) RecordHash(path, hash string) {
+	tx.hashes[path] = hash
+}
+
+// Remove stages the removal of path on commit. Missing files are ignored.
+func (tx *transaction) Remove(path string) {
+	tx.ops = append(tx.ops, txOp{Target: path})
+}
+
+// Abort discards everything staged.
+func (tx *transaction) Abort() {
+	if tx.stageDir != "" {
+		os.RemoveAll(tx.stageDir)
+	}
+}
+
+// Commit applies the staged changes. On failure every change already applied
+// is undone before returning the error.
+func (tx *transaction) Commit() error {
+	if tx.planned != nil {
+		return fmt.Errorf("cannot commit a plan")
+	}
+	defer tx.Abort()
+
+	// Plan first so the journal describes the whole commit before anything moves
+	exists := make(map[string]bool)
+	for i := range tx.ops {
+		op := &tx.ops[i]
+		existed, seen := exists[op.Target]
+		if !seen {
+			_, err := os.Lstat(op.Target)
+			existed = err == nil
+		}
+		op.Existed = existed
+		if existed {
+			op.Backup = filepath.Join(tx.stageDir, "old-"+strconv.Itoa(i))
+		}
+		exists[op.Target] = op.Staged != ""
+	}
+	if err := tx.writeJournal(); err != nil {
+		return err
+	}
+
+	for i := range tx.ops {
+		if err := tx.apply(&tx.ops[i]); err != nil {
+			rollback(tx.ops[:i+1])
+			return err
+		}
+	}
+
+	// The journal going away is the commit point
+	if err := os.Remove(filepath.Join(tx.stageDir, journalFile)); err != nil {
+		rollback(tx.ops)
+		return fmt.Errorf("failed to finish activation: %w", err)
+	}
+	syncDirs(tx.ops)
+	return nil
+}
+
+// apply performs one planned op.
+func (tx *transaction) apply(op *txOp) error {
+	if op.Existed {
+		if err := os.Rename(op.Target, op.Backup); err != nil {
+			return fmt.Errorf("failed to replace %s: %w", op.Target, err)
+		}
+	}
+	if op.Staged == "" {
+		return nil
+	}
+
+	dirs, err := mkdirAllTracked(filepath.Dir(op.Target))
+	op.NewDirs = dirs
+	if err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", op.Target, err)
+	}
+	if len(dirs) > 0 {
+		// Keep the journal accurate so recovery can remove these too
+		if err := tx.writeJournal(); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(op.Staged, op.Target); err != nil {
+		return fmt.Errorf("failed to write %s: %w", op.Target, err)
+	}
+	return nil
+}
+
+// writeJournal persists the planned ops to the staging directory.
+func (tx *transaction) writeJournal() error {
+	data, err := json.Marshal(tx.ops)
+	if err != nil {
+		return err
+	}
+	if err := writeFileSync(filepath.Join(tx.stageDir, journalFile), data, 0600); err != nil {
+		return fmt.Errorf("failed to write activation journal: %w", err)
+	}
+	return nil
+}
+
+// rollback undoes ops in reverse order. It works from what is on disk, so it
+// is safe on ops that were only partly applied.
+func rollback(ops []txOp) {
+	for i := len(ops) - 1; i >= 0; i-- {
+		op := ops[i]
+		if op.Existed {
+			if _, err := os.Lstat(op.Backup); err == nil {
+				os.Rename(op.Backup, op.Target)
+			}
+		} else if op.Staged != "" {
+			if _, err := os.Lstat(op.Staged); os.IsNotExist(err) {
+				// The staged file was moved into place
+				os.Remove(op.Target)
+			}
+		}
+		for j := len(op.NewDirs) - 1; j >= 0; j-- {
+			os.Remove(op.NewDirs[j]) // only succeeds while empty
+		}
+	}
+}
+
+// recoverTransactions rolls back commits interrupted by a crash or signal and
+// removes abandoned staging directories.
+func recoverTransactions(root string) error {
+	dirs, err := filepath.Glob(filepath.Join(root, stagingPrefix+"*"))
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range dirs {
+		data, err := os.ReadFile(filepath.Join(dir, journalFile))
+		if err == nil {
+			var ops []txOp
+			if err := json.Unmarshal(data, &ops); err != nil {
+				return fmt.Errorf("corrupt activation journal in %s: %w", dir, err)
+			}
+			rollback(ops)
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFileSync writes a file and flushes it to disk.
+func writeFileSync(path string, data []byte, mode os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	// OpenFile's mode is subject to the umask
+	return os.Chmod(path, mode)
+}
+
+// mkdirAllTracked creates dir and any missing parents, returning the
+// directories it created, outermost first.
+func mkdirAllTracked(dir string) ([]string, error) {
+	var missing []string
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil {
+			break
+		}
+		missing = append([]string{d}, missing...)
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return missing, nil
+}
+
+// syncDirs flushes the directory entries of renamed files. Errors are
+// ignored: not every platform supports syncing directories.
+func syncDirs(ops []txOp) {
+	seen := make(map[string]bool)
+	for _, op := range ops {
+		dir := filepath.Dir(op.Target)
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		if d, err := os.Open(dir); err == nil {
+			d.Sync()
+			d.Close()
+		}
+	}
+}
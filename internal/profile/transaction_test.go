@@ -0,0 +1,197 @@
+package profile
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// commitStaged runs stage in a fresh transaction and commits it if stage succeeds.
+func commitStaged(mgr *Manager, stage func(tx *transaction) error) error {
+	tx, err := mgr.begin()
+	if err != nil {
+		return err
+	}
+	if err := stage(tx); err != nil {
+		tx.Abort()
+		return err
+	}
+	return tx.Commit()
+}
+
+func readString(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestTransactionCommit(t *testing.T) {
+	claudeDir := t.TempDir()
+	mgr := NewManager(t.TempDir(), claudeDir)
+
+	existing := filepath.Join(claudeDir, "CLAUDE.md")
+	obsolete := filepath.Join(claudeDir, "agents", "old.md")
+	if err := os.MkdirAll(filepath.Dir(obsolete), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{existing, obsolete} {
+		if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := commitStaged(mgr, func(tx *transaction) error {
+		tx.Remove(obsolete)
+		tx.Remove(filepath.Join(claudeDir, "never-existed"))
+		if err := tx.WriteFile(existing, []byte("new"), 0644); err != nil {
+			return err
+		}
+		return tx.WriteFile(filepath.Join(claudeDir, "hooks", "session-start", "10-x.sh"), []byte("#!/bin/sh\n"), 0755)
+	})
+	if err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	if got := readString(t, existing); got != "new" {
+		t.Errorf("CLAUDE.md = %q, want %q", got, "new")
+	}
+	if _, err := os.Stat(obsolete); !os.IsNotExist(err) {
+		t.Error("removed file should be gone")
+	}
+	info, err := os.Stat(filepath.Join(claudeDir, "hooks", "session-start", "10-x.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("hook mode = %v, want 0755", info.Mode().Perm())
+	}
+
+	staging, _ := filepath.Glob(filepath.Join(claudeDir, stagingPrefix+"*"))
+	if len(staging) != 0 {
+		t.Errorf("staging directories left behind: %v", staging)
+	}
+}
+
+func TestTransactionRollback(t *testing.T) {
+	claudeDir := t.TempDir()
+	mgr := NewManager(t.TempDir(), claudeDir)
+
+	first := filepath.Join(claudeDir, "CLAUDE.md")
+	removed := filepath.Join(claudeDir, "agents", "keep.md")
+	if err := os.MkdirAll(filepath.Dir(removed), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{first, removed} {
+		if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A regular file where a directory is needed makes the last op fail
+	if err := os.WriteFile(filepath.Join(claudeDir, "hooks"), []byte("in the way"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := commitStaged(mgr, func(tx *transaction) error {
+		if err := tx.WriteFile(first, []byte("new"), 0644); err != nil {
+			return err
+		}
+		tx.Remove(removed)
+		if err := tx.WriteFile(filepath.Join(claudeDir, "new-dir", "created.md"), []byte("new"), 0644); err != nil {
+			return err
+		}
+		return tx.WriteFile(filepath.Join(claudeDir, "hooks", "session-start", "10-x.sh"), []byte("new"), 0755)
+	})
+	if err == nil {
+		t.Fatal("Commit() should fail")
+	}
+
+	if got := readString(t, first); got != "old" {
+		t.Errorf("CLAUDE.md = %q, want rollback to %q", got, "old")
+	}
+	if got := readString(t, removed); got != "old" {
+		t.Errorf("removed file = %q, want it restored", got)
+	}
+	if _, err := os.Stat(filepath.Join(claudeDir, "new-dir")); !os.IsNotExist(err) {
+		t.Error("directory created by the failed commit should be removed")
+	}
+}
+
+func TestRecoverTransactions(t *testing.T) {
+	claudeDir := t.TempDir()
+	target := filepath.Join(claudeDir, "settings.json")
+	created := filepath.Join(claudeDir, ".current-profile")
+
+	// Simulate a process killed mid-commit: settings.json already replaced,
+	// its original moved aside, and a new state file written
+	stageDir := filepath.Join(claudeDir, stagingPrefix+"crashed")
+	if err := os.MkdirAll(stageDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	backup := filepath.Join(stageDir, "old-0")
+	writes := map[string]string{backup: "old", target: "new", created: "new"}
+	for path, content := range writes {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ops := []txOp{
+		{Target: target, Staged: filepath.Join(stageDir, "new-0"), Backup: backup, Existed: true},
+		{Target: created, Staged: filepath.Join(stageDir, "new-1")},
+	}
+	data, _ := json.Marshal(ops)
+	if err := os.WriteFile(filepath.Join(stageDir, journalFile), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	mgr := NewManager(t.TempDir(), claudeDir)
+	tx, err := mgr.begin()
+	if err != nil {
+		t.Fatalf("begin() error = %v", err)
+	}
+	tx.Abort()
+
+	if got := readString(t, target); got != "old" {
+		t.Errorf("settings.json = %q, want recovered %q", got, "old")
+	}
+	if _, err := os.Stat(created); !os.IsNotExist(err) {
+		t.Error("file created by the interrupted commit should be removed")
+	}
+	if _, err := os.Stat(stageDir); !os.IsNotExist(err) {
+		t.Error("stale staging directory should be removed")
+	}
+}
+
+func TestActivateFailureLeavesPreviousProfile(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+	writeProfile(t, tmpDir, "first", "")
+	secondDir := writeProfile(t, tmpDir, "second", "")
+	writeHook(t, secondDir, "session-start", "10-second.sh", 0755)
+
+	if err := mgr.Activate("first"); err != nil {
+		t.Fatal(err)
+	}
+	before := readString(t, filepath.Join(mgr.ClaudeDir, "CLAUDE.md"))
+
+	// Hooks can't be deployed while a file occupies the hooks directory path
+	if err := os.WriteFile(filepath.Join(mgr.ClaudeDir, "hooks"), []byte("in the way"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mgr.Activate("second"); err == nil {
+		t.Fatal("Activate() should fail")
+	}
+	if got := readString(t, filepath.Join(mgr.ClaudeDir, "CLAUDE.md")); got != before {
+		t.Errorf("CLAUDE.md changed after failed activation:\n%s", got)
+	}
+	if active := mgr.GetActiveProfileName(); active != "first" {
+		t.Errorf("active profile = %q, want %q", active, "first")
+	}
+}
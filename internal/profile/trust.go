@@ -0,0 +1,266 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TrustFileName is the store of .dotclaude files approved for automatic
+// activation, in the global ClaudeDir.
+const TrustFileName = ".dotclaude-trust.json"
+
+// AutoActivateEnv overrides the stored auto-activation policy.
+const AutoActivateEnv = "DOTCLAUDE_AUTO_ACTIVATE"
+
+// AutoActivatePolicy controls what the session-start hook does when a
+// project's .dotclaude names a profile other than the active one.
+type AutoActivatePolicy string
+
+const (
+	// AutoActivateNever only reports the mismatch.
+	AutoActivateNever AutoActivatePolicy = "never"
+	// AutoActivatePrompt asks before switching when run in a terminal, and
+	// otherwise reports the mismatch.
+	AutoActivatePrompt AutoActivatePolicy = "prompt"
+	// AutoActivateAuto switches without asking if the .dotclaude file is
+	// trusted, and otherwise behaves like AutoActivatePrompt.
+	AutoActivateAuto AutoActivatePolicy = "auto"
+)
+
+// ParseAutoActivatePolicy validates a policy name.
+func ParseAutoActivatePolicy(s string) (AutoActivatePolicy, error) {
+	switch p := AutoActivatePolicy(strings.ToLower(strings.TrimSpace(s))); p {
+	case AutoActivateNever, AutoActivatePrompt, AutoActivateAuto:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid auto-activation policy %q (want never, prompt or auto)", s)
+}
+
+// TrustedFile is a .dotclaude file approved for automatic activation.
+type TrustedFile struct {
+	Dir       string    `json:"dir"`  // Directory containing the .dotclaude file
+	Hash      string    `json:"hash"` // Content hash at the time it was trusted
+	TrustedAt time.Time `json:"trusted_at"`
+}
+
+// TrustStore holds the auto-activation policy and the trusted .dotclaude files.
+type TrustStore struct {
+	Version int                `json:"version"`
+	Policy  AutoActivatePolicy `json:"policy,omitempty"`
+	Trusted []TrustedFile      `json:"trusted,omitempty"`
+}
+
+// trustPath returns the path of the trust store.
+func (m *Manager) trustPath() string {
+	return filepath.Join(m.ClaudeDir, TrustFileName)
+}
+
+// LoadTrust reads the trust store. A missing store is empty.
+func (m *Manager) LoadTrust() (*TrustStore, error) {
+	data, err := os.ReadFile(m.trustPath())
+	if os.IsNotExist(err) {
+		return &TrustStore{Version: 1}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trust store: %w", err)
+	}
+
+	var store TrustStore
+	if err := json.Unmarshal(data, &store); err != nil {
+		return nil, fmt.Errorf("invalid trust store %s: %w", m.trustPath(), err)
+	}
+	return &store, nil
+}
+
+// saveTrust writes the trust store. Callers hold the lock.
+func (m *Manager) saveTrust(store *TrustStore) error {
+	sort.Slice(store.Trusted, func(i, j int) bool {
+		return store.Trusted[i].Dir < store.Trusted[j].Dir
+	})
+	data, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return err
+	}
+	tx, err := m.begin()
+	if err != nil {
+		return err
+	}
+	if err := tx.WriteFile(m.trustPath(), append(data, '\n'), 0600); err != nil {
+		tx.Abort()
+		return fmt.Errorf("failed to write trust store: %w", err)
+	}
+	return tx.Commit()
+}
+
+// AutoActivatePolicy returns the effective policy: $DOTCLAUDE_AUTO_ACTIVATE if
+// set, else the stored policy, else AutoActivateNever.
+func (m *Manager) AutoActivatePolicy() (AutoActivatePolicy, error) {
+	if env := os.Getenv(AutoActivateEnv); env != "" {
+		policy, err := ParseAutoActivatePolicy(env)
+		if err != nil {
+			return AutoActivateNever, fmt.Errorf("%s: %w", AutoActivateEnv, err)
+		}
+		return policy, nil
+	}
+
+	store, err := m.LoadTrust()
+	if err != nil {
+		return AutoActivateNever, err
+	}
+	if store.Policy == "" {
+		return AutoActivateNever, nil
+	}
+	return ParseAutoActivatePolicy(string(store.Policy))
+}
+
+// SetAutoActivatePolicy stores the auto-activation policy.
+func (m *Manager) SetAutoActivatePolicy(policy AutoActivatePolicy) error {
+	if _, err := ParseAutoActivatePolicy(string(policy)); err != nil {
+		return err
+	}
+
+	// Serialize with other dotclaude processes
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	store, err := m.LoadTrust()
+	if err != nil {
+		return err
+	}
+	store.Policy = policy
+	return m.saveTrust(store)
+}
+
+// trustKey returns the canonical directory of a .dotclaude file, so the same
+// file is recognized however it is reached.
+func trustKey(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Dir(abs)
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	return dir, nil
+}
+
+// Trust approves the .dotclaude file at path, as it is now, for automatic
+// activation. Editing the file revokes the approval.
+func (m *Manager) Trust(path string) (*TrustedFile, error) {
+	dir, err := trustKey(path)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := hashFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	// Serialize with other dotclaude processes
+	unlock, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	store, err := m.LoadTrust()
+	if err != nil {
+		return nil, err
+	}
+	entry := TrustedFile{Dir: dir, Hash: hash, TrustedAt: time.Now().UTC().Truncate(time.Second)}
+	replaced := false
+	for i := range store.Trusted {
+		if store.Trusted[i].Dir == dir {
+			store.Trusted[i] = entry
+			replaced = true
+		}
+	}
+	if !replaced {
+		store.Trusted = append(store.Trusted, entry)
+	}
+	if err := m.saveTrust(store); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Untrust revokes the approval of the .dotclaude file at path. It reports
+// whether the file was trusted.
+func (m *Manager) Untrust(path string) (bool, error) {
+	dir, err := trustKey(path)
+	if err != nil {
+		return false, err
+	}
+
+	// Serialize with other dotclaude processes
+	unlock, err := m.lock()
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	store, err := m.LoadTrust()
+	if err != nil {
+		return false, err
+	}
+	kept := store.Trusted[:0]
+	for _, t := range store.Trusted {
+		if t.Dir != dir {
+			kept = append(kept, t)
+		}
+	}
+	if len(kept) == len(store.Trusted) {
+		return false, nil
+	}
+	store.Trusted = kept
+	return true, m.saveTrust(store)
+}
+
+// TrustStatus describes whether a .dotclaude file may be auto-activated.
+type TrustStatus int
+
+const (
+	// Untrusted files were never approved.
+	Untrusted TrustStatus = iota
+	// TrustChanged files were approved, but have been edited since.
+	TrustChanged
+	// Trusted files are approved as they are now.
+	Trusted
+)
+
+// CheckTrust reports whether the .dotclaude file at path is approved for
+// automatic activation with its current content.
+func (m *Manager) CheckTrust(path string) (TrustStatus, error) {
+	dir, err := trustKey(path)
+	if err != nil {
+		return Untrusted, err
+	}
+	store, err := m.LoadTrust()
+	if err != nil {
+		return Untrusted, err
+	}
+
+	for _, t := range store.Trusted {
+		if t.Dir != dir {
+			continue
+		}
+		hash, err := hashFile(path)
+		if err != nil {
+			return Untrusted, err
+		}
+		if hash != t.Hash {
+			return TrustChanged, nil
+		}
+		return Trusted, nil
+	}
+	return Untrusted, nil
+}
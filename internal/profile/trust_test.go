@@ -0,0 +1,143 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTrust(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+	project := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(project, ".dotclaude")
+	if err := os.WriteFile(path, []byte("profile: work\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if status, err := mgr.CheckTrust(path); err != nil || status != Untrusted {
+		t.Fatalf("CheckTrust() before trusting = %v, %v, want Untrusted", status, err)
+	}
+
+	if _, err := mgr.Trust(path); err != nil {
+		t.Fatalf("Trust() error = %v", err)
+	}
+	if status, err := mgr.CheckTrust(path); err != nil || status != Trusted {
+		t.Errorf("CheckTrust() after trusting = %v, %v, want Trusted", status, err)
+	}
+
+	// Trusting again replaces the entry
+	if _, err := mgr.Trust(path); err != nil {
+		t.Fatal(err)
+	}
+	store, err := mgr.LoadTrust()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(store.Trusted) != 1 {
+		t.Errorf("trusted files = %d, want 1", len(store.Trusted))
+	}
+
+	// Editing the file revokes the approval
+	if err := os.WriteFile(path, []byte("profile: personal\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if status, err := mgr.CheckTrust(path); err != nil || status != TrustChanged {
+		t.Errorf("CheckTrust() after edit = %v, %v, want TrustChanged", status, err)
+	}
+
+	removed, err := mgr.Untrust(path)
+	if err != nil || !removed {
+		t.Fatalf("Untrust() = %v, %v, want true", removed, err)
+	}
+	if removed, _ := mgr.Untrust(path); removed {
+		t.Error("Untrust() of an untrusted file should report false")
+	}
+	if status, _ := mgr.CheckTrust(path); status != Untrusted {
+		t.Errorf("CheckTrust() after Untrust = %v, want Untrusted", status)
+	}
+}
+
+func TestTrustSymlinkedDir(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+	project := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(project, ".dotclaude"), []byte("work\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(tmpDir, "link")
+	if err := os.Symlink(project, link); err != nil {
+		t.Skip("symlinks not supported")
+	}
+
+	if _, err := mgr.Trust(filepath.Join(link, ".dotclaude")); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := mgr.CheckTrust(filepath.Join(project, ".dotclaude")); status != Trusted {
+		t.Errorf("CheckTrust() through the real path = %v, want Trusted", status)
+	}
+}
+
+func TestAutoActivatePolicy(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+	t.Setenv(AutoActivateEnv, "")
+
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+
+	if policy, err := mgr.AutoActivatePolicy(); err != nil || policy != AutoActivateNever {
+		t.Errorf("default policy = %v, %v, want never", policy, err)
+	}
+
+	if err := mgr.SetAutoActivatePolicy(AutoActivateAuto); err != nil {
+		t.Fatal(err)
+	}
+	if policy, _ := mgr.AutoActivatePolicy(); policy != AutoActivateAuto {
+		t.Errorf("stored policy = %v, want auto", policy)
+	}
+
+	t.Setenv(AutoActivateEnv, "prompt")
+	if policy, _ := mgr.AutoActivatePolicy(); policy != AutoActivatePrompt {
+		t.Errorf("policy with %s=prompt = %v, want prompt", AutoActivateEnv, policy)
+	}
+
+	t.Setenv(AutoActivateEnv, "always")
+	if _, err := mgr.AutoActivatePolicy(); err == nil {
+		t.Error("an invalid environment policy should be an error")
+	}
+
+	if err := mgr.SetAutoActivatePolicy("sometimes"); err == nil {
+		t.Error("SetAutoActivatePolicy() should reject unknown policies")
+	}
+}
+
+func TestParseAutoActivatePolicy(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    AutoActivatePolicy
+		wantErr bool
+	}{
+		{"never", AutoActivateNever, false},
+		{"Prompt", AutoActivatePrompt, false},
+		{" auto ", AutoActivateAuto, false},
+		{"", "", true},
+		{"yes", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAutoActivatePolicy(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseAutoActivatePolicy(%q) = %q, %v, want %q (error %v)", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
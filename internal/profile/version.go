@@ -0,0 +1,120 @@
+package profile
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// checkMinVersion returns an error if the profile's manifest requires a newer
+// dotclaude than the manager is running.
+func (m *Manager) checkMinVersion(profileName string, manifest *Manifest) error {
+	if m.Version == "" || manifest == nil || manifest.MinVersion == "" {
+		return nil
+	}
+
+	cmp, err := CompareVersions(m.Version, manifest.MinVersion)
+	if err != nil {
+		return fmt.Errorf("invalid min_version in profile '%s': %w", profileName, err)
+	}
+	if cmp < 0 {
+		return fmt.Errorf("profile '%s' requires dotclaude >= %s (running %s)", profileName, manifest.MinVersion, m.Version)
+	}
+	return nil
+}
+
+// CompareVersions compares two semantic versions ("1.2.3", "v1.2", "1.0.0-rc.2")
+// and returns -1, 0 or 1. Build metadata is ignored and a pre-release sorts
+// before its release, following semver precedence rules.
+func CompareVersions(a, b string) (int, error) {
+	va, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := 0; i < 3; i++ {
+		if va.core[i] != vb.core[i] {
+			return compareInts(va.core[i], vb.core[i]), nil
+		}
+	}
+	return comparePrerelease(va.pre, vb.pre), nil
+}
+
+// semver is a parsed semantic version.
+type semver struct {
+	core [3]int
+	pre  []string
+}
+
+// parseVersion parses "MAJOR[.MINOR[.PATCH]][-PRERELEASE][+BUILD]" with an optional "v".
+func parseVersion(s string) (semver, error) {
+	var v semver
+
+	str := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexByte(str, '+'); i >= 0 {
+		str = str[:i]
+	}
+	if i := strings.IndexByte(str, '-'); i >= 0 {
+		v.pre = strings.Split(str[i+1:], ".")
+		str = str[:i]
+	}
+
+	parts := strings.Split(str, ".")
+	if str == "" || len(parts) > 3 {
+		return v, fmt.Errorf("invalid version %q", s)
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid version %q", s)
+		}
+		v.core[i] = n
+	}
+	return v, nil
+}
+
+// comparePrerelease orders pre-release identifiers; no pre-release is highest.
+func comparePrerelease(a, b []string) int {
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return 1
+	case len(b) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a) && i < len(b); i++ {
+		na, errA := strconv.Atoi(a[i])
+		nb, errB := strconv.Atoi(b[i])
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				return compareInts(na, nb)
+			}
+		case errA == nil:
+			return -1 // numeric identifiers sort before alphanumeric ones
+		case errB == nil:
+			return 1
+		default:
+			if c := strings.Compare(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareInts(len(a), len(b))
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
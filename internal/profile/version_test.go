@@ -0,0 +1,76 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.0.0", "1.0.1", -1},
+		{"1.2.0", "1.10.0", -1},
+		{"2.0.0", "1.9.9", 1},
+		{"v1.2", "1.2.0", 0},
+		{"1.0.0-rc.2", "1.0.0", -1},
+		{"1.0.0-rc.2", "1.0.0-rc.10", -1},
+		{"1.0.0-alpha", "1.0.0-1", 1},
+		{"1.0.0-rc.1", "1.0.0-rc.1.1", -1},
+		{"1.0.0+build.5", "1.0.0", 0},
+	}
+
+	for _, tt := range tests {
+		got, err := CompareVersions(tt.a, tt.b)
+		if err != nil {
+			t.Errorf("CompareVersions(%q, %q) error = %v", tt.a, tt.b, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "one", "1.2.3.4", "1.-2"} {
+		if _, err := CompareVersions(bad, "1.0.0"); err == nil {
+			t.Errorf("CompareVersions(%q) should error", bad)
+		}
+	}
+}
+
+func TestActivateMinVersion(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	mgr := NewManager(tmpDir, filepath.Join(tmpDir, ".claude"))
+	mgr.Version = "1.0.0-rc.2"
+
+	writeProfile(t, tmpDir, "work", "")
+	writeProfile(t, tmpDir, "client", "work")
+	workManifest := filepath.Join(tmpDir, "profiles", "work", "profile.yaml")
+	if err := os.WriteFile(workManifest, []byte("min_version: 1.2.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := mgr.Activate("client")
+	if err == nil {
+		t.Fatal("Activate() should refuse a profile requiring a newer version")
+	}
+	if !strings.Contains(err.Error(), "profile 'work' requires dotclaude >= 1.2.0 (running 1.0.0-rc.2)") {
+		t.Errorf("Activate() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(mgr.ClaudeDir, "CLAUDE.md")); !os.IsNotExist(err) {
+		t.Error("Activate() should not write anything when refusing")
+	}
+
+	if err := os.WriteFile(workManifest, []byte("min_version: 1.0.0-rc.1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.Activate("client"); err != nil {
+		t.Errorf("Activate() error = %v", err)
+	}
+}
@@ -0,0 +1,12 @@
+// Package terminal holds terminal checks shared by the CLI and the hooks.
+package terminal
+
+import (
+	"os"
+)
+
+// StdinIsTerminal reports whether stdin is interactive, so prompts can be shown
+func StdinIsTerminal() bool {
+	fileInfo, err := os.Stdin.Stat()
+	return err == nil && (fileInfo.Mode()&os.ModeCharDevice) != 0
+}